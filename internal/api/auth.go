@@ -0,0 +1,30 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authCookie 携带访问令牌的Cookie名称，Web UI通过Cookie登录，<img>等无法设置请求头的请求也会携带
+const authCookie = "klaw_token"
+
+// SetAuthToken 设置API访问令牌，为空时没有请求被视为已登录
+func (s *Server) SetAuthToken(token string) {
+	s.authToken = token
+}
+
+// authenticated 判断请求是否携带了正确的访问令牌
+func (s *Server) authenticated(r *http.Request) bool {
+	if s.authToken == "" {
+		return false
+	}
+
+	token := ""
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	} else if cookie, err := r.Cookie(authCookie); err == nil {
+		token = cookie.Value
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kudig-io/klaw/internal/chart"
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+const (
+	// defaultChartWidth 和 defaultChartHeight 未指定尺寸时图表的像素大小
+	defaultChartWidth  = 800
+	defaultChartHeight = 400
+	// maxChartSize 图表宽高的上限
+	maxChartSize = 4000
+	// defaultChartCacheTTL 渲染结果的默认缓存时长
+	defaultChartCacheTTL = 30 * time.Second
+	// maxChartCacheEntries 缓存的最大图表数
+	maxChartCacheEntries = 256
+	// defaultChartLinkTTL 和 defaultMaxChartLinkTTL 签名URL的默认和最长有效期
+	defaultChartLinkTTL    = 24 * time.Hour
+	defaultMaxChartLinkTTL = 30 * 24 * time.Hour
+)
+
+// chartContentTypes 图表格式对应的Content-Type
+var chartContentTypes = map[chart.Format]string{
+	chart.FormatPNG: "image/png",
+	chart.FormatSVG: "image/svg+xml",
+}
+
+// chartSignatureParams 签名相关的查询参数，不参与缓存键
+var chartSignatureParams = []string{"expires", "signature"}
+
+type cachedChart struct {
+	data    []byte
+	expires time.Time
+}
+
+// chartCache 渲染结果的短时缓存，相同路径和查询参数的请求在有效期内直接返回缓存的图表
+type chartCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedChart
+}
+
+func newChartCache(ttl time.Duration) *chartCache {
+	if ttl <= 0 {
+		ttl = defaultChartCacheTTL
+	}
+	return &chartCache{ttl: ttl, entries: make(map[string]cachedChart)}
+}
+
+func (c *chartCache) get(key string, now time.Time) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// put 缓存图表，先清理过期的条目，仍然超过上限时随机淘汰
+func (c *chartCache) put(key string, data []byte, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < maxChartCacheEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+	c.entries[key] = cachedChart{data: data, expires: now.Add(c.ttl)}
+}
+
+// SetChartsConfig 设置图表渲染接口的签名密钥和缓存时长
+func (s *Server) SetChartsConfig(cfg config.ChartsConfig) {
+	s.chartsConfig = cfg
+	s.chartCache = newChartCache(cfg.CacheTTL)
+}
+
+// chartSignature 计算图表URL的签名，覆盖路径和除signature外的所有查询参数（包括expires）
+func chartSignature(key, path string, query url.Values) string {
+	signed := url.Values{}
+	for name, values := range query {
+		if name != "signature" {
+			signed[name] = values
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(path + "?" + signed.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyChartSignature 配置了签名密钥时校验请求的签名和过期时间，已登录的请求（如Web UI）不需要签名
+func (s *Server) verifyChartSignature(r *http.Request, now time.Time) error {
+	if s.chartsConfig.SigningKey == "" || s.authenticated(r) {
+		return nil
+	}
+
+	query := r.URL.Query()
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid expires parameter")
+	}
+	if now.Unix() > expires {
+		return fmt.Errorf("chart link has expired")
+	}
+	want := chartSignature(s.chartsConfig.SigningKey, r.URL.Path, query)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(want)) {
+		return fmt.Errorf("invalid chart signature")
+	}
+	return nil
+}
+
+// chartCacheKey 由路径和除签名参数外的查询参数组成，相对时间范围（如 from=now-6h）在缓存有效期内共用同一结果
+func chartCacheKey(r *http.Request) string {
+	query := r.URL.Query()
+	for _, param := range chartSignatureParams {
+		query.Del(param)
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// parseChartSize 解析width/height查询参数
+func parseChartSize(r *http.Request) (int, int, error) {
+	width, height := defaultChartWidth, defaultChartHeight
+	for name, target := range map[string]*int{"width": &width, "height": &height} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 || size > maxChartSize {
+			return 0, 0, fmt.Errorf("invalid %s parameter, must be between 1 and %d", name, maxChartSize)
+		}
+		*target = size
+	}
+	return width, height, nil
+}
+
+// clusterExists 判断集群是否已配置
+func (s *Server) clusterExists(clusterName string) bool {
+	if s.k8sManager == nil {
+		return true
+	}
+	for _, cluster := range s.k8sManager.GetClusters() {
+		if cluster.Name == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+// handleGetChart 按需渲染集群、节点、Pod、命名空间或资源使用图表
+func (s *Server) handleGetChart(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	if err := s.verifyChartSignature(r, now); err != nil {
+		s.respondError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterName := vars["cluster"]
+	format := chart.Format(vars["format"])
+
+	key := chartCacheKey(r)
+	if data, ok := s.chartCache.get(key, now); ok {
+		s.writeChart(w, format, data)
+		return
+	}
+
+	if !s.clusterExists(clusterName) {
+		s.respondError(w, "Cluster not found", http.StatusNotFound)
+		return
+	}
+	from, to, err := parseTimeRange(r, time.Hour)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	width, height, err := parseChartSize(r)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render, err := s.chartRenderer(vars["chart"], clusterName, r.URL.Query())
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := s.monitoringService.GetMetricsHistoryRange(clusterName, from, to, chart.DefaultStep(from, to))
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	generator := chart.NewGenerator(width, height)
+	generator.SetFormat(format)
+	data, err := render(generator, history, from, to)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.chartCache.put(key, data, now)
+	s.writeChart(w, format, data)
+}
+
+// chartRenderFunc 使用指标历史渲染图表
+type chartRenderFunc func(generator *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error)
+
+// chartRenderer 返回图表名称对应的渲染函数，node和namespace图表需要同名的查询参数
+func (s *Server) chartRenderer(name, clusterName string, query url.Values) (chartRenderFunc, error) {
+	switch name {
+	case "cluster":
+		return func(g *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+			return g.GenerateClusterMetricsChart(clusterName, history, from, to)
+		}, nil
+	case "node":
+		node := query.Get("node")
+		if node == "" {
+			return nil, fmt.Errorf("node chart requires node parameter")
+		}
+		return func(g *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+			return g.GenerateNodeMetricsChart(node, history, from, to)
+		}, nil
+	case "pod":
+		return func(g *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+			return g.GeneratePodMetricsChart(clusterName, history, from, to)
+		}, nil
+	case "namespace":
+		namespace := query.Get("namespace")
+		if namespace == "" {
+			return nil, fmt.Errorf("namespace chart requires namespace parameter")
+		}
+		return func(g *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+			return g.GenerateNamespaceMetricsChart(clusterName, namespace, history, from, to)
+		}, nil
+	case "resource-usage":
+		return func(g *chart.Generator, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+			return g.GenerateResourceUsageChart(clusterName, history, from, to)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown chart: %s, expected cluster, node, pod, namespace or resource-usage", name)
+	}
+}
+
+// writeChart 输出图表，允许浏览器在缓存有效期内复用
+func (s *Server) writeChart(w http.ResponseWriter, format chart.Format, data []byte) {
+	w.Header().Set("Content-Type", chartContentTypes[format])
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(s.chartCache.ttl/time.Second)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// signChartRequest 生成签名URL的请求，URL为 /api/charts/ 下的图表地址，TTL为有效期（如24h）
+type signChartRequest struct {
+	URL   string `json:"url"`
+	TTL   string `json:"ttl"`
+	Actor string `json:"actor"`
+}
+
+// handleSignChart 为图表地址生成带签名和过期时间的URL，持有URL即可在有效期内访问图表。
+// 只有已登录的请求可以生成链接，否则签名起不到访问控制的作用
+func (s *Server) handleSignChart(w http.ResponseWriter, r *http.Request) {
+	if s.chartsConfig.SigningKey == "" {
+		s.respondError(w, "chart signing is not configured", http.StatusBadRequest)
+		return
+	}
+	if !s.authenticated(r) {
+		s.respondError(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req signChartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	link, err := url.Parse(req.URL)
+	if err != nil || !strings.HasPrefix(link.Path, "/api/charts/") {
+		s.respondError(w, "url must be a chart path under /api/charts/", http.StatusBadRequest)
+		return
+	}
+
+	maxTTL := s.chartsConfig.MaxLinkTTL
+	if maxTTL <= 0 {
+		maxTTL = defaultMaxChartLinkTTL
+	}
+	ttl := defaultChartLinkTTL
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > maxTTL {
+			s.respondError(w, fmt.Sprintf("invalid ttl, must be a positive duration no longer than %s", maxTTL), http.StatusBadRequest)
+			return
+		}
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	query := link.Query()
+	query.Del("signature")
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", chartSignature(s.chartsConfig.SigningKey, link.Path, query))
+	signed := link.Path + "?" + query.Encode()
+
+	actor := req.Actor
+	if actor == "" {
+		actor = "api"
+	}
+	s.monitoringService.RecordAudit(actor, "chart.sign", link.Path, fmt.Sprintf("expires %s", expiresAt.Format(time.RFC3339)))
+	s.respondJSON(w, map[string]string{"url": signed, "expires_at": expiresAt.Format(time.RFC3339)}, http.StatusOK)
+}
@@ -6,29 +6,39 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/kudig-io/klaw/internal/config"
 	"github.com/kudig-io/klaw/internal/kubernetes"
 	"github.com/kudig-io/klaw/internal/metrics"
 	"github.com/kudig-io/klaw/internal/monitoring"
+	"github.com/kudig-io/klaw/internal/storage"
 )
 
+// eventStreamHeartbeat 实时事件流的心跳间隔
+const eventStreamHeartbeat = 15 * time.Second
+
 type Server struct {
-	k8sManager       *kubernetes.Manager
+	k8sManager        *kubernetes.Manager
 	monitoringService *monitoring.Service
-	resources        *kubernetes.Resources
+	resources         *kubernetes.Resources
 	metricsCollector  *metrics.Collector
-	router           *mux.Router
+	router            *mux.Router
+	chartsConfig      config.ChartsConfig
+	chartCache        *chartCache
+	authToken         string
 }
 
 func NewServer(k8sManager *kubernetes.Manager, monitoringService *monitoring.Service) *Server {
 	return &Server{
-		k8sManager:       k8sManager,
+		k8sManager:        k8sManager,
 		monitoringService: monitoringService,
-		resources:        kubernetes.NewResources(k8sManager),
+		resources:         kubernetes.NewResources(k8sManager),
 		metricsCollector:  metrics.NewCollector(k8sManager),
-		router:           mux.NewRouter(),
+		router:            mux.NewRouter(),
+		chartCache:        newChartCache(defaultChartCacheTTL),
 	}
 }
 
@@ -38,6 +48,7 @@ func (s *Server) SetupRoutes() {
 	s.router.HandleFunc("/api/clusters/{name}/status", s.handleGetClusterStatus).Methods("GET")
 	s.router.HandleFunc("/api/clusters/{name}/metrics", s.handleGetClusterMetrics).Methods("GET")
 	s.router.HandleFunc("/api/clusters/{name}/namespaces", s.handleGetNamespaces).Methods("GET")
+	s.router.HandleFunc("/api/clusters/{cluster}/namespaces/{namespace}/metrics", s.handleGetNamespaceMetrics).Methods("GET")
 
 	s.router.HandleFunc("/api/clusters/{cluster}/pods", s.handleListPods).Methods("GET")
 	s.router.HandleFunc("/api/clusters/{cluster}/namespaces/{namespace}/pods", s.handleListPods).Methods("GET")
@@ -51,14 +62,45 @@ func (s *Server) SetupRoutes() {
 
 	s.router.HandleFunc("/api/clusters/{cluster}/events", s.handleGetEvents).Methods("GET")
 	s.router.HandleFunc("/api/clusters/{cluster}/namespaces/{namespace}/events", s.handleGetEvents).Methods("GET")
+	s.router.HandleFunc("/api/clusters/{cluster}/events/warnings", s.handleGetEventAggregates).Methods("GET")
+	s.router.HandleFunc("/api/events/stream", s.handleEventStream).Methods("GET")
 
+	s.router.HandleFunc("/api/monitoring/rules", s.handleGetAlertRules).Methods("GET")
 	s.router.HandleFunc("/api/monitoring/{cluster}/status", s.handleGetMonitorStatus).Methods("GET")
 	s.router.HandleFunc("/api/monitoring/{cluster}/alerts", s.handleGetMonitorAlerts).Methods("GET")
 	s.router.HandleFunc("/api/monitoring/{cluster}/history", s.handleGetMetricsHistory).Methods("GET")
 
+	s.router.HandleFunc("/api/alerts/{id}", s.handleGetAlert).Methods("GET")
+	s.router.HandleFunc("/api/alerts/{id}/ack", s.handleAckAlert).Methods("POST")
+	s.router.HandleFunc("/api/alerts/{id}/assign", s.handleAssignAlert).Methods("POST")
+	s.router.HandleFunc("/api/alerts/{id}/resolve", s.handleResolveAlert).Methods("POST")
+	s.router.HandleFunc("/api/alerts/{id}/comments", s.handleCommentAlert).Methods("POST")
+
+	s.router.HandleFunc("/api/webhooks/alertmanager", s.handleAlertmanagerWebhook).Methods("POST")
+
+	s.router.HandleFunc("/api/silences", s.handleGetSilences).Methods("GET")
+	s.router.HandleFunc("/api/silences", s.handleCreateSilence).Methods("POST")
+	s.router.HandleFunc("/api/silences/{id}", s.handleExpireSilence).Methods("DELETE")
+
+	s.router.HandleFunc("/api/certificates", s.handleGetCertificates).Methods("GET")
+
+	s.router.HandleFunc("/api/slos", s.handleGetSLOs).Methods("GET")
+
+	s.router.HandleFunc("/api/charts/sign", s.handleSignChart).Methods("POST")
+	s.router.HandleFunc("/api/charts/{cluster}/{chart:[a-z-]+}.{format:png|svg}", s.handleGetChart).Methods("GET")
+
+	s.router.HandleFunc("/api/audit", s.handleGetAuditLog).Methods("GET")
+
+	s.router.HandleFunc("/metrics", s.handlePrometheusMetrics).Methods("GET")
+
 	s.router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist"))).Methods("GET")
 }
 
+// Handler 返回处理API请求的http.Handler，需要先调用SetupRoutes
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
 func (s *Server) Start(port int) error {
 	s.SetupRoutes()
 	addr := fmt.Sprintf(":%d", port)
@@ -180,6 +222,26 @@ func (s *Server) handleGetNamespaces(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, namespaces, http.StatusOK)
 }
 
+func (s *Server) handleGetNamespaceMetrics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterName := vars["cluster"]
+	namespace := vars["namespace"]
+
+	clusterMetrics, err := s.metricsCollector.CollectClusterMetrics(clusterName)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	namespaceMetrics := clusterMetrics.Namespace(namespace)
+	if namespaceMetrics == nil {
+		s.respondError(w, "Namespace not found", http.StatusNotFound)
+		return
+	}
+
+	s.respondJSON(w, namespaceMetrics, http.StatusOK)
+}
+
 func (s *Server) handleListPods(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterName := vars["cluster"]
@@ -243,6 +305,8 @@ func (s *Server) handleDeletePod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.monitoringService.RecordAudit("api", "pod.delete", fmt.Sprintf("%s/%s/%s", clusterName, namespace, podName), r.RemoteAddr)
+
 	s.respondJSON(w, map[string]string{"message": "Pod deleted successfully"}, http.StatusOK)
 }
 
@@ -291,6 +355,11 @@ func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
 	clusterName := vars["cluster"]
 	namespace := vars["namespace"]
 
+	if isEventSearch(r) {
+		s.searchEvents(w, r, clusterName, namespace)
+		return
+	}
+
 	events, err := s.resources.ListEvents(clusterName, namespace)
 	if err != nil {
 		s.respondError(w, err.Error(), http.StatusInternalServerError)
@@ -306,8 +375,8 @@ func (s *Server) handleGetMonitorStatus(w http.ResponseWriter, r *http.Request)
 
 	history := s.monitoringService.GetMetricsHistory(clusterName)
 	status := map[string]interface{}{
-		"cluster":  clusterName,
-		"active":   len(history) > 0,
+		"cluster":    clusterName,
+		"active":     len(history) > 0,
 		"dataPoints": len(history),
 	}
 
@@ -319,7 +388,7 @@ func (s *Server) handleGetMonitorAlerts(w http.ResponseWriter, r *http.Request)
 	clusterName := vars["cluster"]
 
 	alerts := s.monitoringService.GetAlerts()
-	var clusterAlerts []monitoring.Alert
+	var clusterAlerts []*monitoring.Alert
 	for _, alert := range alerts {
 		if alert.Cluster == clusterName {
 			clusterAlerts = append(clusterAlerts, alert)
@@ -333,6 +402,439 @@ func (s *Server) handleGetMetricsHistory(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	clusterName := vars["cluster"]
 
-	history := s.monitoringService.GetMetricsHistory(clusterName)
-	s.respondJSON(w, history, http.StatusOK)
+	from, to, err := parseTimeRange(r, time.Hour)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	step, err := parseStep(r.URL.Query().Get("step"), from, to)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := metrics.FieldNames()
+	if value := r.URL.Query().Get("fields"); value != "" {
+		fields = strings.Split(value, ",")
+	}
+
+	history, err := s.monitoringService.GetMetricsHistoryRange(clusterName, from, to, step)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	series, err := metrics.Downsample(clusterName, history, fields, from, to, step)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.respondJSON(w, series, http.StatusOK)
+}
+
+// maxHistoryPoints 单次历史查询返回的最大点数
+const maxHistoryPoints = 11000
+
+// parseStep 解析step参数，支持Go时长格式（如30s、5m）或秒数，未指定时按约200个点自动计算
+func parseStep(value string, from, to time.Time) (time.Duration, error) {
+	var step time.Duration
+	if value == "" {
+		step = (to.Sub(from) / 200).Round(time.Second)
+	} else if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		step = time.Duration(seconds * float64(time.Second))
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid step parameter: %v", err)
+		}
+		step = d
+	}
+
+	if step < time.Second {
+		step = time.Second
+	}
+	if to.Sub(from)/step > maxHistoryPoints {
+		return 0, fmt.Errorf("too many points, increase step or narrow the time range")
+	}
+
+	return step, nil
+}
+
+func (s *Server) handleGetAlertRules(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, s.monitoringService.GetRuleStates(), http.StatusOK)
+}
+
+func (s *Server) handleGetAlert(w http.ResponseWriter, r *http.Request) {
+	alert, err := s.monitoringService.GetAlert(mux.Vars(r)["id"])
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.respondJSON(w, alert, http.StatusOK)
+}
+
+// alertActionRequest 告警操作请求，actor为操作者
+type alertActionRequest struct {
+	Actor    string `json:"actor"`
+	Assignee string `json:"assignee"`
+	Comment  string `json:"comment"`
+}
+
+// decodeAlertAction 解析告警操作请求并确认告警存在
+func (s *Server) decodeAlertAction(w http.ResponseWriter, r *http.Request) (string, *alertActionRequest, bool) {
+	id := mux.Vars(r)["id"]
+	if _, err := s.monitoringService.GetAlert(id); err != nil {
+		s.respondError(w, err.Error(), http.StatusNotFound)
+		return "", nil, false
+	}
+
+	var req alertActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return "", nil, false
+	}
+	if req.Actor == "" {
+		s.respondError(w, "actor is required", http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	return id, &req, true
+}
+
+// respondAlertAction 返回告警操作结果，成功时记录审计日志并返回最新的告警
+func (s *Server) respondAlertAction(w http.ResponseWriter, id string, req *alertActionRequest, action, detail string, err error) {
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.monitoringService.RecordAudit(req.Actor, action, id, detail)
+	alert, err := s.monitoringService.GetAlert(id)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.respondJSON(w, alert, http.StatusOK)
+}
+
+func (s *Server) handleAckAlert(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := s.decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	s.respondAlertAction(w, id, req, "alert.ack", "", s.monitoringService.AckAlert(id, req.Actor))
+}
+
+func (s *Server) handleAssignAlert(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := s.decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	s.respondAlertAction(w, id, req, "alert.assign", req.Assignee, s.monitoringService.AssignAlert(id, req.Actor, req.Assignee))
+}
+
+func (s *Server) handleResolveAlert(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := s.decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	s.respondAlertAction(w, id, req, "alert.resolve", "", s.monitoringService.ResolveAlert(id, req.Actor))
+}
+
+func (s *Server) handleCommentAlert(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := s.decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	s.respondAlertAction(w, id, req, "alert.comment", req.Comment, s.monitoringService.CommentAlert(id, req.Actor, req.Comment))
+}
+
+// handleAlertmanagerWebhook 接收Alertmanager webhook推送的告警，cluster查询参数指定没有cluster标签的告警所属集群
+func (s *Server) handleAlertmanagerWebhook(w http.ResponseWriter, r *http.Request) {
+	var payload monitoring.AlertmanagerWebhook
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.respondError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	alerts, err := payload.ExternalAlerts(r.URL.Query().Get("cluster"))
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.monitoringService.IngestAlerts(monitoring.SourceAlertmanager, alerts)
+	s.respondJSON(w, map[string]int{"received": len(alerts)}, http.StatusOK)
+}
+
+func (s *Server) handleGetSilences(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, s.monitoringService.GetSilences(), http.StatusOK)
+}
+
+// createSilenceRequest 创建静默规则的请求，ends_at和duration二选一
+type createSilenceRequest struct {
+	monitoring.Matcher
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Duration  string    `json:"duration"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+}
+
+func (s *Server) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	var req createSilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	silence := monitoring.Silence{
+		Matcher:   req.Matcher,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+	}
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			s.respondError(w, fmt.Sprintf("invalid duration: %v", err), http.StatusBadRequest)
+			return
+		}
+		start := req.StartsAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		silence.StartsAt = start
+		silence.EndsAt = start.Add(duration)
+	}
+
+	created, err := s.monitoringService.CreateSilence(silence)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.monitoringService.RecordAudit(created.CreatedBy, "silence.create", created.ID, created.Matcher.String())
+	s.respondJSON(w, created, http.StatusCreated)
+}
+
+func (s *Server) handleExpireSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := s.monitoringService.ExpireSilence(id); err != nil {
+		s.respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.monitoringService.RecordAudit("api", "silence.expire", id, r.RemoteAddr)
+	s.respondJSON(w, map[string]string{"message": "Silence expired successfully"}, http.StatusOK)
+}
+
+func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r, 24*time.Hour)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := s.monitoringService.GetAuditLog(from, to)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.respondJSON(w, entries, http.StatusOK)
+}
+
+// handleGetCertificates 获取所有集群的TLS证书，按过期时间排序，within_days指定时只返回在该天数内过期的证书
+func (s *Server) handleGetCertificates(w http.ResponseWriter, r *http.Request) {
+	var within time.Duration
+	if value := r.URL.Query().Get("within_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			s.respondError(w, "invalid within_days parameter", http.StatusBadRequest)
+			return
+		}
+		within = time.Duration(days) * 24 * time.Hour
+	}
+
+	certificates := s.monitoringService.GetCertificates(within)
+	if certificates == nil {
+		certificates = []monitoring.CertificateInfo{}
+	}
+	s.respondJSON(w, certificates, http.StatusOK)
+}
+
+// handleGetSLOs 获取所有SLO的达成率、剩余错误预算和燃烧率
+func (s *Server) handleGetSLOs(w http.ResponseWriter, r *http.Request) {
+	slos := s.monitoringService.GetSLOStatus()
+	if slos == nil {
+		slos = []monitoring.SLOStatus{}
+	}
+	s.respondJSON(w, slos, http.StatusOK)
+}
+
+// eventSearchParams 指定任一参数时从事件归档中查询，而不是列出API Server中的当前事件
+var eventSearchParams = []string{"from", "to", "reason", "namespace", "kind", "object", "type", "limit"}
+
+// isEventSearch 判断事件请求是否为归档查询
+func isEventSearch(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, param := range eventSearchParams {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchEvents 按集群、命名空间、对象、原因和时间范围查询归档的事件，默认查询最近24小时
+func (s *Server) searchEvents(w http.ResponseWriter, r *http.Request, clusterName, namespace string) {
+	from, to, err := parseTimeRange(r, 24*time.Hour)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	query := storage.EventQuery{
+		Cluster:   clusterName,
+		Namespace: namespace,
+		Kind:      params.Get("kind"),
+		Name:      params.Get("object"),
+		Reason:    params.Get("reason"),
+		Type:      params.Get("type"),
+		From:      from,
+		To:        to,
+	}
+	if query.Namespace == "" {
+		query.Namespace = params.Get("namespace")
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			s.respondError(w, "invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	records, err := s.monitoringService.SearchEvents(query)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if records == nil {
+		records = []storage.EventRecord{}
+	}
+	s.respondJSON(w, records, http.StatusOK)
+}
+
+// handleGetEventAggregates 获取集群中按涉及对象和原因聚合的Warning事件
+func (s *Server) handleGetEventAggregates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	aggregates := s.monitoringService.GetEventAggregates(vars["cluster"])
+	if aggregates == nil {
+		aggregates = []monitoring.EventAggregate{}
+	}
+	s.respondJSON(w, aggregates, http.StatusOK)
+}
+
+// handleEventStream 以Server-Sent Events推送实时的Warning事件，可通过cluster参数只接收指定集群的事件
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	clusterName := r.URL.Query().Get("cluster")
+
+	events, unsubscribe := s.monitoringService.SubscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// 定期发送注释行，避免空闲连接被代理断开
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if clusterName != "" && event.Cluster != clusterName {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
+
+// parseTimeRange 解析from/to查询参数，支持RFC3339格式、now和相对时间（如now-6h），未指定时默认查询最近defaultRange时长
+func parseTimeRange(r *http.Request, defaultRange time.Duration) (time.Time, time.Time, error) {
+	now := time.Now()
+	to := now
+	if value := r.URL.Query().Get("to"); value != "" {
+		t, err := parseTimeParam(value, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to parameter: %v", err)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultRange)
+	if value := r.URL.Query().Get("from"); value != "" {
+		t, err := parseTimeParam(value, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from parameter: %v", err)
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+
+	return from, to, nil
+}
+
+// parseTimeParam 解析RFC3339格式的时间，或相对于当前时间的 now、now-<duration>
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "now" {
+		return now, nil
+	}
+	if offset, ok := strings.CutPrefix(value, "now-"); ok {
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.monitoringService.WriteMetrics(w); err != nil {
+		log.Printf("Failed to write prometheus metrics: %v", err)
+	}
 }
@@ -0,0 +1,213 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/api"
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+const testToken = "secret-token"
+
+// newChartServer 创建使用bbolt存储中最近一小时采样的API服务，集群prod有每分钟一个采样
+func newChartServer(t *testing.T, charts config.ChartsConfig) (*httptest.Server, *storage.BoltStore) {
+	t.Helper()
+
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "klaw.db"), config.RetentionConfig{
+		Raw: 24 * time.Hour, FiveMinute: 24 * time.Hour, OneHour: 24 * time.Hour, Audit: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	for i := 60; i > 0; i-- {
+		appendSample(t, store, now.Add(-time.Duration(i)*time.Minute), 40)
+	}
+
+	manager := kubernetes.NewManagerWithClients([]config.ClusterConfig{{Name: "prod"}}, nil)
+	service := monitoring.NewService(manager)
+	if err := service.SetStore(store); err != nil {
+		t.Fatalf("SetStore() error = %v", err)
+	}
+
+	server := api.NewServer(manager, service)
+	server.SetChartsConfig(charts)
+	server.SetAuthToken(testToken)
+	server.SetupRoutes()
+
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	return httpServer, store
+}
+
+func appendSample(t *testing.T, store *storage.BoltStore, timestamp time.Time, cpuUsage int64) {
+	t.Helper()
+	err := store.AppendSample(&metrics.ClusterMetrics{
+		ClusterName: "prod",
+		Timestamp:   timestamp,
+		Resources:   metrics.ResourceMetrics{CPUCapacity: 100, CPUUsage: cpuUsage, MemoryCapacity: 100, MemoryUsage: 60},
+	})
+	if err != nil {
+		t.Fatalf("AppendSample() error = %v", err)
+	}
+}
+
+// get 请求图表，返回状态码、Content-Type和响应体
+func get(t *testing.T, rawURL string, cookie *http.Cookie) (int, string, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), body
+}
+
+// sign 请求生成签名链接，token为空时不携带Authorization头
+func sign(t *testing.T, server *httptest.Server, token, chartURL, ttl string) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"url": chartURL, "ttl": ttl, "actor": "alice"})
+	req, _ := http.NewRequest("POST", server.URL+"/api/charts/sign", bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/charts/sign error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]string
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result["url"]
+}
+
+func TestCharts_Render(t *testing.T) {
+	server, _ := newChartServer(t, config.ChartsConfig{})
+
+	status, contentType, body := get(t, server.URL+"/api/charts/prod/cluster.png?from=now-1h&width=320&height=200", nil)
+	if status != http.StatusOK || contentType != "image/png" || !bytes.HasPrefix(body, []byte("\x89PNG")) {
+		t.Fatalf("expected png chart, got %d %s", status, contentType)
+	}
+	status, contentType, body = get(t, server.URL+"/api/charts/prod/namespace.svg?namespace=web", nil)
+	if status != http.StatusOK || contentType != "image/svg+xml" || !strings.Contains(string(body), "Namespace - prod/web") {
+		t.Fatalf("expected svg chart, got %d %s", status, contentType)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"unknown cluster", "/api/charts/staging/cluster.png", http.StatusNotFound},
+		{"unknown chart", "/api/charts/prod/disk.png", http.StatusBadRequest},
+		{"node without name", "/api/charts/prod/node.png", http.StatusBadRequest},
+		{"width too large", "/api/charts/prod/cluster.png?width=4001", http.StatusBadRequest},
+		{"invalid height", "/api/charts/prod/cluster.png?height=abc", http.StatusBadRequest},
+		{"invalid range", "/api/charts/prod/cluster.png?from=now&to=now-1h", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _, body := get(t, server.URL+tt.path, nil); status != tt.status {
+				t.Errorf("GET %s = %d (%s), want %d", tt.path, status, body, tt.status)
+			}
+		})
+	}
+}
+
+func TestCharts_SignedLinks(t *testing.T) {
+	server, _ := newChartServer(t, config.ChartsConfig{SigningKey: "key", MaxLinkTTL: 48 * time.Hour})
+	chartPath := "/api/charts/prod/cluster.svg?from=now-1h"
+
+	if status, _, _ := get(t, server.URL+chartPath, nil); status != http.StatusForbidden {
+		t.Errorf("expected unsigned request to be rejected, got %d", status)
+	}
+	// Web UI通过Cookie登录，不需要签名
+	if status, _, _ := get(t, server.URL+chartPath, &http.Cookie{Name: "klaw_token", Value: testToken}); status != http.StatusOK {
+		t.Errorf("expected authenticated request without signature to succeed, got %d", status)
+	}
+
+	if status, _ := sign(t, server, "", chartPath, "1h"); status != http.StatusUnauthorized {
+		t.Errorf("expected signing without token to be rejected, got %d", status)
+	}
+	if status, _ := sign(t, server, "wrong", chartPath, "1h"); status != http.StatusUnauthorized {
+		t.Errorf("expected signing with wrong token to be rejected, got %d", status)
+	}
+	if status, _ := sign(t, server, testToken, chartPath, "72h"); status != http.StatusBadRequest {
+		t.Errorf("expected ttl over max_link_ttl to be rejected, got %d", status)
+	}
+	if status, _ := sign(t, server, testToken, "/api/clusters", "1h"); status != http.StatusBadRequest {
+		t.Errorf("expected non-chart url to be rejected, got %d", status)
+	}
+
+	status, signed := sign(t, server, testToken, chartPath, "1h")
+	if status != http.StatusOK || !strings.Contains(signed, "signature=") {
+		t.Fatalf("expected signed url, got %d %q", status, signed)
+	}
+	if status, _, _ := get(t, server.URL+signed, nil); status != http.StatusOK {
+		t.Errorf("expected signed url to be accepted, got %d", status)
+	}
+
+	// 修改任一参数都会使签名失效
+	link, _ := url.Parse(signed)
+	query := link.Query()
+	query.Set("width", "1000")
+	link.RawQuery = query.Encode()
+	if status, _, _ := get(t, server.URL+link.String(), nil); status != http.StatusForbidden {
+		t.Errorf("expected tampered url to be rejected, got %d", status)
+	}
+
+	_, expiring := sign(t, server, testToken, chartPath, "1ns")
+	time.Sleep(1100 * time.Millisecond)
+	if status, _, body := get(t, server.URL+expiring, nil); status != http.StatusForbidden || !strings.Contains(string(body), "expired") {
+		t.Errorf("expected expired url to be rejected, got %d %s", status, body)
+	}
+}
+
+func TestCharts_Cache(t *testing.T) {
+	server, store := newChartServer(t, config.ChartsConfig{SigningKey: "key", CacheTTL: time.Minute})
+	chartPath := "/api/charts/prod/cluster.svg?from=now-1h"
+
+	_, first := sign(t, server, testToken, chartPath, "1h")
+	_, _, before := get(t, server.URL+first, nil)
+
+	// 新的采样在缓存有效期内不会出现在图表中，签名参数不同的链接共用同一个缓存
+	appendSample(t, store, time.Now(), 95)
+	_, second := sign(t, server, testToken, chartPath, "2h")
+	if first == second {
+		t.Fatal("expected different signed urls for different ttls")
+	}
+	status, _, after := get(t, server.URL+second, nil)
+	if status != http.StatusOK || !bytes.Equal(before, after) {
+		t.Errorf("expected cached chart for the same chart parameters, got %d", status)
+	}
+
+	// 图表参数不同时重新渲染
+	_, resized := sign(t, server, testToken, chartPath+"&width=640", "1h")
+	if _, _, body := get(t, server.URL+resized, nil); bytes.Equal(before, body) {
+		t.Error("expected a new render for different chart parameters")
+	}
+}
@@ -1,41 +1,73 @@
 package chart
 
 import (
-	"bytes"
 	"fmt"
 	"time"
 
 	"github.com/kudig-io/klaw/internal/metrics"
 )
 
+// Format 图表的输出格式
+type Format string
+
+const (
+	// FormatPNG PNG位图，用于发送到钉钉和飞书
+	FormatPNG Format = "png"
+	// FormatSVG SVG矢量图
+	FormatSVG Format = "svg"
+	// FormatText 由Unicode方块或盲文字符组成的文本迷你图，用于不能发送图片的聊天群
+	FormatText Format = "text"
+)
+
 // Generator 图表生成器
 type Generator struct {
 	width  int
 	height int
+	format Format
+	// textStyle 和 textWidth 文本迷你图的字符样式和每行最大宽度
+	textStyle TextStyle
+	textWidth int
 }
 
-// NewGenerator 创建图表生成器
+// NewGenerator 创建图表生成器，默认输出PNG
 func NewGenerator(width, height int) *Generator {
 	return &Generator{
-		width:  width,
-		height: height,
+		width:     width,
+		height:    height,
+		format:    FormatPNG,
+		textStyle: TextStyleBlocks,
+		textWidth: defaultTextWidth,
 	}
 }
 
+// SetFormat 设置图表的输出格式
+func (g *Generator) SetFormat(format Format) {
+	g.format = format
+}
+
+// SetTextOptions 设置文本迷你图的字符样式和每行最大宽度（字符数），width不大于0时使用默认宽度
+func (g *Generator) SetTextOptions(style TextStyle, width int) {
+	g.textStyle = style
+	g.textWidth = width
+}
+
 // ChartData 图表数据
 type ChartData struct {
-	Title       string
-	XLabels     []string
-	YLabel      string
-	Datasets    []Dataset
-	ShowLegend  bool
+	Title      string
+	XLabels    []string
+	XLabel     string
+	YLabel     string
+	Datasets   []Dataset
+	ShowLegend bool
 }
 
-// Dataset 数据集
+// Dataset 数据集，Data中的NaN表示缺失的数据点
 type Dataset struct {
-	Label    string
-	Data     []float64
-	Color    string
+	Label string
+	Data  []float64
+	// Color 颜色，格式为 #RRGGBB，为空时使用默认调色板
+	Color string
+	// DataType 图表类型：line（折线，默认）、bar（柱状）或 area（按顺序堆叠的面积）
 	DataType string
 }
 
@@ -44,23 +76,25 @@ func (g *Generator) GenerateChart(data ChartData) ([]byte, error) {
 	return g.generateChart(data)
 }
 
-// GenerateClusterMetricsChart 生成集群指标图表
-func (g *Generator) GenerateClusterMetricsChart(metrics *metrics.ClusterMetrics) ([]byte, error) {
+// GenerateClusterMetricsChart 根据指标历史生成集群CPU和内存使用率图表
+func (g *Generator) GenerateClusterMetricsChart(clusterName string, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+	buckets := newTimeBuckets(history, from, to)
 	chartData := ChartData{
-		Title:      fmt.Sprintf("集群监控 - %s", metrics.ClusterName),
-		XLabels:    g.generateTimeLabels(),
-		YLabel:     "使用率 (%)",
+		Title:      fmt.Sprintf("Cluster - %s", clusterName),
+		XLabels:    buckets.labels(),
+		XLabel:     "Time",
+		YLabel:     "Usage (%)",
 		ShowLegend: true,
 		Datasets: []Dataset{
 			{
-				Label:    "CPU使用率",
-				Data:     g.generateCPUMetrics(metrics),
+				Label:    "CPU usage",
+				Data:     buckets.values(history, clusterField("cpu.usage_percent")),
 				Color:    "#FF6B6B",
 				DataType: "line",
 			},
 			{
-				Label:    "内存使用率",
-				Data:     g.generateMemoryMetrics(metrics),
+				Label:    "Memory usage",
+				Data:     buckets.values(history, clusterField("memory.usage_percent")),
 				Color:    "#4ECDC4",
 				DataType: "line",
 			},
@@ -70,31 +104,25 @@ func (g *Generator) GenerateClusterMetricsChart(metrics *metrics.ClusterMetrics)
 	return g.GenerateChart(chartData)
 }
 
-// GenerateNodeMetricsChart 生成节点指标图表
-func (g *Generator) GenerateNodeMetricsChart(nodeName string, metrics []*metrics.NodeDetail) ([]byte, error) {
-	cpuData := make([]float64, len(metrics))
-	memData := make([]float64, len(metrics))
-
-	for i, metric := range metrics {
-		cpuData[i] = metric.CPUUsagePercent
-		memData[i] = metric.MemoryUsagePercent
-	}
-
+// GenerateNodeMetricsChart 根据指标历史生成节点CPU和内存使用率图表，节点不在采样中的时段显示为空白
+func (g *Generator) GenerateNodeMetricsChart(nodeName string, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+	buckets := newTimeBuckets(history, from, to)
 	chartData := ChartData{
-		Title:      fmt.Sprintf("节点监控 - %s", nodeName),
-		XLabels:    g.generateTimeLabels(),
-		YLabel:     "使用率 (%)",
+		Title:      fmt.Sprintf("Node - %s", nodeName),
+		XLabels:    buckets.labels(),
+		XLabel:     "Time",
+		YLabel:     "Usage (%)",
 		ShowLegend: true,
 		Datasets: []Dataset{
 			{
-				Label:    "CPU使用率",
-				Data:     cpuData,
+				Label:    "CPU usage",
+				Data:     buckets.values(history, nodeField(nodeName, "cpu.usage_percent")),
 				Color:    "#FF6B6B",
 				DataType: "line",
 			},
 			{
-				Label:    "内存使用率",
-				Data:     memData,
+				Label:    "Memory usage",
+				Data:     buckets.values(history, nodeField(nodeName, "memory.usage_percent")),
 				Color:    "#4ECDC4",
 				DataType: "line",
 			},
@@ -104,37 +132,39 @@ func (g *Generator) GenerateNodeMetricsChart(nodeName string, metrics []*metrics
 	return g.GenerateChart(chartData)
 }
 
-// GeneratePodMetricsChart 生成Pod指标图表
-func (g *Generator) GeneratePodMetricsChart(podName string, metrics []*metrics.PodDetail) ([]byte, error) {
-	runningData := make([]float64, len(metrics))
-	failedData := make([]float64, len(metrics))
-
-	for i, metric := range metrics {
-		if metric.Status == "Running" {
-			runningData[i] = 1
-		}
-		if metric.Status == "Failed" {
-			failedData[i] = 1
-		}
-	}
-
+// GeneratePodMetricsChart 根据指标历史生成集群中各阶段Pod数量的堆叠面积图
+func (g *Generator) GeneratePodMetricsChart(clusterName string, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+	buckets := newTimeBuckets(history, from, to)
 	chartData := ChartData{
-		Title:      fmt.Sprintf("Pod监控 - %s", podName),
-		XLabels:    g.generateTimeLabels(),
-		YLabel:     "数量",
+		Title:      fmt.Sprintf("Pods - %s", clusterName),
+		XLabels:    buckets.labels(),
+		XLabel:     "Time",
+		YLabel:     "Count",
 		ShowLegend: true,
 		Datasets: []Dataset{
 			{
-				Label:    "运行中",
-				Data:     runningData,
+				Label:    "Running",
+				Data:     buckets.values(history, clusterField("pods.running")),
 				Color:    "#4ECDC4",
-				DataType: "bar",
+				DataType: "area",
+			},
+			{
+				Label:    "Pending",
+				Data:     buckets.values(history, clusterField("pods.pending")),
+				Color:    "#F7B731",
+				DataType: "area",
 			},
 			{
-				Label:    "失败",
-				Data:     failedData,
+				Label:    "Failed",
+				Data:     buckets.values(history, clusterField("pods.failed")),
 				Color:    "#FF6B6B",
-				DataType: "bar",
+				DataType: "area",
+			},
+			{
+				Label:    "Succeeded",
+				Data:     buckets.values(history, clusterField("pods.succeeded")),
+				Color:    "#45B7D1",
+				DataType: "area",
 			},
 		},
 	}
@@ -142,25 +172,27 @@ func (g *Generator) GeneratePodMetricsChart(podName string, metrics []*metrics.P
 	return g.GenerateChart(chartData)
 }
 
-// GenerateResourceUsageChart 生成资源使用图表
-func (g *Generator) GenerateResourceUsageChart(metrics *metrics.ResourceMetrics) ([]byte, error) {
+// GenerateNamespaceMetricsChart 根据指标历史生成命名空间CPU和内存使用量占申请量比例的图表，没有申请量的时段显示为空白
+func (g *Generator) GenerateNamespaceMetricsChart(clusterName, namespace string, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+	buckets := newTimeBuckets(history, from, to)
 	chartData := ChartData{
-		Title:      "资源使用概览",
-		XLabels:    []string{"CPU", "内存"},
-		YLabel:     "使用量",
+		Title:      fmt.Sprintf("Namespace - %s/%s", clusterName, namespace),
+		XLabels:    buckets.labels(),
+		XLabel:     "Time",
+		YLabel:     "Usage of requests (%)",
 		ShowLegend: true,
 		Datasets: []Dataset{
 			{
-				Label:    "已使用",
-				Data:     []float64{50, 60},
+				Label:    "CPU",
+				Data:     buckets.values(history, namespaceUsage(namespace, "cpu")),
 				Color:    "#FF6B6B",
-				DataType: "bar",
+				DataType: "line",
 			},
 			{
-				Label:    "可用",
-				Data:     []float64{50, 40},
+				Label:    "Memory",
+				Data:     buckets.values(history, namespaceUsage(namespace, "memory")),
 				Color:    "#4ECDC4",
-				DataType: "bar",
+				DataType: "line",
 			},
 		},
 	}
@@ -168,93 +200,69 @@ func (g *Generator) GenerateResourceUsageChart(metrics *metrics.ResourceMetrics)
 	return g.GenerateChart(chartData)
 }
 
-// generateChart 生成图表（使用ASCII艺术）
-func (g *Generator) generateChart(data ChartData) ([]byte, error) {
-	var buf bytes.Buffer
-
-	buf.WriteString(fmt.Sprintf("\n%s\n", data.Title))
-	buf.WriteString(fmt.Sprintf("%s\n", g.generateSeparator(len(data.Title))))
-
-	if data.ShowLegend {
-		buf.WriteString("\n图例:\n")
-		for _, dataset := range data.Datasets {
-			buf.WriteString(fmt.Sprintf("  %s: %s\n", dataset.Label, dataset.Color))
+// GenerateResourceUsageChart 根据时间范围内最新的采样生成CPU和内存已使用与可用比例的图表
+func (g *Generator) GenerateResourceUsageChart(clusterName string, history []*metrics.ClusterMetrics, from, to time.Time) ([]byte, error) {
+	var latest *metrics.ClusterMetrics
+	for _, m := range history {
+		if m.Timestamp.Before(from) || m.Timestamp.After(to) {
+			continue
+		}
+		if latest == nil || m.Timestamp.After(latest.Timestamp) {
+			latest = m
 		}
-		buf.WriteString("\n")
 	}
-
-	buf.WriteString(fmt.Sprintf("%s\n", data.YLabel))
-	buf.WriteString(g.generateSeparator(20))
-
-	for i, dataset := range data.Datasets {
-		buf.WriteString(fmt.Sprintf("\n%s:\n", dataset.Label))
-		buf.WriteString(g.generateBarChart(dataset.Data, dataset.Color))
+	if latest == nil {
+		return nil, fmt.Errorf("no metrics for cluster %s between %s and %s", clusterName,
+			from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05"))
 	}
 
-	buf.WriteString("\n")
-	for i, label := range data.XLabels {
-		if i < len(data.XLabels) {
-			buf.WriteString(fmt.Sprintf("%-10s", label))
-		}
+	cpu, _ := latest.Field("cpu.usage_percent")
+	memory, _ := latest.Field("memory.usage_percent")
+	chartData := ChartData{
+		Title:      fmt.Sprintf("Resource usage - %s (%s)", clusterName, latest.Timestamp.In(to.Location()).Format("2006-01-02 15:04")),
+		XLabels:    []string{"CPU", "Memory"},
+		YLabel:     "Usage (%)",
+		ShowLegend: true,
+		Datasets: []Dataset{
+			{
+				Label:    "Used",
+				Data:     []float64{cpu, memory},
+				Color:    "#FF6B6B",
+				DataType: "bar",
+			},
+			{
+				Label:    "Available",
+				Data:     []float64{100 - cpu, 100 - memory},
+				Color:    "#4ECDC4",
+				DataType: "bar",
+			},
+		},
 	}
-	buf.WriteString("\n")
-
-	return buf.Bytes(), nil
-}
-
-// generateBarChart 生成条形图
-func (g *Generator) generateBarChart(data []float64, color string) string {
-	var result string
-	for _, value := range data {
-		barLength := int(value / 10)
-		if barLength > 20 {
-			barLength = 20
-		}
-
-		bar := ""
-		for i := 0; i < barLength; i++ {
-			bar += "█"
-		}
 
-		result += fmt.Sprintf("  %-5.1f %s\n", value, bar)
-	}
-	return result
+	return g.GenerateChart(chartData)
 }
 
-// generateTimeLabels 生成时间标签
-func (g *Generator) generateTimeLabels() []string {
-	labels := make([]string, 10)
-	now := time.Now()
-	for i := 0; i < 10; i++ {
-		t := now.Add(-time.Duration(9-i) * time.Minute)
-		labels[i] = t.Format("15:04")
+// generateChart 按输出格式渲染图表
+func (g *Generator) generateChart(data ChartData) ([]byte, error) {
+	if g.format == FormatText {
+		return renderText(data, g.textStyle, g.textWidth)
 	}
-	return labels
-}
-
-// generateSeparator 生成分隔线
-func (g *Generator) generateSeparator(length int) string {
-	separator := ""
-	for i := 0; i < length; i++ {
-		separator += "="
+	if g.width <= 0 || g.height <= 0 {
+		return nil, fmt.Errorf("invalid chart size %dx%d", g.width, g.height)
 	}
-	return separator
-}
 
-// generateCPUMetrics 生成CPU指标数据
-func (g *Generator) generateCPUMetrics(metrics *metrics.ClusterMetrics) []float64 {
-	data := make([]float64, 10)
-	for i := 0; i < 10; i++ {
-		data[i] = 30 + float64(i)*5
+	var c canvas
+	switch g.format {
+	case FormatPNG, "":
+		c = newRasterCanvas(g.width, g.height)
+	case FormatSVG:
+		c = newSVGCanvas(g.width, g.height)
+	default:
+		return nil, fmt.Errorf("unsupported chart format: %s", g.format)
 	}
-	return data
-}
 
-// generateMemoryMetrics 生成内存指标数据
-func (g *Generator) generateMemoryMetrics(metrics *metrics.ClusterMetrics) []float64 {
-	data := make([]float64, 10)
-	for i := 0; i < 10; i++ {
-		data[i] = 40 + float64(i)*3
+	if err := render(c, data, g.width, g.height); err != nil {
+		return nil, err
 	}
-	return data
+	return c.encode()
 }
@@ -0,0 +1,158 @@
+package chart
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// maxChartPoints 时间序列图表最多的数据点数
+const maxChartPoints = 120
+
+// niceDurations 可选的分桶间隔
+var niceDurations = []time.Duration{
+	15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// niceDuration 返回不小于d的最小可选间隔
+func niceDuration(d time.Duration) time.Duration {
+	for _, nice := range niceDurations {
+		if nice >= d {
+			return nice
+		}
+	}
+	return niceDurations[len(niceDurations)-1]
+}
+
+// DefaultStep 返回时间范围对应的默认分桶间隔，查询Prometheus等按间隔返回数据的数据源时使用
+func DefaultStep(from, to time.Time) time.Duration {
+	return niceDuration(to.Sub(from) / maxChartPoints)
+}
+
+// timeBuckets 按固定间隔对齐的时间分桶，没有采样的分桶在图表中显示为断开的空白
+type timeBuckets struct {
+	start time.Time
+	step  time.Duration
+	count int
+	// location 时间标签使用的时区
+	location *time.Location
+}
+
+// newTimeBuckets 根据时间范围和采样间隔创建分桶。分桶间隔不小于采样间隔的1.5倍，
+// 避免采样时刻的抖动使相邻分桶一个有两个采样、一个为空而出现虚假的空白
+func newTimeBuckets(history []*metrics.ClusterMetrics, from, to time.Time) timeBuckets {
+	step := to.Sub(from) / maxChartPoints
+	if interval := sampleInterval(history, from, to); interval*3/2 > step {
+		step = interval * 3 / 2
+	}
+	step = niceDuration(step)
+
+	start := from.Truncate(step)
+	return timeBuckets{
+		start:    start,
+		step:     step,
+		count:    int(to.Sub(start)/step) + 1,
+		location: from.Location(),
+	}
+}
+
+// sampleInterval 返回时间范围内相邻采样间隔的中位数
+func sampleInterval(history []*metrics.ClusterMetrics, from, to time.Time) time.Duration {
+	var intervals []time.Duration
+	var last time.Time
+	for _, m := range history {
+		if m.Timestamp.Before(from) || m.Timestamp.After(to) {
+			continue
+		}
+		if !last.IsZero() && m.Timestamp.After(last) {
+			intervals = append(intervals, m.Timestamp.Sub(last))
+		}
+		last = m.Timestamp
+	}
+	if len(intervals) == 0 {
+		return 0
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	return intervals[len(intervals)/2]
+}
+
+// labels 返回各分桶起始时刻的标签，时间范围越长显示的日期部分越多
+func (b timeBuckets) labels() []string {
+	layout := "15:04"
+	switch span := time.Duration(b.count) * b.step; {
+	case span > 7*24*time.Hour:
+		layout = "01-02"
+	case span > 24*time.Hour:
+		layout = "01-02 15:04"
+	}
+
+	labels := make([]string, b.count)
+	for i := range labels {
+		labels[i] = b.start.Add(time.Duration(i) * b.step).In(b.location).Format(layout)
+	}
+	return labels
+}
+
+// values 计算各分桶内采样的平均值，没有采样或value返回false的分桶为NaN
+func (b timeBuckets) values(history []*metrics.ClusterMetrics, value func(m *metrics.ClusterMetrics) (float64, bool)) []float64 {
+	sums := make([]float64, b.count)
+	counts := make([]int, b.count)
+	for _, m := range history {
+		i := int(math.Floor(float64(m.Timestamp.Sub(b.start)) / float64(b.step)))
+		if i < 0 || i >= b.count {
+			continue
+		}
+		if v, ok := value(m); ok {
+			sums[i] += v
+			counts[i]++
+		}
+	}
+
+	result := make([]float64, b.count)
+	for i := range result {
+		result[i] = math.NaN()
+		if counts[i] > 0 {
+			result[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return result
+}
+
+// clusterField 返回读取集群指标字段的函数
+func clusterField(name string) func(m *metrics.ClusterMetrics) (float64, bool) {
+	return func(m *metrics.ClusterMetrics) (float64, bool) {
+		return m.Field(name)
+	}
+}
+
+// nodeField 返回读取指定节点指标字段的函数，采样中没有该节点时返回false
+func nodeField(nodeName, name string) func(m *metrics.ClusterMetrics) (float64, bool) {
+	return func(m *metrics.ClusterMetrics) (float64, bool) {
+		for i := range m.Nodes.Details {
+			if m.Nodes.Details[i].Name == nodeName {
+				return m.Nodes.Details[i].Field(name)
+			}
+		}
+		return 0, false
+	}
+}
+
+// namespaceUsage 返回读取命名空间资源使用量占申请量百分比的函数，resource为cpu或memory
+func namespaceUsage(namespace, resource string) func(m *metrics.ClusterMetrics) (float64, bool) {
+	return func(m *metrics.ClusterMetrics) (float64, bool) {
+		ns := m.Namespace(namespace)
+		if ns == nil {
+			return 0, false
+		}
+		usage, _ := ns.Field(resource + ".usage")
+		requests, _ := ns.Field(resource + ".requests")
+		if requests == 0 {
+			return 0, false
+		}
+		return usage / requests * 100, true
+	}
+}
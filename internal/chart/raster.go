@@ -0,0 +1,171 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+)
+
+// rasterCanvas 绘制到内存图像并编码为PNG的画布，线条按像素覆盖率抗锯齿
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func newRasterCanvas(width, height int) *rasterCanvas {
+	return &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+// blend 按覆盖率将颜色叠加到像素上
+func (r *rasterCanvas) blend(x, y int, c color.RGBA, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(r.img.Rect)) || coverage <= 0 {
+		return
+	}
+	a := coverage * float64(c.A) / 0xFF
+	if a > 1 {
+		a = 1
+	}
+	i := r.img.PixOffset(x, y)
+	pix := r.img.Pix[i : i+4 : i+4]
+	pix[0] = uint8(math.Round(float64(pix[0])*(1-a) + float64(c.R)*a))
+	pix[1] = uint8(math.Round(float64(pix[1])*(1-a) + float64(c.G)*a))
+	pix[2] = uint8(math.Round(float64(pix[2])*(1-a) + float64(c.B)*a))
+	pix[3] = uint8(math.Round(float64(pix[3])*(1-a) + 0xFF*a))
+}
+
+func (r *rasterCanvas) fillRect(x, y, w, h float64, c color.RGBA) {
+	x0, y0 := int(math.Round(x)), int(math.Round(y))
+	x1, y1 := int(math.Round(x+w)), int(math.Round(y+h))
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			r.blend(px, py, c, 1)
+		}
+	}
+}
+
+// polyline 逐段计算像素中心到线段的距离得到覆盖率，同一像素取各段覆盖率的最大值，避免连接处颜色加深
+func (r *rasterCanvas) polyline(points []point, width float64, c color.RGBA) {
+	if len(points) == 0 {
+		return
+	}
+	half := width / 2
+	minX, minY, maxX, maxY := points[0].x, points[0].y, points[0].x, points[0].y
+	for _, p := range points[1:] {
+		minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+		maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+	}
+	x0, y0 := int(math.Floor(minX-half-1)), int(math.Floor(minY-half-1))
+	x1, y1 := int(math.Ceil(maxX+half+1)), int(math.Ceil(maxY+half+1))
+
+	coverage := make([]float64, (x1-x0+1)*(y1-y0+1))
+	stride := x1 - x0 + 1
+	for i := 0; i < len(points); i++ {
+		a, b := points[i], points[i]
+		if i+1 < len(points) {
+			b = points[i+1]
+		} else if len(points) > 1 {
+			continue
+		}
+		sx0, sy0 := int(math.Floor(math.Min(a.x, b.x)-half-1)), int(math.Floor(math.Min(a.y, b.y)-half-1))
+		sx1, sy1 := int(math.Ceil(math.Max(a.x, b.x)+half+1)), int(math.Ceil(math.Max(a.y, b.y)+half+1))
+		for py := sy0; py <= sy1; py++ {
+			for px := sx0; px <= sx1; px++ {
+				d := segmentDistance(point{float64(px) + 0.5, float64(py) + 0.5}, a, b)
+				cov := math.Min(1, half+0.5-d)
+				if k := (py-y0)*stride + px - x0; cov > coverage[k] {
+					coverage[k] = cov
+				}
+			}
+		}
+	}
+	for py := y0; py <= y1; py++ {
+		for px := x0; px <= x1; px++ {
+			r.blend(px, py, c, coverage[(py-y0)*stride+px-x0])
+		}
+	}
+}
+
+// segmentDistance 点到线段的距离
+func segmentDistance(p, a, b point) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, ((p.x-a.x)*dx+(p.y-a.y)*dy)/length))
+	}
+	return math.Hypot(p.x-a.x-t*dx, p.y-a.y-t*dy)
+}
+
+// fillPolygon 按扫描线以奇偶规则填充多边形
+func (r *rasterCanvas) fillPolygon(points []point, c color.RGBA) {
+	if len(points) < 3 {
+		return
+	}
+	minY, maxY := points[0].y, points[0].y
+	for _, p := range points[1:] {
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+
+	var crossings []float64
+	for py := int(math.Floor(minY)); py <= int(math.Ceil(maxY)); py++ {
+		y := float64(py) + 0.5
+		crossings = crossings[:0]
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			if (a.y <= y) != (b.y <= y) {
+				crossings = append(crossings, a.x+(y-a.y)/(b.y-a.y)*(b.x-a.x))
+			}
+		}
+		sort.Float64s(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for px := int(math.Ceil(crossings[i] - 0.5)); float64(px)+0.5 <= crossings[i+1]; px++ {
+				r.blend(px, py, c, 1)
+			}
+		}
+	}
+}
+
+func (r *rasterCanvas) dot(p point, radius float64, c color.RGBA) {
+	r.polyline([]point{p}, radius*2, c)
+}
+
+// text 使用内置点阵字体绘制文本，字体不包含的字符绘制为方框
+func (r *rasterCanvas) text(x, y float64, s string, scale int, c color.RGBA, anchor textAnchor) {
+	switch anchor {
+	case anchorMiddle:
+		x -= textWidth(s, scale) / 2
+	case anchorEnd:
+		x -= textWidth(s, scale)
+	}
+	ox, oy := int(math.Round(x)), int(math.Round(y))
+	for _, ch := range s {
+		cells := runeCells(ch)
+		if bitmap, ok := glyph(ch); ok {
+			for col, bits := range bitmap {
+				for row := 0; row < glyphHeight; row++ {
+					if bits&(1<<row) != 0 {
+						r.fillRect(float64(ox+col*scale), float64(oy+row*scale), float64(scale), float64(scale), c)
+					}
+				}
+			}
+		} else {
+			w := float64((cells*glyphWidth - 1) * scale)
+			h := float64(glyphHeight * scale)
+			r.fillRect(float64(ox), float64(oy), w, float64(scale), c)
+			r.fillRect(float64(ox), float64(oy)+h-float64(scale), w, float64(scale), c)
+			r.fillRect(float64(ox), float64(oy), float64(scale), h, c)
+			r.fillRect(float64(ox)+w-float64(scale), float64(oy), float64(scale), h, c)
+		}
+		ox += cells * glyphWidth * scale
+	}
+}
+
+func (r *rasterCanvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, r.img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,391 @@
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// point 画布上的坐标，原点在左上角
+type point struct {
+	x, y float64
+}
+
+// textAnchor 文本的水平对齐方式
+type textAnchor int
+
+const (
+	anchorStart textAnchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas 图表的绘制目标，PNG和SVG分别实现，布局只计算一次
+type canvas interface {
+	fillRect(x, y, w, h float64, c color.RGBA)
+	polyline(points []point, width float64, c color.RGBA)
+	fillPolygon(points []point, c color.RGBA)
+	dot(p point, radius float64, c color.RGBA)
+	// text 绘制单行文本，y为文本顶部
+	text(x, y float64, s string, scale int, c color.RGBA, anchor textAnchor)
+	encode() ([]byte, error)
+}
+
+// defaultPalette 数据集未指定颜色时按顺序使用的颜色
+var defaultPalette = []string{"#FF6B6B", "#4ECDC4", "#45B7D1", "#F7B731", "#5F27CD", "#A3CB38"}
+
+var (
+	backgroundColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	gridColor       = color.RGBA{R: 0xE5, G: 0xE7, B: 0xEB, A: 0xFF}
+	axisColor       = color.RGBA{R: 0x6B, G: 0x72, B: 0x80, A: 0xFF}
+	textColor       = color.RGBA{R: 0x37, G: 0x41, B: 0x51, A: 0xFF}
+)
+
+// areaAlpha 堆叠面积图填充的不透明度
+const areaAlpha = 0xB0
+
+// yTickCount Y轴的期望刻度数
+const yTickCount = 5
+
+// parseColor 解析 #RRGGBB 或 #RGB 格式的颜色
+func parseColor(s string) (color.RGBA, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}, true
+}
+
+// datasetColor 返回数据集的颜色，未指定或无法解析时使用默认调色板
+func datasetColor(dataset Dataset, index int) color.RGBA {
+	if c, ok := parseColor(dataset.Color); ok {
+		return c
+	}
+	c, _ := parseColor(defaultPalette[index%len(defaultPalette)])
+	return c
+}
+
+// niceStep 返回不小于 span/count 的1、2、5乘以10的幂的刻度间隔
+func niceStep(span float64, count int) float64 {
+	raw := span / float64(count)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch normalized := raw / magnitude; {
+	case normalized <= 1:
+		return magnitude
+	case normalized <= 2:
+		return 2 * magnitude
+	case normalized <= 5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
+
+// formatTick 按刻度间隔的精度格式化刻度值
+func formatTick(v, step float64) string {
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// isGap 判断数据点是否缺失，NaN表示该时刻没有采样，绘制为断开的空白
+func isGap(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0)
+}
+
+// layout 图表各部分的位置和坐标换算
+type layout struct {
+	scale  int
+	left   float64
+	right  float64
+	top    float64
+	bottom float64
+	yMin   float64
+	yMax   float64
+	count  int
+	bars   bool
+}
+
+// x 返回第i个数据点的横坐标。有柱状图时数据点位于各分组的中心，否则首尾数据点对齐坐标轴两端
+func (l *layout) x(i int) float64 {
+	width := l.right - l.left
+	if l.bars {
+		return l.left + (float64(i)+0.5)*width/float64(l.count)
+	}
+	if l.count <= 1 {
+		return l.left + width/2
+	}
+	return l.left + float64(i)*width/float64(l.count-1)
+}
+
+// y 返回数值对应的纵坐标
+func (l *layout) y(v float64) float64 {
+	return l.bottom - (v-l.yMin)/(l.yMax-l.yMin)*(l.bottom-l.top)
+}
+
+// valueRange 计算Y轴需要容纳的数值范围，堆叠面积图按堆叠后的总和计算，范围总是包含0
+func valueRange(data ChartData, count int) (float64, float64) {
+	lo, hi := 0.0, 0.0
+	stacked := make([]float64, count)
+	for _, dataset := range data.Datasets {
+		for i, v := range dataset.Data {
+			if isGap(v) {
+				continue
+			}
+			if dataset.DataType == "area" {
+				stacked[i] += v
+				v = stacked[i]
+			}
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// render 在画布上绘制图表，width和height为画布的像素尺寸
+func render(c canvas, data ChartData, width, height int) error {
+	scale := width / 400
+	if s := height / 300; s < scale {
+		scale = s
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	pad := float64(8 * scale)
+	lineHeight := float64(glyphHeight * scale)
+
+	count := len(data.XLabels)
+	bars := 0
+	for _, dataset := range data.Datasets {
+		if len(dataset.Data) > count {
+			count = len(dataset.Data)
+		}
+		if dataset.DataType == "bar" {
+			bars++
+		}
+	}
+
+	if count == 0 {
+		count = 1
+	}
+
+	c.fillRect(0, 0, float64(width), float64(height), backgroundColor)
+
+	top := pad
+	if data.Title != "" {
+		c.text(float64(width)/2, top, data.Title, scale+1, textColor, anchorMiddle)
+		top += float64(glyphHeight*(scale+1)) + pad
+	}
+	if data.ShowLegend && len(data.Datasets) > 0 {
+		top = drawLegend(c, data.Datasets, scale, pad, top, float64(width)-pad)
+	}
+	if data.YLabel != "" {
+		c.text(pad, top, data.YLabel, scale, textColor, anchorStart)
+		top += lineHeight + pad/2
+	}
+	// 为最上方的刻度标签留出半行
+	top += lineHeight / 2
+
+	bottom := float64(height) - pad - lineHeight - pad/2
+	if data.XLabel != "" {
+		c.text(float64(width)/2, float64(height)-pad-lineHeight, data.XLabel, scale, textColor, anchorMiddle)
+		bottom -= lineHeight + pad/2
+	}
+
+	lo, hi := valueRange(data, count)
+	step := niceStep(hi-lo, yTickCount)
+	l := &layout{
+		scale:  scale,
+		top:    top,
+		bottom: bottom,
+		yMin:   math.Floor(lo/step) * step,
+		yMax:   math.Ceil(hi/step) * step,
+		count:  count,
+		bars:   bars > 0,
+	}
+
+	ticks := int(math.Round((l.yMax - l.yMin) / step))
+	labelWidth := 0.0
+	for i := 0; i <= ticks; i++ {
+		labelWidth = math.Max(labelWidth, textWidth(formatTick(l.yMin+float64(i)*step, step), scale))
+	}
+	l.left = pad + labelWidth + pad/2
+	l.right = float64(width) - pad - float64(glyphWidth*scale*2)
+	if l.right-l.left < float64(10*scale) || l.bottom-l.top < float64(10*scale) {
+		return fmt.Errorf("chart size %dx%d is too small", width, height)
+	}
+
+	// 网格和Y轴刻度
+	for i := 0; i <= ticks; i++ {
+		v := l.yMin + float64(i)*step
+		y := math.Round(l.y(v))
+		c.fillRect(l.left, y, l.right-l.left, 1, gridColor)
+		c.text(l.left-pad/2, y-lineHeight/2, formatTick(v, step), scale, textColor, anchorEnd)
+	}
+
+	drawAreas(c, l, data.Datasets)
+	drawBars(c, l, data.Datasets, bars)
+	drawLines(c, l, data.Datasets)
+
+	// 坐标轴，有负值时X轴位于0处
+	c.fillRect(l.left, l.top, 1, l.bottom-l.top, axisColor)
+	c.fillRect(l.left, math.Round(l.y(0)), l.right-l.left, 1, axisColor)
+
+	drawXLabels(c, l, data.XLabels, pad)
+	return nil
+}
+
+// drawLegend 在图表上方绘制图例，一行放不下时换行，返回图例下方的纵坐标
+func drawLegend(c canvas, datasets []Dataset, scale int, pad, top, right float64) float64 {
+	lineHeight := float64(glyphHeight * scale)
+	swatch := float64(10 * scale)
+	x := pad
+	for i, dataset := range datasets {
+		entry := swatch + float64(4*scale) + textWidth(dataset.Label, scale)
+		if x > pad && x+entry > right {
+			x = pad
+			top += lineHeight + pad/2
+		}
+		c.fillRect(x, top, swatch, lineHeight, datasetColor(dataset, i))
+		c.text(x+swatch+float64(4*scale), top, dataset.Label, scale, textColor, anchorStart)
+		x += entry + float64(12*scale)
+	}
+	return top + lineHeight + pad
+}
+
+// drawXLabels 绘制X轴标签，标签过密时按间隔跳过
+func drawXLabels(c canvas, l *layout, labels []string, pad float64) {
+	if len(labels) == 0 {
+		return
+	}
+	widest := 0.0
+	for _, label := range labels {
+		widest = math.Max(widest, textWidth(label, l.scale))
+	}
+	spacing := (l.right - l.left) / float64(len(labels))
+	stride := int(math.Ceil((widest + float64(glyphWidth*l.scale)) / spacing))
+	if stride < 1 {
+		stride = 1
+	}
+	for i := 0; i < len(labels); i += stride {
+		c.text(l.x(i), l.bottom+pad/2, labels[i], l.scale, textColor, anchorMiddle)
+	}
+}
+
+// runs 将数据按缺失点分成连续的段
+func runs(data []float64) [][2]int {
+	var result [][2]int
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && !isGap(data[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			result = append(result, [2]int{start, i})
+			start = -1
+		}
+	}
+	return result
+}
+
+// drawAreas 按顺序堆叠绘制面积图数据集，缺失的数据点处断开
+func drawAreas(c canvas, l *layout, datasets []Dataset) {
+	base := make([]float64, l.count)
+	for index, dataset := range datasets {
+		if dataset.DataType != "area" {
+			continue
+		}
+		stroke := datasetColor(dataset, index)
+		fill := stroke
+		fill.A = areaAlpha
+
+		for _, run := range runs(dataset.Data) {
+			upper := make([]point, 0, run[1]-run[0])
+			lower := make([]point, 0, run[1]-run[0])
+			for i := run[0]; i < run[1]; i++ {
+				upper = append(upper, point{l.x(i), l.y(base[i] + dataset.Data[i])})
+				lower = append(lower, point{l.x(i), l.y(base[i])})
+			}
+			if len(upper) == 1 {
+				c.dot(upper[0], float64(2*l.scale), stroke)
+				continue
+			}
+			polygon := append([]point{}, upper...)
+			for i := len(lower) - 1; i >= 0; i-- {
+				polygon = append(polygon, lower[i])
+			}
+			c.fillPolygon(polygon, fill)
+			c.polyline(upper, float64(l.scale), stroke)
+		}
+		for i, v := range dataset.Data {
+			if !isGap(v) {
+				base[i] += v
+			}
+		}
+	}
+}
+
+// drawBars 绘制柱状图数据集，同一数据点的多个数据集并排分组
+func drawBars(c canvas, l *layout, datasets []Dataset, bars int) {
+	if bars == 0 {
+		return
+	}
+	band := (l.right - l.left) / float64(l.count)
+	group := band * 0.7
+	width := group / float64(bars)
+	slot := 0
+	for index, dataset := range datasets {
+		if dataset.DataType != "bar" {
+			continue
+		}
+		color := datasetColor(dataset, index)
+		for i, v := range dataset.Data {
+			if isGap(v) {
+				continue
+			}
+			x := l.left + float64(i)*band + (band-group)/2 + float64(slot)*width
+			y0, y1 := l.y(math.Max(v, 0)), l.y(math.Min(v, 0))
+			c.fillRect(x, y0, width, y1-y0, color)
+		}
+		slot++
+	}
+}
+
+// drawLines 绘制折线图数据集，缺失的数据点处断开，孤立的数据点绘制为圆点
+func drawLines(c canvas, l *layout, datasets []Dataset) {
+	for index, dataset := range datasets {
+		if dataset.DataType == "bar" || dataset.DataType == "area" {
+			continue
+		}
+		color := datasetColor(dataset, index)
+		for _, run := range runs(dataset.Data) {
+			points := make([]point, 0, run[1]-run[0])
+			for i := run[0]; i < run[1]; i++ {
+				points = append(points, point{l.x(i), l.y(dataset.Data[i])})
+			}
+			if len(points) == 1 {
+				c.dot(points[0], float64(2*l.scale), color)
+				continue
+			}
+			c.polyline(points, 1.5*float64(l.scale), color)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// svgCanvas 输出SVG矢量图的画布，文本使用等宽字体，字号与点阵字体的度量一致
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	return c
+}
+
+// svgNumber 格式化坐标，保留一位小数
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// svgColor 返回颜色的fill或stroke属性，半透明时附加不透明度
+func svgColor(attr string, c color.RGBA) string {
+	s := fmt.Sprintf(`%s="#%02X%02X%02X"`, attr, c.R, c.G, c.B)
+	if c.A != 0xFF {
+		s += fmt.Sprintf(` %s-opacity="%s"`, attr, strconv.FormatFloat(math.Round(float64(c.A)/0xFF*100)/100, 'f', -1, 64))
+	}
+	return s
+}
+
+func svgPoints(points []point) string {
+	var buf bytes.Buffer
+	for i, p := range points {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(svgNumber(p.x) + "," + svgNumber(p.y))
+	}
+	return buf.String()
+}
+
+func (s *svgCanvas) fillRect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&s.buf, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`+"\n",
+		svgNumber(x), svgNumber(y), svgNumber(w), svgNumber(h), svgColor("fill", c))
+}
+
+func (s *svgCanvas) polyline(points []point, width float64, c color.RGBA) {
+	fmt.Fprintf(&s.buf, `<polyline points="%s" fill="none" %s stroke-width="%s" stroke-linejoin="round" stroke-linecap="round"/>`+"\n",
+		svgPoints(points), svgColor("stroke", c), svgNumber(width))
+}
+
+func (s *svgCanvas) fillPolygon(points []point, c color.RGBA) {
+	fmt.Fprintf(&s.buf, `<polygon points="%s" %s/>`+"\n", svgPoints(points), svgColor("fill", c))
+}
+
+func (s *svgCanvas) dot(p point, radius float64, c color.RGBA) {
+	fmt.Fprintf(&s.buf, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", svgNumber(p.x), svgNumber(p.y), svgNumber(radius), svgColor("fill", c))
+}
+
+// text 按点阵字体的度量输出文本，SVG中可以显示任意Unicode字符
+func (s *svgCanvas) text(x, y float64, text string, scale int, c color.RGBA, anchor textAnchor) {
+	anchors := map[textAnchor]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}
+	fmt.Fprintf(&s.buf, `<text x="%s" y="%s" font-family="monospace" font-size="%d" text-anchor="%s" %s>`,
+		svgNumber(x), svgNumber(y+float64((glyphHeight-1)*scale)), 10*scale, anchors[anchor], svgColor("fill", c))
+	xml.EscapeText(&s.buf, []byte(text))
+	s.buf.WriteString("</text>\n")
+}
+
+func (s *svgCanvas) encode() ([]byte, error) {
+	s.buf.WriteString("</svg>\n")
+	return s.buf.Bytes(), nil
+}
@@ -0,0 +1,236 @@
+package chart_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/chart"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// update 使用 go test ./internal/chart/test -update 重新生成golden文件
+var update = flag.Bool("update", false, "update golden files")
+
+var goldenCharts = map[string]chart.ChartData{
+	"line": {
+		Title:      "Cluster - prod",
+		XLabels:    []string{"10:00", "10:05", "10:10", "10:15", "10:20", "10:25", "10:30", "10:35"},
+		XLabel:     "Time",
+		YLabel:     "Usage (%)",
+		ShowLegend: true,
+		Datasets: []chart.Dataset{
+			{Label: "CPU usage", Data: []float64{32, 35, 41, math.NaN(), math.NaN(), 48, 44, 51}, Color: "#FF6B6B", DataType: "line"},
+			{Label: "Memory usage", Data: []float64{60, 61, 63, 62, 66, 65, math.NaN(), 70}, Color: "#4ECDC4", DataType: "line"},
+		},
+	},
+	"bar": {
+		Title:      "Pods - prod",
+		XLabels:    []string{"default", "kube-system", "web", "batch"},
+		YLabel:     "Count",
+		ShowLegend: true,
+		Datasets: []chart.Dataset{
+			{Label: "Running", Data: []float64{12, 18, 7, 3}, Color: "#4ECDC4", DataType: "bar"},
+			{Label: "Failed", Data: []float64{1, 0, 2, math.NaN()}, Color: "#FF6B6B", DataType: "bar"},
+		},
+	},
+	"stacked_area": {
+		Title:      "Pod phases - prod",
+		XLabels:    []string{"10:00", "10:05", "10:10", "10:15", "10:20", "10:25"},
+		XLabel:     "Time",
+		YLabel:     "Pods",
+		ShowLegend: true,
+		Datasets: []chart.Dataset{
+			{Label: "Running", Data: []float64{40, 42, 45, 44, 47, 50}, DataType: "area"},
+			{Label: "Pending", Data: []float64{3, 5, 2, math.NaN(), 4, 1}, DataType: "area"},
+			{Label: "Failed", Data: []float64{1, 0, 2, 1, 3, 2}, DataType: "area"},
+		},
+	},
+}
+
+func TestGenerator_Golden(t *testing.T) {
+	for name, data := range goldenCharts {
+		for _, format := range []chart.Format{chart.FormatPNG, chart.FormatSVG} {
+			generator := chart.NewGenerator(640, 360)
+			generator.SetFormat(format)
+			got, err := generator.GenerateChart(data)
+			if err != nil {
+				t.Fatalf("GenerateChart(%s, %s) error = %v", name, format, err)
+			}
+
+			path := filepath.Join("testdata", name+"."+string(format))
+			if *update {
+				if err := os.WriteFile(path, got, 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+				continue
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s does not match the golden file, run with -update after checking the output", path)
+			}
+		}
+	}
+}
+
+func TestGenerator_Formats(t *testing.T) {
+	data := goldenCharts["line"]
+
+	generator := chart.NewGenerator(300, 200)
+	output, err := generator.GenerateChart(data)
+	if err != nil {
+		t.Fatalf("GenerateChart() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(output))
+	if err != nil {
+		t.Fatalf("expected a valid png: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 300 || bounds.Dy() != 200 {
+		t.Errorf("expected 300x200 image, got %v", bounds)
+	}
+
+	generator.SetFormat(chart.FormatSVG)
+	output, err = generator.GenerateChart(data)
+	if err != nil {
+		t.Fatalf("GenerateChart() error = %v", err)
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(output))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("expected well-formed svg: %v", err)
+		}
+	}
+
+	generator.SetFormat("gif")
+	if _, err := generator.GenerateChart(data); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if _, err := chart.NewGenerator(40, 30).GenerateChart(data); err == nil {
+		t.Error("expected error for a chart too small to draw")
+	}
+}
+
+// sampledHistory 生成每分钟一个采样的历史，跳过skip返回true的时刻
+func sampledHistory(from, to time.Time, skip func(t time.Time) bool) []*metrics.ClusterMetrics {
+	var history []*metrics.ClusterMetrics
+	for t := from; !t.After(to); t = t.Add(time.Minute) {
+		if skip(t) {
+			continue
+		}
+		history = append(history, &metrics.ClusterMetrics{
+			ClusterName: "prod",
+			Timestamp:   t,
+			Nodes:       metrics.NodeMetricsSummary{Details: []metrics.NodeDetail{{Name: "node-1", CPUUsagePercent: 40, MemoryUsagePercent: 70}}},
+			Pods:        metrics.PodMetricsSummary{Running: 20, Pending: 2, Failed: 1},
+			Resources:   metrics.ResourceMetrics{CPUCapacity: 4000, CPUUsage: 1000, MemoryCapacity: 1000, MemoryUsage: 600},
+		})
+	}
+	return history
+}
+
+func TestGenerator_HistoryCharts(t *testing.T) {
+	from := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	// 10:20到10:30之间没有采样
+	history := sampledHistory(from, to, func(t time.Time) bool {
+		return !t.Before(from.Add(20*time.Minute)) && t.Before(from.Add(30*time.Minute))
+	})
+
+	generator := chart.NewGenerator(640, 360)
+	generator.SetFormat(chart.FormatSVG)
+	output, err := generator.GenerateClusterMetricsChart("prod", history, from, to)
+	if err != nil {
+		t.Fatalf("GenerateClusterMetricsChart() error = %v", err)
+	}
+	svg := string(output)
+	if !strings.Contains(svg, ">10:00<") || !strings.Contains(svg, "Cluster - prod") {
+		t.Errorf("expected labels from the sample timestamps, got %s", svg)
+	}
+	// CPU和内存各被空白分成两段
+	if n := strings.Count(svg, "<polyline"); n != 4 {
+		t.Errorf("expected each series to break at the gap, got %d polylines", n)
+	}
+
+	// 节点在采样中不存在时整条曲线为空白
+	output, err = generator.GenerateNodeMetricsChart("node-2", history, from, to)
+	if err != nil {
+		t.Fatalf("GenerateNodeMetricsChart() error = %v", err)
+	}
+	if strings.Contains(string(output), "<polyline") {
+		t.Error("expected no lines for a node without samples")
+	}
+
+	output, err = generator.GeneratePodMetricsChart("prod", history, from, to)
+	if err != nil {
+		t.Fatalf("GeneratePodMetricsChart() error = %v", err)
+	}
+	if n := strings.Count(string(output), "<polygon"); n != 8 {
+		t.Errorf("expected four stacked areas split by the gap, got %d polygons", n)
+	}
+
+	output, err = generator.GenerateResourceUsageChart("prod", history, from, to)
+	if err != nil {
+		t.Fatalf("GenerateResourceUsageChart() error = %v", err)
+	}
+	if !strings.Contains(string(output), "2024-05-01 11:00") {
+		t.Error("expected the resource usage chart to show the latest sample time")
+	}
+	if _, err := generator.GenerateResourceUsageChart("prod", history, to.Add(time.Hour), to.Add(2*time.Hour)); err == nil {
+		t.Error("expected error without samples in range")
+	}
+}
+
+func TestGenerator_TextSparklines(t *testing.T) {
+	data := chart.ChartData{
+		Title:   "Cluster - prod",
+		XLabels: []string{"10:00", "10:01", "10:02", "10:03", "10:04", "10:05", "10:06", "10:07", "10:08"},
+		YLabel:  "Usage (%)",
+		Datasets: []chart.Dataset{
+			{Label: "CPU usage", Data: []float64{0, 10, 20, 30, math.NaN(), 50, 60, 70, 35}},
+			{Label: "Memory usage", Data: []float64{math.NaN(), math.NaN()}},
+		},
+	}
+
+	generator := chart.NewGenerator(640, 360)
+	generator.SetFormat(chart.FormatText)
+	output, err := generator.GenerateChart(data)
+	if err != nil {
+		t.Fatalf("GenerateChart() error = %v", err)
+	}
+	want := "Cluster - prod\n10:00 - 10:08, Usage (%)\nCPU usage  last 35  min 0  max 70\n▁▂▃▄ ▆▇█▅\nMemory usage: no data"
+	if string(output) != want {
+		t.Errorf("blocks output =\n%s\nwant\n%s", output, want)
+	}
+
+	// 窄宽度时统计信息换行，数据点合并到宽度之内
+	generator.SetTextOptions(chart.TextStyleBraille, 16)
+	output, err = generator.GenerateChart(data)
+	if err != nil {
+		t.Fatalf("GenerateChart() error = %v", err)
+	}
+	lines := strings.Split(string(output), "\n")
+	if len(lines) != 7 || lines[2] != "CPU usage" || lines[3] != "last 35  min 0" || lines[4] != "max 70" {
+		t.Fatalf("unexpected braille output:\n%s", output)
+	}
+	if lines[5] != "⣀⣤⢰⣿⡆" {
+		t.Errorf("braille sparkline = %q", lines[5])
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n > 16 {
+			t.Errorf("line %q exceeds width: %d", line, n)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package chart
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TextStyle 文本迷你图使用的字符
+type TextStyle string
+
+const (
+	// TextStyleBlocks 每个字符一个数据点，使用8级高度的方块字符 ▁▂▃▄▅▆▇█
+	TextStyleBlocks TextStyle = "blocks"
+	// TextStyleBraille 每个字符两个数据点，使用盲文点阵字符，每个数据点4级高度
+	TextStyleBraille TextStyle = "braille"
+)
+
+// defaultTextWidth 文本迷你图每行的默认最大宽度（字符数），适合钉钉和飞书的手机端消息
+const defaultTextWidth = 40
+
+// sparkBlocks 方块迷你图从低到高的字符
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// brailleDots 盲文字符左右两列从下到上的点位
+var brailleDots = [2][4]rune{
+	{0x40, 0x04, 0x02, 0x01},
+	{0x80, 0x20, 0x10, 0x08},
+}
+
+// brailleBlank 没有点的盲文字符，作为缺失数据的占位，宽度与其他盲文字符一致
+const brailleBlank = 0x2800
+
+// renderText 将图表渲染为文本：标题、时间范围和单位，以及每个数据集一行迷你图和最小值、最大值、最新值。
+// 各数据集按自身的最小值和最大值缩放，堆叠面积图也按单个数据集而不是堆叠后的值绘制
+func renderText(data ChartData, style TextStyle, width int) ([]byte, error) {
+	if width <= 0 {
+		width = defaultTextWidth
+	}
+	points := width
+	switch style {
+	case TextStyleBlocks, "":
+	case TextStyleBraille:
+		points = width * 2
+	default:
+		return nil, fmt.Errorf("unsupported text chart style: %s", style)
+	}
+
+	// 文本行超过宽度时截断，迷你图的长度在重采样时已经限制在宽度之内
+	var lines []string
+	addLine := func(line string) {
+		lines = append(lines, truncateCells(line, width))
+	}
+	if data.Title != "" {
+		addLine(data.Title)
+	}
+	// 时间序列显示X轴的起止标签，柱状图的X轴标签是分类而不是时间
+	var subtitle []string
+	if n := len(data.XLabels); n > 1 && !hasBars(data) {
+		subtitle = append(subtitle, data.XLabels[0]+" - "+data.XLabels[n-1])
+	}
+	if data.YLabel != "" {
+		subtitle = append(subtitle, data.YLabel)
+	}
+	if len(subtitle) > 0 {
+		addLine(strings.Join(subtitle, ", "))
+	}
+
+	for _, ds := range data.Datasets {
+		minValue, maxValue, last, ok := seriesStats(ds.Data)
+		if !ok {
+			addLine(ds.Label + ": no data")
+			continue
+		}
+
+		// 标签和统计信息优先放在一行，放不下时统计信息另起一行，仍然放不下时逐项换行
+		stats := []string{"last " + formatValue(last), "min " + formatValue(minValue), "max " + formatValue(maxValue)}
+		if header := ds.Label + "  " + strings.Join(stats, "  "); cellWidth(header) <= width {
+			addLine(header)
+		} else {
+			addLine(ds.Label)
+			for _, line := range wrapFields(stats, width) {
+				addLine(line)
+			}
+		}
+
+		values := resample(ds.Data, points)
+		if style == TextStyleBraille {
+			lines = append(lines, brailleSparkline(values, minValue, maxValue))
+		} else {
+			lines = append(lines, blockSparkline(values, minValue, maxValue))
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// hasBars 判断图表是否包含柱状图数据集
+func hasBars(data ChartData) bool {
+	for _, ds := range data.Datasets {
+		if ds.DataType == "bar" {
+			return true
+		}
+	}
+	return false
+}
+
+// seriesStats 返回数据集中非缺失值的最小值、最大值和最后一个值
+func seriesStats(data []float64) (float64, float64, float64, bool) {
+	minValue, maxValue, last := math.Inf(1), math.Inf(-1), 0.0
+	ok := false
+	for _, v := range data {
+		if isGap(v) {
+			continue
+		}
+		minValue, maxValue, last, ok = math.Min(minValue, v), math.Max(maxValue, v), v, true
+	}
+	return minValue, maxValue, last, ok
+}
+
+// resample 将数据均匀合并为不超过n个点，每个点取对应区间内非缺失值的平均，区间内全部缺失时为NaN。
+// 数据点少于n时保持原样，不做拉伸
+func resample(data []float64, n int) []float64 {
+	if len(data) <= n {
+		return data
+	}
+	result := make([]float64, n)
+	for i := range result {
+		sum, count := 0.0, 0
+		for _, v := range data[i*len(data)/n : (i+1)*len(data)/n] {
+			if !isGap(v) {
+				sum += v
+				count++
+			}
+		}
+		result[i] = math.NaN()
+		if count > 0 {
+			result[i] = sum / float64(count)
+		}
+	}
+	return result
+}
+
+// level 将值按最小值和最大值映射到[0, 1]，所有值相同时位于中间
+func level(v, minValue, maxValue float64) float64 {
+	if maxValue == minValue {
+		return 0.5
+	}
+	return (v - minValue) / (maxValue - minValue)
+}
+
+// blockSparkline 每个数据点一个方块字符，缺失的数据点为空格
+func blockSparkline(values []float64, minValue, maxValue float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		if isGap(v) {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteRune(sparkBlocks[int(math.Round(level(v, minValue, maxValue)*float64(len(sparkBlocks)-1)))])
+	}
+	return b.String()
+}
+
+// brailleSparkline 每个盲文字符绘制两个数据点，每个数据点从底部填充1到4个点，最小值也保留一个点以区别于缺失
+func brailleSparkline(values []float64, minValue, maxValue float64) string {
+	var b strings.Builder
+	for i := 0; i < len(values); i += 2 {
+		ch := rune(brailleBlank)
+		for col := 0; col < 2 && i+col < len(values); col++ {
+			v := values[i+col]
+			if isGap(v) {
+				continue
+			}
+			dots := 1 + int(math.Round(level(v, minValue, maxValue)*3))
+			for row := 0; row < dots; row++ {
+				ch |= brailleDots[col][row]
+			}
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// formatValue 保留一位小数，整数不显示小数部分
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// wrapFields 用两个空格连接各项，超过宽度时换行
+func wrapFields(fields []string, width int) []string {
+	var lines []string
+	current := ""
+	for _, field := range fields {
+		switch {
+		case current == "":
+			current = field
+		case cellWidth(current)+2+cellWidth(field) <= width:
+			current += "  " + field
+		default:
+			lines = append(lines, current)
+			current = field
+		}
+	}
+	return append(lines, current)
+}
+
+// cellWidth 文本的显示宽度，非ASCII字符按两个字符宽度计算
+func cellWidth(s string) int {
+	cells := 0
+	for _, r := range s {
+		cells += runeCells(r)
+	}
+	return cells
+}
+
+// truncateCells 截断超过宽度的文本行，末尾以...标记
+func truncateCells(s string, width int) string {
+	if cellWidth(s) <= width {
+		return s
+	}
+	cells := 0
+	for i, r := range s {
+		if cells+runeCells(r) > width-3 {
+			return s[:i] + "..."
+		}
+		cells += runeCells(r)
+	}
+	return s
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,6 +16,8 @@ type Config struct {
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
 	Messaging  MessagingConfig  `yaml:"messaging"`
 	OpenClaw   OpenClawConfig   `yaml:"openclaw"`
+	Monitoring MonitoringConfig `yaml:"monitoring"`
+	Storage    StorageConfig    `yaml:"storage"`
 	Server     ServerConfig     `yaml:"server"`
 }
 
@@ -22,7 +28,7 @@ type KubernetesConfig struct {
 
 // ClusterConfig 集群配置
 type ClusterConfig struct {
-	Name      string `yaml:"name"`
+	Name       string `yaml:"name"`
 	Kubeconfig string `yaml:"kubeconfig"`
 	Context    string `yaml:"context"`
 }
@@ -31,15 +37,29 @@ type ClusterConfig struct {
 type MessagingConfig struct {
 	DingTalk DingTalkConfig `yaml:"dingtalk"`
 	Feishu   FeishuConfig   `yaml:"feishu"`
+	// Charts 聊天消息中图表的发送方式
+	Charts ChatChartsConfig `yaml:"charts"`
+}
+
+// ChatChartsConfig 聊天消息中图表的发送方式，图片被禁用或发送失败时以文本迷你图回复
+type ChatChartsConfig struct {
+	// DisableImages 不发送图片，直接以文本迷你图回复，用于禁止图片的群聊
+	DisableImages bool `yaml:"disable_images"`
+	// TextStyle 文本迷你图的字符样式：blocks（方块，默认）或 braille（盲文点阵，水平精度加倍）
+	TextStyle string `yaml:"text_style"`
+	// TextWidth 文本迷你图每行的最大字符数，默认40
+	TextWidth int `yaml:"text_width"`
 }
 
 // DingTalkConfig 钉钉配置
 type DingTalkConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	AppKey  string `yaml:"app_key"`
+	Enabled   bool   `yaml:"enabled"`
+	AppKey    string `yaml:"app_key"`
 	AppSecret string `yaml:"app_secret"`
 	Webhook   string `yaml:"webhook"`
 	Secret    string `yaml:"secret"`
+	// APIBase 开放平台地址，默认 https://oapi.dingtalk.com，发送图片时通过企业内部应用（app_key/app_secret）上传
+	APIBase string `yaml:"api_base"`
 }
 
 // FeishuConfig 飞书配置
@@ -47,6 +67,10 @@ type FeishuConfig struct {
 	Enabled   bool   `yaml:"enabled"`
 	AppID     string `yaml:"app_id"`
 	AppSecret string `yaml:"app_secret"`
+	// ChatID 默认发送消息的群聊ID
+	ChatID string `yaml:"chat_id"`
+	// APIBase 开放平台地址，默认 https://open.feishu.cn，海外版Lark为 https://open.larksuite.com
+	APIBase string `yaml:"api_base"`
 }
 
 // OpenClawConfig OpenClaw配置
@@ -55,9 +79,305 @@ type OpenClawConfig struct {
 	Skills  string `yaml:"skills"`
 }
 
+// MonitoringConfig 监控配置
+type MonitoringConfig struct {
+	Prometheus PrometheusConfig `yaml:"prometheus"`
+	// RulesFile 告警规则文件，相对路径相对于配置文件所在目录
+	RulesFile string            `yaml:"rules_file"`
+	Rules     []AlertRuleConfig `yaml:"rules"`
+	// RepeatInterval 告警持续firing时重复通知的间隔
+	RepeatInterval time.Duration `yaml:"repeat_interval"`
+	// MaintenanceWindows 周期性维护窗口，窗口内匹配的告警照常记录但不发送通知
+	MaintenanceWindows []MaintenanceWindowConfig `yaml:"maintenance_windows"`
+	// EscalationPolicies 升级策略，告警超过截止时间仍未确认时逐级扩大通知范围
+	EscalationPolicies []EscalationPolicyConfig `yaml:"escalation_policies"`
+	// Receivers 通知接收方，由Route按告警标签选择
+	Receivers []ReceiverConfig `yaml:"receivers"`
+	// Route 通知路由树的根节点，未配置时所有告警发送到默认的钉钉和飞书
+	Route *RouteConfig `yaml:"route"`
+	// Detectors 内置检测器配置
+	Detectors DetectorsConfig `yaml:"detectors"`
+	// SLOs 服务等级目标，根据存储的指标采样计算达成率并按错误预算燃烧率告警
+	SLOs []SLOConfig `yaml:"slos"`
+}
+
+// SLOConfig 工作负载就绪副本数的服务等级目标，如 "Deployment api 至少有2个就绪副本的时间占30天的99.9%"
+type SLOConfig struct {
+	Name      string `yaml:"name"`
+	Cluster   string `yaml:"cluster"`
+	Namespace string `yaml:"namespace"`
+	// Kind 工作负载类型，默认Deployment
+	Kind     string `yaml:"kind"`
+	Workload string `yaml:"workload"`
+	// MinReady 满足目标所需的最少就绪副本数，默认1
+	MinReady int `yaml:"min_ready"`
+	// Objective 目标达成率（百分比），如 99.9
+	Objective float64 `yaml:"objective"`
+	// Window 计算达成率和错误预算的时间窗口，默认720h（30天）
+	Window time.Duration `yaml:"window"`
+}
+
+// DetectorsConfig 内置检测器配置
+type DetectorsConfig struct {
+	Pods         PodDetectorConfig          `yaml:"pods"`
+	Nodes        NodeDetectorConfig         `yaml:"nodes"`
+	Volumes      VolumeDetectorConfig       `yaml:"volumes"`
+	Certificates CertificateDetectorConfig  `yaml:"certificates"`
+	ControlPlane ControlPlaneDetectorConfig `yaml:"control_plane"`
+	Events       EventDetectorConfig        `yaml:"events"`
+	Anomaly      AnomalyDetectorConfig      `yaml:"anomaly"`
+}
+
+// NodeDetectorConfig 节点健康检测器配置，未配置的字段使用默认值
+type NodeDetectorConfig struct {
+	// FlapThreshold 节点在FlapWindow内Ready状态变化次数达到该值时告警，默认3
+	FlapThreshold int `yaml:"flap_threshold"`
+	// FlapWindow 统计Ready状态变化的时间窗口，默认30m
+	FlapWindow time.Duration `yaml:"flap_window"`
+	// LeaseTimeout kubelet心跳Lease超过该时长未续约时告警，默认1m
+	LeaseTimeout time.Duration `yaml:"lease_timeout"`
+}
+
+// VolumeDetectorConfig PVC检测器配置，未配置的字段使用默认值
+type VolumeDetectorConfig struct {
+	// PendingTimeout PVC处于Pending超过该时长时告警，默认5m
+	PendingTimeout time.Duration `yaml:"pending_timeout"`
+	// WarningPercent 卷空间或inode使用率达到该值时发出warning告警，默认80
+	WarningPercent float64 `yaml:"warning_percent"`
+	// CriticalPercent 卷空间或inode使用率达到该值时发出critical告警，默认90
+	CriticalPercent float64 `yaml:"critical_percent"`
+	// PredictionWindow 用于线性预测写满时间的历史时长，默认6h
+	PredictionWindow time.Duration `yaml:"prediction_window"`
+	// PredictionHorizon 预测在该时长内写满时告警，默认24h
+	PredictionHorizon time.Duration `yaml:"prediction_horizon"`
+}
+
+// CertificateDetectorConfig TLS证书检测器配置，未配置的字段使用默认值
+type CertificateDetectorConfig struct {
+	// WarningDays 证书剩余天数不超过该值时发出warning告警，默认30
+	WarningDays int `yaml:"warning_days"`
+	// CriticalDays 证书剩余天数不超过该值时发出critical告警，默认7
+	CriticalDays int `yaml:"critical_days"`
+	// ScanInterval 重新列出TLS Secret和cert-manager Certificate的间隔，默认6h，两次扫描之间按缓存的证书计算剩余天数
+	ScanInterval time.Duration `yaml:"scan_interval"`
+}
+
+// ControlPlaneDetectorConfig 控制面检测器配置，未配置的字段使用默认值
+type ControlPlaneDetectorConfig struct {
+	// LatencyThreshold API Server请求延迟中位数超过该值时告警，默认1s
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+}
+
+// EventDetectorConfig Warning事件监听配置，未配置的字段使用默认值
+type EventDetectorConfig struct {
+	// Filters 丢弃匹配的噪声事件，不参与聚合、实时事件流和突增检测
+	Filters []EventFilterConfig `yaml:"filters"`
+	// SpikeWindow 统计事件速率的时间窗口，默认5m
+	SpikeWindow time.Duration `yaml:"spike_window"`
+	// SpikeThreshold 同一原因的事件在SpikeWindow内达到该次数才可能告警，默认10
+	SpikeThreshold int `yaml:"spike_threshold"`
+	// SpikeFactor 事件数达到基线速率的该倍数时告警，默认3
+	SpikeFactor float64 `yaml:"spike_factor"`
+	// BaselineWindow 计算基线速率的历史时长，同时是聚合结果的保留时长，默认1h
+	BaselineWindow time.Duration `yaml:"baseline_window"`
+}
+
+// EventFilterConfig 事件过滤条件，配置的字段全部匹配时丢弃事件
+type EventFilterConfig struct {
+	Reason    string `yaml:"reason"`
+	Namespace string `yaml:"namespace"`
+	// Kind 事件涉及对象的类型，如 Pod
+	Kind string `yaml:"kind"`
+	// Message 匹配事件消息的正则表达式
+	Message string `yaml:"message"`
+}
+
+// AnomalyDetectorConfig 统计异常检测配置，默认不启用
+type AnomalyDetectorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Method 基线计算方式：rolling使用最近Window内的均值和标准差，seasonal使用之前各周同一时刻（hour-of-week）的值，默认rolling
+	Method string `yaml:"method"`
+	// Step 每个采样点的统计时长，速率类序列的值为每个Step内的次数，默认5m
+	Step time.Duration `yaml:"step"`
+	// Window rolling基线的历史时长，默认24h
+	Window time.Duration `yaml:"window"`
+	// Weeks seasonal基线使用的历史周数，默认4
+	Weeks int `yaml:"weeks"`
+	// Sensitivity 偏离均值超过该倍数的标准差时告警，默认3
+	Sensitivity float64 `yaml:"sensitivity"`
+	// MinSamples 基线至少需要的采样点数，不足时不检测，默认rolling为12、seasonal为2
+	MinSamples int `yaml:"min_samples"`
+	// Series 检测的序列，未配置时检测Pod总数、重启速率、CPU和内存使用率以及Warning事件速率
+	Series []AnomalySeriesConfig `yaml:"series"`
+}
+
+// AnomalySeriesConfig 单个序列的异常检测配置，未配置的字段使用全局配置
+type AnomalySeriesConfig struct {
+	// Field 集群指标字段（如 pods.total、cpu.usage_percent），或派生序列 pods.restart_rate、events.warning_rate
+	Field       string  `yaml:"field"`
+	Method      string  `yaml:"method"`
+	Sensitivity float64 `yaml:"sensitivity"`
+	// MinDelta 观察值与均值的差至少达到该值时才告警，避免平稳序列的微小变化告警
+	MinDelta float64 `yaml:"min_delta"`
+}
+
+// PodDetectorConfig Pod故障检测器配置，未配置的字段使用默认值
+type PodDetectorConfig struct {
+	// LogLines 告警中附带的容器最后日志行数，默认10
+	LogLines int64 `yaml:"log_lines"`
+	// OOMWindow 容器在该时长内被OOMKilled时告警，默认1h
+	OOMWindow time.Duration `yaml:"oom_window"`
+	// RestartThreshold 工作负载在RestartWindow内的重启次数达到该值时告警，默认5
+	RestartThreshold int `yaml:"restart_threshold"`
+	// RestartWindow 统计重启次数的时间窗口，默认10m
+	RestartWindow time.Duration `yaml:"restart_window"`
+}
+
+// ReceiverConfig 通知接收方配置，一个接收方可以包含多个钉钉机器人和飞书群
+type ReceiverConfig struct {
+	Name     string                   `yaml:"name"`
+	DingTalk []DingTalkReceiverConfig `yaml:"dingtalk"`
+	Feishu   []FeishuReceiverConfig   `yaml:"feishu"`
+}
+
+// DingTalkReceiverConfig 钉钉机器人接收方
+type DingTalkReceiverConfig struct {
+	Webhook string `yaml:"webhook"`
+	Secret  string `yaml:"secret"`
+}
+
+// FeishuReceiverConfig 飞书群接收方，使用feishu配置中的应用凭证发送
+type FeishuReceiverConfig struct {
+	ChatID string `yaml:"chat_id"`
+}
+
+// RouteConfig 通知路由配置，子路由未配置的receiver和分组参数继承父路由
+type RouteConfig struct {
+	Receiver string `yaml:"receiver"`
+	// Match 标签完全匹配，可用标签包括告警标签以及alertname、cluster、namespace、severity
+	Match map[string]string `yaml:"match"`
+	// MatchRE 标签正则匹配
+	MatchRE map[string]string `yaml:"match_re"`
+	// Continue 匹配后是否继续匹配后续的兄弟路由
+	Continue bool `yaml:"continue"`
+	// GroupBy 分组标签，相同分组的告警合并为一条消息，"..."表示按所有标签分组
+	GroupBy []string `yaml:"group_by"`
+	// GroupWait 新分组发送第一条消息前的等待时间
+	GroupWait time.Duration `yaml:"group_wait"`
+	// GroupInterval 同一分组两次发送之间的最小间隔
+	GroupInterval time.Duration `yaml:"group_interval"`
+	Routes        []RouteConfig `yaml:"routes"`
+}
+
+// EscalationPolicyConfig 升级策略配置，按顺序匹配，告警使用第一个匹配的策略
+type EscalationPolicyConfig struct {
+	Name string `yaml:"name"`
+	// Severity 策略生效的告警级别，为空时为critical
+	Severity string                 `yaml:"severity"`
+	Match    AlertMatcherConfig     `yaml:"match"`
+	Steps    []EscalationStepConfig `yaml:"steps"`
+}
+
+// EscalationStepConfig 升级步骤，告警触发后超过After仍未确认时重新通知并@值班人
+type EscalationStepConfig struct {
+	After time.Duration `yaml:"after"`
+	// DingTalkMobiles 钉钉中需要@的值班人手机号
+	DingTalkMobiles []string `yaml:"dingtalk_mobiles"`
+	// FeishuUserIDs 飞书中需要@的值班人open_id
+	FeishuUserIDs []string `yaml:"feishu_user_ids"`
+	// MentionAll 是否@所有人
+	MentionAll bool `yaml:"mention_all"`
+	// Receivers 除告警路由匹配的接收方外，该步骤额外通知的接收方名称
+	Receivers []string `yaml:"receivers"`
+}
+
+// AlertMatcherConfig 告警匹配条件，所有非空条件都满足时匹配
+type AlertMatcherConfig struct {
+	Cluster   string            `yaml:"cluster"`
+	Namespace string            `yaml:"namespace"`
+	Node      string            `yaml:"node"`
+	Rule      string            `yaml:"rule"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+// MaintenanceWindowConfig 维护窗口配置
+type MaintenanceWindowConfig struct {
+	Name string `yaml:"name"`
+	// Days 窗口生效的星期，如 [Sat, Sun]，为空表示每天
+	Days []string `yaml:"days"`
+	// Start 窗口开始时间，格式为 HH:MM
+	Start    string        `yaml:"start"`
+	Duration time.Duration `yaml:"duration"`
+	// Timezone 时区，如 Asia/Shanghai，为空时使用本地时区
+	Timezone string             `yaml:"timezone"`
+	Match    AlertMatcherConfig `yaml:"match"`
+}
+
+// AlertRuleConfig 告警规则配置
+type AlertRuleConfig struct {
+	Name string `yaml:"name"`
+	// Scope 规则的作用范围：cluster、namespace、node或workload
+	Scope string `yaml:"scope"`
+	// Expr 基于指标字段的表达式，如 pods.failed > 0
+	Expr string `yaml:"expr"`
+	// For 条件持续满足多长时间后才触发
+	For      time.Duration     `yaml:"for"`
+	Severity string            `yaml:"severity"`
+	Labels   map[string]string `yaml:"labels"`
+	// Message 告警消息模板，使用Go template语法
+	Message string `yaml:"message"`
+	// Namespaces 限定namespace和workload范围规则生效的命名空间，为空时对所有命名空间生效
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// RulesFile 告警规则文件结构
+type RulesFile struct {
+	Rules []AlertRuleConfig `yaml:"rules"`
+}
+
+// PrometheusConfig Prometheus数据源配置
+type PrometheusConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+	// ClusterLabel 多个集群共用一个Prometheus时用于区分集群的标签名
+	ClusterLabel string        `yaml:"cluster_label"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// StorageConfig 持久化存储配置
+type StorageConfig struct {
+	Enabled   bool            `yaml:"enabled"`
+	Path      string          `yaml:"path"`
+	Retention RetentionConfig `yaml:"retention"`
+}
+
+// RetentionConfig 数据保留时长，指标按原始、5分钟、1小时三级降采样
+type RetentionConfig struct {
+	Raw        time.Duration `yaml:"raw"`
+	FiveMinute time.Duration `yaml:"five_minute"`
+	OneHour    time.Duration `yaml:"one_hour"`
+	Audit      time.Duration `yaml:"audit"`
+	Events     time.Duration `yaml:"events"`
+}
+
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port int `yaml:"port"`
+	// AuthToken API访问令牌，请求在Authorization头中携带 Bearer 令牌或在klaw_token Cookie中携带时视为已登录。
+	// 生成图表签名链接需要登录，已登录的请求访问图表时不需要签名
+	AuthToken string `yaml:"auth_token"`
+	// Charts 图表渲染接口配置
+	Charts ChartsConfig `yaml:"charts"`
+}
+
+// ChartsConfig 图表渲染接口配置
+type ChartsConfig struct {
+	// SigningKey 签名URL的密钥，配置后图表接口只接受带有效签名且未过期的请求
+	SigningKey string `yaml:"signing_key"`
+	// CacheTTL 渲染结果的缓存时长，默认30s
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// MaxLinkTTL 签名URL的最长有效期，默认720h（30天）
+	MaxLinkTTL time.Duration `yaml:"max_link_ttl"`
 }
 
 // Load 加载配置文件
@@ -79,10 +399,89 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config file: %v", err)
 	}
 
+	// 加载告警规则文件，与配置文件中同名的规则以配置文件为准
+	if config.Monitoring.RulesFile != "" {
+		rulesPath := config.Monitoring.RulesFile
+		if !filepath.IsAbs(rulesPath) {
+			rulesPath = filepath.Join(filepath.Dir(path), rulesPath)
+		}
+		rules, err := LoadRules(rulesPath)
+		if err != nil {
+			return nil, err
+		}
+		overridden := make(map[string]bool)
+		for _, rule := range config.Monitoring.Rules {
+			overridden[rule.Name] = true
+		}
+		for _, rule := range rules {
+			if !overridden[rule.Name] {
+				config.Monitoring.Rules = append(config.Monitoring.Rules, rule)
+			}
+		}
+	}
+
 	// 设置默认值
 	if config.Server.Port == 0 {
 		config.Server.Port = 8080
 	}
+	if config.Server.Charts.CacheTTL == 0 {
+		config.Server.Charts.CacheTTL = 30 * time.Second
+	}
+	if config.Server.Charts.MaxLinkTTL == 0 {
+		config.Server.Charts.MaxLinkTTL = 30 * 24 * time.Hour
+	}
+	if config.Messaging.Charts.TextStyle == "" {
+		config.Messaging.Charts.TextStyle = "blocks"
+	}
+	if config.Messaging.Charts.TextWidth == 0 {
+		config.Messaging.Charts.TextWidth = 40
+	}
+	if config.Monitoring.Prometheus.Timeout == 0 {
+		config.Monitoring.Prometheus.Timeout = 30 * time.Second
+	}
+	if config.Monitoring.RepeatInterval == 0 {
+		config.Monitoring.RepeatInterval = 4 * time.Hour
+	}
+	if config.Storage.Path == "" {
+		config.Storage.Path = "./data/klaw.db"
+	}
+	if config.Storage.Retention.Raw == 0 {
+		config.Storage.Retention.Raw = 24 * time.Hour
+	}
+	if config.Storage.Retention.FiveMinute == 0 {
+		config.Storage.Retention.FiveMinute = 7 * 24 * time.Hour
+	}
+	if config.Storage.Retention.OneHour == 0 {
+		config.Storage.Retention.OneHour = 30 * 24 * time.Hour
+	}
+	if config.Storage.Retention.Audit == 0 {
+		config.Storage.Retention.Audit = 30 * 24 * time.Hour
+	}
+	if config.Storage.Retention.Events == 0 {
+		config.Storage.Retention.Events = 14 * 24 * time.Hour
+	}
 
 	return &config, nil
 }
+
+// LoadRules 加载告警规则文件，文件中出现未知字段（如拼写错误的字段名）或规则缺少name、expr时返回错误
+func LoadRules(path string) ([]AlertRuleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %v", err)
+	}
+
+	var file RulesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to unmarshal rules file %s: %v", path, err)
+	}
+
+	for i, rule := range file.Rules {
+		if rule.Name == "" || rule.Expr == "" {
+			return nil, fmt.Errorf("rule %d in %s requires name and expr", i+1, path)
+		}
+	}
+	return file.Rules, nil
+}
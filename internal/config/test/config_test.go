@@ -1,7 +1,10 @@
 package config_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/kudig-io/klaw/internal/config"
 )
@@ -22,3 +25,96 @@ func TestLoadConfig(t *testing.T) {
 		t.Error("Expected at least one cluster in config")
 	}
 }
+
+// writeFile 在目录中写入测试文件并返回路径
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := config.LoadRules("../../../configs/rules.yaml.example")
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if len(rules) == 0 {
+		t.Fatal("expected rules in example rules file")
+	}
+	if rules[0].Name != "NodeHighMemory" || rules[0].Scope != "node" || rules[0].For != 5*time.Minute {
+		t.Errorf("unexpected first rule: %+v", rules[0])
+	}
+}
+
+func TestLoadConfig_RulesFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "rules.yaml", `
+rules:
+  - name: PodsFailed
+    scope: namespace
+    expr: pods.failed > 0
+    severity: critical
+  - name: PodsPending
+    scope: namespace
+    expr: pods.pending > 10
+    for: 10m
+`)
+	// 配置文件中的同名规则覆盖规则文件中的规则，规则文件相对于配置文件所在目录
+	path := writeFile(t, dir, "config.yaml", `
+monitoring:
+  rules_file: rules.yaml
+  rules:
+    - name: PodsFailed
+      scope: namespace
+      expr: pods.failed > 3
+      severity: warning
+`)
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	rules := cfg.Monitoring.Rules
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", rules)
+	}
+	if rules[0].Name != "PodsFailed" || rules[0].Expr != "pods.failed > 3" || rules[0].Severity != "warning" {
+		t.Errorf("expected inline rule to override the rules file, got %+v", rules[0])
+	}
+	if rules[1].Name != "PodsPending" || rules[1].For != 10*time.Minute {
+		t.Errorf("expected rule from the rules file, got %+v", rules[1])
+	}
+}
+
+func TestLoadRules_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed yaml", "rules:\n  - name: [PodsFailed\n"},
+		{"unknown field", "rules:\n  - name: PodsFailed\n    expression: pods.failed > 0\n"},
+		{"missing expr", "rules:\n  - name: PodsFailed\n    scope: namespace\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := config.LoadRules(writeFile(t, dir, "rules.yaml", tt.content)); err == nil {
+				t.Error("expected error for invalid rules file")
+			}
+		})
+	}
+
+	if _, err := config.LoadRules(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing rules file")
+	}
+
+	// 规则文件无效时加载配置失败
+	writeFile(t, dir, "rules.yaml", "rules: [")
+	path := writeFile(t, dir, "config.yaml", "monitoring:\n  rules_file: rules.yaml\n")
+	if _, err := config.Load(path); err == nil {
+		t.Error("expected Load() to fail for an invalid rules file")
+	}
+}
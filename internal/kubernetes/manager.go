@@ -2,8 +2,8 @@ package kubernetes
 
 import (
 	"fmt"
-	"path/filepath"
 	"os"
+	"path/filepath"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -14,14 +14,14 @@ import (
 
 // Manager Kubernetes管理器
 type Manager struct {
-	clients map[string]*kubernetes.Clientset
+	clients  map[string]kubernetes.Interface
 	clusters []config.ClusterConfig
 }
 
 // NewManager 创建Kubernetes管理器
 func NewManager(cfg config.KubernetesConfig) (*Manager, error) {
 	m := &Manager{
-		clients: make(map[string]*kubernetes.Clientset),
+		clients:  make(map[string]kubernetes.Interface),
 		clusters: cfg.Clusters,
 	}
 
@@ -37,6 +37,14 @@ func NewManager(cfg config.KubernetesConfig) (*Manager, error) {
 	return m, nil
 }
 
+// NewManagerWithClients 使用已创建的客户端创建Kubernetes管理器
+func NewManagerWithClients(clusters []config.ClusterConfig, clients map[string]kubernetes.Interface) *Manager {
+	return &Manager{
+		clients:  clients,
+		clusters: clusters,
+	}
+}
+
 // initClient 初始化集群客户端
 func (m *Manager) initClient(cluster config.ClusterConfig) (*kubernetes.Clientset, error) {
 	// 确定kubeconfig路径
@@ -81,7 +89,11 @@ func (m *Manager) initClient(cluster config.ClusterConfig) (*kubernetes.Clientse
 }
 
 // GetClient 获取集群客户端
-func (m *Manager) GetClient(clusterName string) (*kubernetes.Clientset, error) {
+func (m *Manager) GetClient(clusterName string) (kubernetes.Interface, error) {
+	if m == nil {
+		return nil, fmt.Errorf("kubernetes manager not initialized")
+	}
+
 	client, ok := m.clients[clusterName]
 	if !ok {
 		return nil, fmt.Errorf("cluster not found: %s", clusterName)
@@ -91,6 +103,9 @@ func (m *Manager) GetClient(clusterName string) (*kubernetes.Clientset, error) {
 
 // GetClusters 获取所有集群
 func (m *Manager) GetClusters() []config.ClusterConfig {
+	if m == nil {
+		return nil
+	}
 	return m.clusters
 }
 
@@ -7,29 +7,45 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kudig-io/klaw/internal/config"
 	"github.com/kudig-io/klaw/internal/kubernetes"
 )
 
+// defaultAPIBase 钉钉开放平台默认地址
+const defaultAPIBase = "https://oapi.dingtalk.com"
+
 // Client 钉钉客户端
 type Client struct {
-	config      config.DingTalkConfig
-	k8sManager  *kubernetes.Manager
-	webhook     string
-	secret      string
+	config     config.DingTalkConfig
+	k8sManager *kubernetes.Manager
+	webhook    string
+	secret     string
+	apiBase    string
+
+	// mutex 保护access token缓存，图表推送和聊天命令会并发上传图片
+	mutex       sync.Mutex
 	accessToken string
+	tokenExpiry time.Time
 }
 
 // NewClient 创建钉钉客户端
 func NewClient(cfg config.DingTalkConfig) (*Client, error) {
+	apiBase := strings.TrimSuffix(cfg.APIBase, "/")
+	if apiBase == "" {
+		apiBase = defaultAPIBase
+	}
 	return &Client{
 		config:  cfg,
 		webhook: cfg.Webhook,
 		secret:  cfg.Secret,
+		apiBase: apiBase,
 	}, nil
 }
 
@@ -47,6 +63,16 @@ func (c *Client) Start() {
 
 // SendMessage 发送消息到钉钉
 func (c *Client) SendMessage(message string) error {
+	return c.SendMentionMessage(message, nil, false)
+}
+
+// SendMentionMessage 发送消息到钉钉并@指定手机号的群成员，atAll为true时@所有人
+func (c *Client) SendMentionMessage(message string, mobiles []string, atAll bool) error {
+	// 钉钉要求被@的手机号同时出现在消息内容中
+	for _, mobile := range mobiles {
+		message += " @" + mobile
+	}
+
 	// 生成签名
 	timestamp := time.Now().UnixMilli()
 	signature := c.generateSignature(timestamp)
@@ -61,6 +87,12 @@ func (c *Client) SendMessage(message string) error {
 			"content": message,
 		},
 	}
+	if len(mobiles) > 0 || atAll {
+		requestBody["at"] = map[string]interface{}{
+			"atMobiles": mobiles,
+			"isAtAll":   atAll,
+		}
+	}
 
 	// 编码请求体
 	data, err := json.Marshal(requestBody)
@@ -69,17 +101,31 @@ func (c *Client) SendMessage(message string) error {
 	}
 
 	// 发送请求
-	resp, err := http.Post(requestURL, "application/json", nil)
+	resp, err := http.Post(requestURL, "application/json", bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to send message: %v", err)
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态
+	return checkResponse(resp, "send message")
+}
+
+// checkResponse 检查机器人接口的响应。钉钉在请求失败时（如签名错误、触发限流）仍返回HTTP 200，
+// 需要检查响应体中的errcode
+func checkResponse(resp *http.Response, action string) error {
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send message, status code: %d", resp.StatusCode)
+		return fmt.Errorf("failed to %s, status code: %d", action, resp.StatusCode)
+	}
+	var response struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", action, err)
+	}
+	if response.ErrCode != 0 {
+		return fmt.Errorf("failed to %s, errcode: %d, errmsg: %s", action, response.ErrCode, response.ErrMsg)
 	}
-
 	return nil
 }
 
@@ -108,8 +154,14 @@ func (c *Client) HandleMessage(message string) (string, error) {
 	return "收到消息: " + message, nil
 }
 
-// SendImage 发送图片到钉钉
+// SendImage 发送图片到钉钉。自定义机器人不能直接发送图片，markdown中的data URI也不会显示，
+// 因此先通过企业内部应用上传图片，再在markdown消息中引用返回的media_id
 func (c *Client) SendImage(imageData []byte, message string) error {
+	mediaID, err := c.uploadImage(imageData)
+	if err != nil {
+		return err
+	}
+
 	// 生成签名
 	timestamp := time.Now().UnixMilli()
 	signature := c.generateSignature(timestamp)
@@ -122,7 +174,7 @@ func (c *Client) SendImage(imageData []byte, message string) error {
 		"msgtype": "markdown",
 		"markdown": map[string]string{
 			"title": "Kubernetes监控图表",
-			"text":  fmt.Sprintf("## %s\n\n![监控图表](data:image/png;base64,%s)", message, base64.StdEncoding.EncodeToString(imageData)),
+			"text":  fmt.Sprintf("## %s\n\n![监控图表](%s)", message, mediaID),
 		},
 	}
 
@@ -139,12 +191,83 @@ func (c *Client) SendImage(imageData []byte, message string) error {
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send image, status code: %d", resp.StatusCode)
+	return checkResponse(resp, "send image")
+}
+
+// getAccessToken 获取企业内部应用的access token，过期前5分钟刷新
+func (c *Client) getAccessToken() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.tokenExpiry) {
+		return c.accessToken, nil
+	}
+	if c.config.AppKey == "" || c.config.AppSecret == "" {
+		return "", fmt.Errorf("dingtalk app_key and app_secret are required to upload images")
 	}
 
-	return nil
+	query := url.Values{"appkey": {c.config.AppKey}, "appsecret": {c.config.AppSecret}}
+	resp, err := http.Get(c.apiBase + "/gettoken?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode access token response: %v", err)
+	}
+	if response.ErrCode != 0 {
+		return "", fmt.Errorf("failed to get access token, errcode: %d, errmsg: %s", response.ErrCode, response.ErrMsg)
+	}
+
+	c.accessToken = response.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(response.ExpiresIn-300) * time.Second)
+	return c.accessToken, nil
+}
+
+// uploadImage 上传PNG图片，返回media_id
+func (c *Client) uploadImage(imageData []byte) (string, error) {
+	token, err := c.getAccessToken()
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("media", "chart.png")
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+	part.Write(imageData)
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+
+	query := url.Values{"access_token": {token}, "type": {"image"}}
+	resp, err := http.Post(c.apiBase+"/media/upload?"+query.Encode(), writer.FormDataContentType(), &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		MediaID string `json:"media_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode upload response: %v", err)
+	}
+	if response.ErrCode != 0 || response.MediaID == "" {
+		return "", fmt.Errorf("failed to upload image, errcode: %d, errmsg: %s", response.ErrCode, response.ErrMsg)
+	}
+	return response.MediaID, nil
 }
 
 // SendChart 发送图表到钉钉
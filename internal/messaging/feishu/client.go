@@ -2,32 +2,46 @@ package feishu
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kudig-io/klaw/internal/config"
 	"github.com/kudig-io/klaw/internal/kubernetes"
 )
 
+// defaultAPIBase 飞书开放平台默认地址
+const defaultAPIBase = "https://open.feishu.cn"
+
 // Client 飞书客户端
 type Client struct {
-	config      config.FeishuConfig
-	k8sManager  *kubernetes.Manager
-	appID       string
-	appSecret   string
+	config     config.FeishuConfig
+	k8sManager *kubernetes.Manager
+	appID      string
+	appSecret  string
+	apiBase    string
+
+	// mutex 保护access token缓存，图表推送和聊天命令会并发发送消息
+	mutex       sync.Mutex
 	accessToken string
 	tokenExpiry time.Time
 }
 
 // NewClient 创建飞书客户端
 func NewClient(cfg config.FeishuConfig) (*Client, error) {
+	apiBase := strings.TrimSuffix(cfg.APIBase, "/")
+	if apiBase == "" {
+		apiBase = defaultAPIBase
+	}
 	return &Client{
 		config:    cfg,
 		appID:     cfg.AppID,
 		appSecret: cfg.AppSecret,
+		apiBase:   apiBase,
 	}, nil
 }
 
@@ -43,15 +57,15 @@ func (c *Client) Start() {
 	fmt.Println("Feishu client started")
 
 	// 初始化获取access token
-	if err := c.refreshAccessToken(); err != nil {
+	if _, err := c.getAccessToken(); err != nil {
 		fmt.Printf("Failed to refresh access token: %v\n", err)
 	}
 }
 
-// refreshAccessToken 刷新access token
+// refreshAccessToken 刷新access token，调用时需持有mutex
 func (c *Client) refreshAccessToken() error {
 	// 构建请求URL
-	url := "https://open.feishu.cn/open-apis/auth/v3/app_access_token/internal"
+	url := c.apiBase + "/open-apis/auth/v3/app_access_token/internal"
 
 	// 构建请求体
 	requestBody := map[string]string{
@@ -66,7 +80,7 @@ func (c *Client) refreshAccessToken() error {
 	}
 
 	// 发送请求
-	resp, err := http.Post(url, "application/json", nil)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to refresh access token: %v", err)
 	}
@@ -103,6 +117,9 @@ func (c *Client) refreshAccessToken() error {
 
 // getAccessToken 获取access token
 func (c *Client) getAccessToken() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	// 检查access token是否过期
 	if time.Now().After(c.tokenExpiry) {
 		if err := c.refreshAccessToken(); err != nil {
@@ -113,16 +130,25 @@ func (c *Client) getAccessToken() (string, error) {
 	return c.accessToken, nil
 }
 
-// SendMessage 发送消息到飞书
+// SendMessage 发送消息到飞书默认群聊
 func (c *Client) SendMessage(message string) error {
+	return c.SendMessageToChat(c.config.ChatID, message)
+}
+
+// SendMessageToChat 发送消息到指定飞书群聊
+func (c *Client) SendMessageToChat(chatID, message string) error {
+	if chatID == "" {
+		return fmt.Errorf("feishu chat_id is not configured")
+	}
+
 	// 获取access token
 	token, err := c.getAccessToken()
 	if err != nil {
 		return err
 	}
 
-	// 构建请求URL
-	url := "https://open.feishu.cn/open-apis/im/v1/messages"
+	// 构建请求URL，receive_id_type通过查询参数指定
+	url := c.apiBase + "/open-apis/im/v1/messages?receive_id_type=chat_id"
 
 	// 构建请求头
 	headers := map[string]string{
@@ -130,12 +156,17 @@ func (c *Client) SendMessage(message string) error {
 		"Content-Type":  "application/json",
 	}
 
+	// 消息内容本身是JSON字符串，需要单独编码以转义引号和换行
+	content, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message content: %v", err)
+	}
+
 	// 构建请求体
 	requestBody := map[string]interface{}{
-		"receive_id_type": "chat_id",
-		"receive_id":      "oc_abcdefg", // 这里需要替换为实际的聊天ID
-		"content":         fmt.Sprintf(`{\"text\":\"%s\"}`, message),
-		"msg_type":        "text",
+		"receive_id": chatID,
+		"content":    string(content),
+		"msg_type":   "text",
 	}
 
 	// 编码请求体
@@ -145,7 +176,7 @@ func (c *Client) SendMessage(message string) error {
 	}
 
 	// 创建请求
-	req, err := http.NewRequest("POST", url, nil)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -163,14 +194,44 @@ func (c *Client) SendMessage(message string) error {
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send message, status code: %d", resp.StatusCode)
-	}
+	return checkResponse(resp, "send message")
+}
 
+// checkResponse 检查开放平台接口的响应，飞书在业务错误（如机器人不在群中、image_key无效）时
+// 可能仍返回HTTP 200，需要检查响应体中的code
+func checkResponse(resp *http.Response, action string) error {
+	var response struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to %s, status code: %d", action, resp.StatusCode)
+		}
+		return fmt.Errorf("failed to decode %s response: %v", action, err)
+	}
+	if resp.StatusCode != http.StatusOK || response.Code != 0 {
+		return fmt.Errorf("failed to %s, status code: %d, code: %d, msg: %s", action, resp.StatusCode, response.Code, response.Msg)
+	}
 	return nil
 }
 
+// SendMentionMessage 发送消息到飞书并@指定open_id的用户，atAll为true时@所有人
+func (c *Client) SendMentionMessage(message string, userIDs []string, atAll bool) error {
+	return c.SendMentionMessageToChat(c.config.ChatID, message, userIDs, atAll)
+}
+
+// SendMentionMessageToChat 发送消息到指定飞书群聊并@指定open_id的用户，atAll为true时@所有人
+func (c *Client) SendMentionMessageToChat(chatID, message string, userIDs []string, atAll bool) error {
+	for _, userID := range userIDs {
+		message += fmt.Sprintf(` <at user_id="%s"></at>`, userID)
+	}
+	if atAll {
+		message += ` <at user_id="all"></at>`
+	}
+	return c.SendMessageToChat(chatID, message)
+}
+
 // HandleMessage 处理接收到的消息
 func (c *Client) HandleMessage(message string) (string, error) {
 	// 这里可以实现消息处理逻辑
@@ -180,14 +241,24 @@ func (c *Client) HandleMessage(message string) (string, error) {
 
 // SendImage 发送图片到飞书
 func (c *Client) SendImage(imageData []byte, message string) error {
+	if c.config.ChatID == "" {
+		return fmt.Errorf("feishu chat_id is not configured")
+	}
+
 	// 获取access token
 	token, err := c.getAccessToken()
 	if err != nil {
 		return err
 	}
 
-	// 构建请求URL
-	url := "https://open.feishu.cn/open-apis/im/v1/messages"
+	// 飞书消息中的图片只能引用已上传图片的image_key
+	imageKey, err := c.uploadImage(token, imageData)
+	if err != nil {
+		return err
+	}
+
+	// 构建请求URL，receive_id_type通过查询参数指定
+	url := c.apiBase + "/open-apis/im/v1/messages?receive_id_type=chat_id"
 
 	// 构建请求头
 	headers := map[string]string{
@@ -195,36 +266,25 @@ func (c *Client) SendImage(imageData []byte, message string) error {
 		"Content-Type":  "application/json",
 	}
 
-	// 构建请求体
-	requestBody := map[string]interface{}{
-		"receive_id_type": "chat_id",
-		"receive_id":      "oc_abcdefg",
-		"msg_type":       "post",
-		"content": map[string]interface{}{
-			"post": map[string]interface{}{
-				"zh_cn": map[string]interface{}{
-					"title":   "Kubernetes监控图表",
-					"content": [][]map[string]interface{}{
-						{
-							{
-								"tag":  "text",
-								"text": message,
-							},
-						},
-						{
-							{
-								"tag":      "img",
-								"img_key":  base64.StdEncoding.EncodeToString(imageData),
-								"alt": map[string]interface{}{
-									"tag":     "plain_text",
-									"content": "监控图表",
-								},
-							},
-						},
-					},
-				},
+	// 富文本消息引用上传图片得到的image_key，消息内容本身是JSON字符串
+	content, err := json.Marshal(map[string]interface{}{
+		"zh_cn": map[string]interface{}{
+			"title": "Kubernetes监控图表",
+			"content": [][]map[string]string{
+				{{"tag": "text", "text": message}},
+				{{"tag": "img", "image_key": imageKey}},
 			},
 		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message content: %v", err)
+	}
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"receive_id": c.config.ChatID,
+		"content":    string(content),
+		"msg_type":   "post",
 	}
 
 	// 编码请求体
@@ -252,12 +312,50 @@ func (c *Client) SendImage(imageData []byte, message string) error {
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send image, status code: %d", resp.StatusCode)
+	return checkResponse(resp, "send image")
+}
+
+// uploadImage 上传消息图片，返回image_key
+func (c *Client) uploadImage(token string, imageData []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("image_type", "message")
+	part, err := writer.CreateFormFile("image", "chart.png")
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+	part.Write(imageData)
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
 	}
 
-	return nil
+	req, err := http.NewRequest("POST", c.apiBase+"/open-apis/im/v1/images", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			ImageKey string `json:"image_key"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode upload response: %v", err)
+	}
+	if response.Code != 0 || response.Data.ImageKey == "" {
+		return "", fmt.Errorf("failed to upload image, code: %d, msg: %s", response.Code, response.Msg)
+	}
+	return response.Data.ImageKey, nil
 }
 
 // SendChart 发送图表到飞书
@@ -2,11 +2,14 @@ package metrics
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
 
 	"github.com/kudig-io/klaw/internal/kubernetes"
 )
@@ -23,12 +26,17 @@ func NewCollector(k8sManager *kubernetes.Manager) *Collector {
 
 // ClusterMetrics 集群指标
 type ClusterMetrics struct {
-	ClusterName    string
-	Timestamp     time.Time
-	Nodes         NodeMetricsSummary
-	Pods          PodMetricsSummary
-	Resources     ResourceMetrics
-	Events        []EventMetric
+	ClusterName string
+	Timestamp   time.Time
+	Nodes       NodeMetricsSummary
+	Pods        PodMetricsSummary
+	Resources   ResourceMetrics
+	Namespaces  []NamespaceMetrics
+	Volumes     []VolumeMetrics
+	// VolumeStatsFailedNodes 无法读取kubelet卷统计的节点，这些节点上的卷不在Volumes中
+	VolumeStatsFailedNodes []string `json:",omitempty"`
+	// Interval 汇总采样覆盖的时长（汇总窗口），原始采样为0
+	Interval time.Duration `json:",omitempty"`
 }
 
 // NodeMetricsSummary 节点指标摘要
@@ -42,51 +50,109 @@ type NodeMetricsSummary struct {
 
 // NodeDetail 节点详情
 type NodeDetail struct {
-	Name              string
-	CPUUsage          string
-	MemoryUsage       string
-	CPUUsagePercent   float64
+	Name               string
+	CPUUsage           string
+	MemoryUsage        string
+	CPUUsagePercent    float64
 	MemoryUsagePercent float64
-	Conditions        []corev1.NodeCondition
+	Conditions         []corev1.NodeCondition
 }
 
 // PodMetricsSummary Pod指标摘要
 type PodMetricsSummary struct {
-	Total      int
-	Running    int
-	Pending    int
-	Failed     int
-	Succeeded  int
-	Details    []PodDetail
+	Total     int
+	Running   int
+	Pending   int
+	Failed    int
+	Succeeded int
+	Restarts  int32
+	Details   []PodDetail
 }
 
 // PodDetail Pod详情
 type PodDetail struct {
 	Name         string
 	Namespace    string
+	NodeName     string
+	WorkloadKind string
+	WorkloadName string
 	Status       string
 	RestartCount int32
 	Age          time.Duration
 }
 
-// ResourceMetrics 资源指标
+// ResourceMetrics 资源指标，数值字段中CPU单位为毫核，内存单位为字节
 type ResourceMetrics struct {
-	TotalCPU     string
-	TotalMemory  string
-	UsedCPU      string
-	UsedMemory   string
-	AvailableCPU string
+	TotalCPU        string
+	TotalMemory     string
+	UsedCPU         string
+	UsedMemory      string
+	AvailableCPU    string
 	AvailableMemory string
+	CPUCapacity     int64
+	MemoryCapacity  int64
+	CPUUsage        int64
+	MemoryUsage     int64
 }
 
-// EventMetric 事件指标
-type EventMetric struct {
-	Type      string
-	Reason    string
-	Message   string
-	Count     int32
-	FirstSeen time.Time
-	LastSeen  time.Time
+// NamespaceMetrics 命名空间指标
+type NamespaceMetrics struct {
+	Name      string
+	Pods      PodCounts
+	Usage     UsageMetrics
+	Workloads []WorkloadMetrics
+}
+
+// WorkloadMetrics 工作负载指标，ReplicaSet创建的Pod归并到其Deployment
+type WorkloadMetrics struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Pods      PodCounts
+	Usage     UsageMetrics
+}
+
+// PodCounts Pod数量统计
+type PodCounts struct {
+	Total     int
+	Ready     int
+	Running   int
+	Pending   int
+	Failed    int
+	Succeeded int
+	Restarts  int32
+	// ReadyFractions 汇总采样中工作负载就绪副本数为i的时间比例，用于计算SLO；原始采样为空，以Ready为准
+	ReadyFractions []float64 `json:",omitempty"`
+}
+
+// UsageMetrics 资源使用量与申请量，CPU单位为毫核，内存单位为字节
+type UsageMetrics struct {
+	CPUUsage       int64
+	MemoryUsage    int64
+	CPURequests    int64
+	MemoryRequests int64
+	CPULimits      int64
+	MemoryLimits   int64
+}
+
+// Namespace 获取命名空间指标，不存在时返回nil
+func (m *ClusterMetrics) Namespace(name string) *NamespaceMetrics {
+	for i := range m.Namespaces {
+		if m.Namespaces[i].Name == name {
+			return &m.Namespaces[i]
+		}
+	}
+	return nil
+}
+
+// Workload 获取工作负载指标，不存在时返回nil
+func (n *NamespaceMetrics) Workload(kind, name string) *WorkloadMetrics {
+	for i := range n.Workloads {
+		if n.Workloads[i].Kind == kind && n.Workloads[i].Name == name {
+			return &n.Workloads[i]
+		}
+	}
+	return nil
 }
 
 // CollectClusterMetrics 收集集群指标
@@ -98,48 +164,44 @@ func (c *Collector) CollectClusterMetrics(clusterName string) (*ClusterMetrics,
 
 	metrics := &ClusterMetrics{
 		ClusterName: clusterName,
-		Timestamp:  time.Now(),
+		Timestamp:   time.Now(),
 	}
 
 	// 收集节点指标
-	nodeMetrics, err := c.collectNodeMetrics(client)
+	nodeUsage := c.collectNodeUsage(client)
+	nodeMetrics, err := c.collectNodeMetrics(client, nodeUsage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect node metrics: %v", err)
 	}
 	metrics.Nodes = *nodeMetrics
 
 	// 收集Pod指标
-	podMetrics, err := c.collectPodMetrics(client)
+	pods, err := client.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect pod metrics: %v", err)
 	}
-	metrics.Pods = *podMetrics
+	workloads := WorkloadOwners(context.Background(), client)
+	metrics.Pods = *c.collectPodMetrics(pods.Items, workloads)
+
+	// 按命名空间和工作负载汇总
+	metrics.Namespaces = c.collectNamespaceMetrics(pods.Items, workloads, c.collectPodUsage(client))
 
 	// 收集资源指标
-	resourceMetrics, err := c.collectResourceMetrics(client)
+	resourceMetrics, err := c.collectResourceMetrics(client, nodeUsage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect resource metrics: %v", err)
 	}
 	metrics.Resources = *resourceMetrics
 
-	// 收集事件指标
-	eventMetrics, err := c.collectEventMetrics(client)
-	if err != nil {
-		return nil, fmt.Errorf("failed to collect event metrics: %v", err)
-	}
-	metrics.Events = eventMetrics
+	// 收集PVC卷使用量，kubelet不可达的节点跳过并记录在VolumeStatsFailedNodes中
+	metrics.Volumes, metrics.VolumeStatsFailedNodes = c.collectVolumeMetrics(client, metrics.Nodes.Details)
 
 	return metrics, nil
 }
 
 // collectNodeMetrics 收集节点指标
-func (c *Collector) collectNodeMetrics(client interface{}) (*NodeMetricsSummary, error) {
-	k8sClient, ok := client.(interface{ CoreV1() interface{ Nodes() interface{} })
-	if !ok {
-		return nil, fmt.Errorf("invalid client type")
-	}
-
-	nodes, err := k8sClient.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+func (c *Collector) collectNodeMetrics(client k8sclient.Interface, usage map[string]corev1.ResourceList) (*NodeMetricsSummary, error) {
+	nodes, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -151,10 +213,20 @@ func (c *Collector) collectNodeMetrics(client interface{}) (*NodeMetricsSummary,
 
 	for _, node := range nodes.Items {
 		detail := NodeDetail{
-			Name:       node.Name,
-			CPUUsage:   node.Status.Capacity.Cpu().String(),
+			Name:        node.Name,
+			CPUUsage:    node.Status.Capacity.Cpu().String(),
 			MemoryUsage: node.Status.Capacity.Memory().String(),
-			Conditions: node.Status.Conditions,
+			Conditions:  node.Status.Conditions,
+		}
+
+		// 使用率以可分配资源为基准，与kubectl top node一致
+		if nodeUsage, ok := usage[node.Name]; ok {
+			if allocatable := node.Status.Allocatable.Cpu().MilliValue(); allocatable > 0 {
+				detail.CPUUsagePercent = float64(nodeUsage.Cpu().MilliValue()) / float64(allocatable) * 100
+			}
+			if allocatable := node.Status.Allocatable.Memory().Value(); allocatable > 0 {
+				detail.MemoryUsagePercent = float64(nodeUsage.Memory().Value()) / float64(allocatable) * 100
+			}
 		}
 
 		// 计算节点状态
@@ -179,35 +251,27 @@ func (c *Collector) collectNodeMetrics(client interface{}) (*NodeMetricsSummary,
 }
 
 // collectPodMetrics 收集Pod指标
-func (c *Collector) collectPodMetrics(client interface{}) (*PodMetricsSummary, error) {
-	k8sClient, ok := client.(interface{ CoreV1() interface{ Pods(string) interface{} })
-	if !ok {
-		return nil, fmt.Errorf("invalid client type")
-	}
-
-	pods, err := k8sClient.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Collector) collectPodMetrics(pods []corev1.Pod, workloads map[string]string) *PodMetricsSummary {
 	summary := &PodMetricsSummary{
-		Total:   len(pods.Items),
-		Details: make([]PodDetail, 0, len(pods.Items)),
+		Total:   len(pods),
+		Details: make([]PodDetail, 0, len(pods)),
 	}
 
-	for _, pod := range pods.Items {
-		restartCount := int32(0)
-		for _, containerStatus := range pod.Status.ContainerStatuses {
-			restartCount += containerStatus.RestartCount
-		}
+	for i := range pods {
+		pod := &pods[i]
+		kind, name := ResolveWorkload(pod, workloads)
 
 		detail := PodDetail{
 			Name:         pod.Name,
 			Namespace:    pod.Namespace,
+			NodeName:     pod.Spec.NodeName,
+			WorkloadKind: kind,
+			WorkloadName: name,
 			Status:       string(pod.Status.Phase),
-			RestartCount: restartCount,
+			RestartCount: podRestarts(pod),
 			Age:          time.Since(pod.CreationTimestamp.Time),
 		}
+		summary.Restarts += detail.RestartCount
 
 		switch pod.Status.Phase {
 		case corev1.PodRunning:
@@ -223,67 +287,264 @@ func (c *Collector) collectPodMetrics(client interface{}) (*PodMetricsSummary, e
 		summary.Details = append(summary.Details, detail)
 	}
 
-	return summary, nil
+	return summary
 }
 
-// collectResourceMetrics 收集资源指标
-func (c *Collector) collectResourceMetrics(client interface{}) (*ResourceMetrics, error) {
-	k8sClient, ok := client.(interface{ CoreV1() interface{ Nodes() interface{} })
-	if !ok {
-		return nil, fmt.Errorf("invalid client type")
+// collectNamespaceMetrics 按命名空间和所属工作负载汇总Pod数量、重启、使用量和申请量
+func (c *Collector) collectNamespaceMetrics(pods []corev1.Pod, workloads map[string]string, usage map[string]UsageMetrics) []NamespaceMetrics {
+	namespaces := make(map[string]*NamespaceMetrics)
+	byWorkload := make(map[string]*WorkloadMetrics)
+
+	for i := range pods {
+		pod := &pods[i]
+
+		ns, ok := namespaces[pod.Namespace]
+		if !ok {
+			ns = &NamespaceMetrics{Name: pod.Namespace}
+			namespaces[pod.Namespace] = ns
+		}
+
+		kind, name := ResolveWorkload(pod, workloads)
+		key := pod.Namespace + "/" + kind + "/" + name
+		workload, ok := byWorkload[key]
+		if !ok {
+			workload = &WorkloadMetrics{Kind: kind, Name: name, Namespace: pod.Namespace}
+			byWorkload[key] = workload
+		}
+
+		podUsage := usage[pod.Namespace+"/"+pod.Name]
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			addPodResources(&podUsage, pod)
+		}
+
+		ns.Pods.add(pod)
+		ns.Usage.add(podUsage)
+		workload.Pods.add(pod)
+		workload.Usage.add(podUsage)
 	}
 
-	nodes, err := k8sClient.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	for _, workload := range byWorkload {
+		ns := namespaces[workload.Namespace]
+		ns.Workloads = append(ns.Workloads, *workload)
+	}
+
+	result := make([]NamespaceMetrics, 0, len(namespaces))
+	for _, ns := range namespaces {
+		sort.Slice(ns.Workloads, func(i, j int) bool {
+			if ns.Workloads[i].Kind != ns.Workloads[j].Kind {
+				return ns.Workloads[i].Kind < ns.Workloads[j].Kind
+			}
+			return ns.Workloads[i].Name < ns.Workloads[j].Name
+		})
+		result = append(result, *ns)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+// WorkloadOwners 收集ReplicaSet到Deployment的归属关系，键为 namespace/replicaset
+func WorkloadOwners(ctx context.Context, client k8sclient.Interface) map[string]string {
+	owners := make(map[string]string)
+
+	// 没有ReplicaSet的读取权限时退化为按ReplicaSet汇总
+	replicaSets, err := client.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return owners
+	}
+
+	for _, rs := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&rs); owner != nil && owner.Kind == "Deployment" {
+			owners[rs.Namespace+"/"+rs.Name] = owner.Name
+		}
+	}
+
+	return owners
+}
+
+// podMetricsList metrics.k8s.io/v1beta1 PodMetricsList 中用到的字段
+type podMetricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Containers []struct {
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// collectPodUsage 从metrics-server读取Pod实际使用量，键为 namespace/pod
+func (c *Collector) collectPodUsage(client k8sclient.Interface) map[string]UsageMetrics {
+	usage := make(map[string]UsageMetrics)
+
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return usage
+	}
+
+	// 集群未安装metrics-server时使用量保持为0
+	data, err := restClient.Get().AbsPath("/apis/metrics.k8s.io/v1beta1/pods").DoRaw(context.Background())
+	if err != nil {
+		return usage
+	}
+
+	var list podMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return usage
+	}
+
+	for _, item := range list.Items {
+		var podUsage UsageMetrics
+		for _, container := range item.Containers {
+			podUsage.CPUUsage += container.Usage.Cpu().MilliValue()
+			podUsage.MemoryUsage += container.Usage.Memory().Value()
+		}
+		usage[item.Metadata.Namespace+"/"+item.Metadata.Name] = podUsage
+	}
+
+	return usage
+}
+
+// nodeMetricsList metrics.k8s.io/v1beta1 NodeMetricsList 中用到的字段
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta   `json:"metadata"`
+		Usage    corev1.ResourceList `json:"usage"`
+	} `json:"items"`
+}
+
+// collectNodeUsage 从metrics-server读取节点实际使用量，键为节点名
+func (c *Collector) collectNodeUsage(client k8sclient.Interface) map[string]corev1.ResourceList {
+	usage := make(map[string]corev1.ResourceList)
+
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return usage
+	}
+
+	// 集群未安装metrics-server时使用量保持为0
+	data, err := restClient.Get().AbsPath("/apis/metrics.k8s.io/v1beta1/nodes").DoRaw(context.Background())
+	if err != nil {
+		return usage
+	}
+
+	var list nodeMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return usage
+	}
+
+	for _, item := range list.Items {
+		usage[item.Metadata.Name] = item.Usage
+	}
+
+	return usage
+}
+
+// collectResourceMetrics 收集资源指标
+func (c *Collector) collectResourceMetrics(client k8sclient.Interface, usage map[string]corev1.ResourceList) (*ResourceMetrics, error) {
+	nodes, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	var totalCPU, totalMemory int64
+	var totalCPU, totalMemory, usedCPU, usedMemory int64
 	for _, node := range nodes.Items {
 		totalCPU += node.Status.Capacity.Cpu().MilliValue()
 		totalMemory += node.Status.Capacity.Memory().Value()
+		if nodeUsage, ok := usage[node.Name]; ok {
+			usedCPU += nodeUsage.Cpu().MilliValue()
+			usedMemory += nodeUsage.Memory().Value()
+		}
 	}
 
 	metrics := &ResourceMetrics{
 		TotalCPU:        formatCPU(totalCPU),
 		TotalMemory:     formatMemory(totalMemory),
-		UsedCPU:         formatCPU(totalCPU / 2),
-		UsedMemory:      formatMemory(totalMemory / 2),
-		AvailableCPU:    formatCPU(totalCPU / 2),
-		AvailableMemory: formatMemory(totalMemory / 2),
+		UsedCPU:         formatCPU(usedCPU),
+		UsedMemory:      formatMemory(usedMemory),
+		AvailableCPU:    formatCPU(totalCPU - usedCPU),
+		AvailableMemory: formatMemory(totalMemory - usedMemory),
+		CPUCapacity:     totalCPU,
+		MemoryCapacity:  totalMemory,
+		CPUUsage:        usedCPU,
+		MemoryUsage:     usedMemory,
 	}
 
 	return metrics, nil
 }
 
-// collectEventMetrics 收集事件指标
-func (c *Collector) collectEventMetrics(client interface{}) ([]EventMetric, error) {
-	k8sClient, ok := client.(interface{ CoreV1() interface{ Events(string) interface{} })
-	if !ok {
-		return nil, fmt.Errorf("invalid client type")
+// ResolveWorkload 解析Pod所属工作负载，没有控制器的Pod按自身汇总
+func ResolveWorkload(pod *corev1.Pod, owners map[string]string) (string, string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
 	}
 
-	events, err := k8sClient.CoreV1().Events("").List(context.Background(), metav1.ListOptions{
-		Limit: 50,
-	})
-	if err != nil {
-		return nil, err
+	if owner.Kind == "ReplicaSet" {
+		if deployment, ok := owners[pod.Namespace+"/"+owner.Name]; ok {
+			return "Deployment", deployment
+		}
+	}
+
+	return owner.Kind, owner.Name
+}
+
+// podRestarts 统计Pod中所有容器的重启次数
+func podRestarts(pod *corev1.Pod) int32 {
+	restartCount := int32(0)
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		restartCount += containerStatus.RestartCount
 	}
+	return restartCount
+}
 
-	metrics := make([]EventMetric, 0, len(events.Items))
-	for _, event := range events.Items {
-		metric := EventMetric{
-			Type:      string(event.Type),
-			Reason:    event.Reason,
-			Message:   event.Message,
-			Count:     event.Count,
-			FirstSeen: event.FirstTimestamp.Time,
-			LastSeen:  event.LastTimestamp.Time,
+// podReady 判断Pod是否处于Ready状态
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
 		}
-		metrics = append(metrics, metric)
 	}
+	return false
+}
 
-	return metrics, nil
+// addPodResources 累加Pod中容器的资源申请量和限制
+func addPodResources(usage *UsageMetrics, pod *corev1.Pod) {
+	for _, container := range pod.Spec.Containers {
+		usage.CPURequests += container.Resources.Requests.Cpu().MilliValue()
+		usage.MemoryRequests += container.Resources.Requests.Memory().Value()
+		usage.CPULimits += container.Resources.Limits.Cpu().MilliValue()
+		usage.MemoryLimits += container.Resources.Limits.Memory().Value()
+	}
+}
+
+// add 累加一个Pod的状态
+func (p *PodCounts) add(pod *corev1.Pod) {
+	p.Total++
+	p.Restarts += podRestarts(pod)
+	if podReady(pod) {
+		p.Ready++
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		p.Running++
+	case corev1.PodPending:
+		p.Pending++
+	case corev1.PodFailed:
+		p.Failed++
+	case corev1.PodSucceeded:
+		p.Succeeded++
+	}
+}
+
+// add 累加资源使用量
+func (u *UsageMetrics) add(other UsageMetrics) {
+	u.CPUUsage += other.CPUUsage
+	u.MemoryUsage += other.MemoryUsage
+	u.CPURequests += other.CPURequests
+	u.MemoryRequests += other.MemoryRequests
+	u.CPULimits += other.CPULimits
+	u.MemoryLimits += other.MemoryLimits
 }
 
 // formatCPU 格式化CPU
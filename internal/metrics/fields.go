@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// clusterFields 集群级数值字段，CPU单位为毫核，内存单位为字节，使用率为百分比
+var clusterFields = map[string]func(m *ClusterMetrics) float64{
+	"nodes.total":     func(m *ClusterMetrics) float64 { return float64(m.Nodes.Total) },
+	"nodes.ready":     func(m *ClusterMetrics) float64 { return float64(m.Nodes.Ready) },
+	"nodes.not_ready": func(m *ClusterMetrics) float64 { return float64(m.Nodes.NotReady) },
+	"pods.total":      func(m *ClusterMetrics) float64 { return float64(m.Pods.Total) },
+	"pods.running":    func(m *ClusterMetrics) float64 { return float64(m.Pods.Running) },
+	"pods.pending":    func(m *ClusterMetrics) float64 { return float64(m.Pods.Pending) },
+	"pods.failed":     func(m *ClusterMetrics) float64 { return float64(m.Pods.Failed) },
+	"pods.succeeded":  func(m *ClusterMetrics) float64 { return float64(m.Pods.Succeeded) },
+	"pods.restarts":   func(m *ClusterMetrics) float64 { return float64(m.Pods.Restarts) },
+	"cpu.capacity":    func(m *ClusterMetrics) float64 { return float64(m.Resources.CPUCapacity) },
+	"cpu.usage":       func(m *ClusterMetrics) float64 { return float64(m.Resources.CPUUsage) },
+	"cpu.usage_percent": func(m *ClusterMetrics) float64 {
+		return percent(m.Resources.CPUUsage, m.Resources.CPUCapacity)
+	},
+	"memory.capacity": func(m *ClusterMetrics) float64 { return float64(m.Resources.MemoryCapacity) },
+	"memory.usage":    func(m *ClusterMetrics) float64 { return float64(m.Resources.MemoryUsage) },
+	"memory.usage_percent": func(m *ClusterMetrics) float64 {
+		return percent(m.Resources.MemoryUsage, m.Resources.MemoryCapacity)
+	},
+}
+
+// groupFields 命名空间和工作负载共用的数值字段
+var groupFields = map[string]func(pods PodCounts, usage UsageMetrics) float64{
+	"pods.total":      func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Total) },
+	"pods.ready":      func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Ready) },
+	"pods.not_ready":  func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Total - p.Ready - p.Succeeded) },
+	"pods.running":    func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Running) },
+	"pods.pending":    func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Pending) },
+	"pods.failed":     func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Failed) },
+	"pods.succeeded":  func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Succeeded) },
+	"pods.restarts":   func(p PodCounts, _ UsageMetrics) float64 { return float64(p.Restarts) },
+	"cpu.usage":       func(_ PodCounts, u UsageMetrics) float64 { return float64(u.CPUUsage) },
+	"cpu.requests":    func(_ PodCounts, u UsageMetrics) float64 { return float64(u.CPURequests) },
+	"cpu.limits":      func(_ PodCounts, u UsageMetrics) float64 { return float64(u.CPULimits) },
+	"memory.usage":    func(_ PodCounts, u UsageMetrics) float64 { return float64(u.MemoryUsage) },
+	"memory.requests": func(_ PodCounts, u UsageMetrics) float64 { return float64(u.MemoryRequests) },
+	"memory.limits":   func(_ PodCounts, u UsageMetrics) float64 { return float64(u.MemoryLimits) },
+}
+
+// nodeFields 节点级数值字段
+var nodeFields = map[string]func(n *NodeDetail) float64{
+	"cpu.usage_percent":    func(n *NodeDetail) float64 { return n.CPUUsagePercent },
+	"memory.usage_percent": func(n *NodeDetail) float64 { return n.MemoryUsagePercent },
+	"ready": func(n *NodeDetail) float64 {
+		for _, condition := range n.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+				return 1
+			}
+		}
+		return 0
+	},
+}
+
+// FieldNames 返回所有可查询的集群级字段名
+func FieldNames() []string {
+	return sortedKeys(clusterFields)
+}
+
+// NamespaceFieldNames 返回命名空间和工作负载可用的字段名
+func NamespaceFieldNames() []string {
+	return sortedKeys(groupFields)
+}
+
+// NodeFieldNames 返回节点可用的字段名
+func NodeFieldNames() []string {
+	return sortedKeys(nodeFields)
+}
+
+// Field 返回集群指标中指定字段的值
+func (m *ClusterMetrics) Field(name string) (float64, bool) {
+	accessor, ok := clusterFields[name]
+	if !ok {
+		return 0, false
+	}
+	return accessor(m), true
+}
+
+// Field 返回命名空间指标中指定字段的值
+func (ns *NamespaceMetrics) Field(name string) (float64, bool) {
+	accessor, ok := groupFields[name]
+	if !ok {
+		return 0, false
+	}
+	return accessor(ns.Pods, ns.Usage), true
+}
+
+// Field 返回工作负载指标中指定字段的值
+func (w *WorkloadMetrics) Field(name string) (float64, bool) {
+	accessor, ok := groupFields[name]
+	if !ok {
+		return 0, false
+	}
+	return accessor(w.Pods, w.Usage), true
+}
+
+// Field 返回节点指标中指定字段的值
+func (n *NodeDetail) Field(name string) (float64, bool) {
+	accessor, ok := nodeFields[name]
+	if !ok {
+		return 0, false
+	}
+	return accessor(n), true
+}
+
+// sortedKeys 返回排序后的字段名
+func sortedKeys[T any](fields map[string]T) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// percent 计算百分比，分母为0时返回0
+func percent(used, capacity int64) float64 {
+	if capacity == 0 {
+		return 0
+	}
+	return float64(used) / float64(capacity) * 100
+}
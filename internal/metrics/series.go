@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeSeries 紧凑格式的时间序列，Timestamps为毫秒时间戳，
+// Series中每个字段的值数组与Timestamps一一对应，没有采样的时间点为null
+type TimeSeries struct {
+	Cluster    string                `json:"cluster"`
+	From       time.Time             `json:"from"`
+	To         time.Time             `json:"to"`
+	Step       int64                 `json:"step"`
+	Timestamps []int64               `json:"timestamps"`
+	Series     map[string][]*float64 `json:"series"`
+}
+
+// Downsample 将指标历史按step对齐分桶，桶内取平均值，并只保留fields中的字段
+func Downsample(clusterName string, history []*ClusterMetrics, fields []string, from, to time.Time, step time.Duration) (*TimeSeries, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	for _, field := range fields {
+		if _, ok := clusterFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+	}
+
+	start := from.Truncate(step)
+	buckets := int(to.Sub(start)/step) + 1
+
+	series := &TimeSeries{
+		Cluster:    clusterName,
+		From:       from,
+		To:         to,
+		Step:       int64(step / time.Second),
+		Timestamps: make([]int64, buckets),
+		Series:     make(map[string][]*float64, len(fields)),
+	}
+	for i := range series.Timestamps {
+		series.Timestamps[i] = start.Add(time.Duration(i) * step).UnixMilli()
+	}
+
+	sums := make(map[string][]float64, len(fields))
+	counts := make([]int, buckets)
+	for _, field := range fields {
+		sums[field] = make([]float64, buckets)
+	}
+
+	for _, m := range history {
+		if m.Timestamp.Before(from) || m.Timestamp.After(to) {
+			continue
+		}
+		i := int(m.Timestamp.Sub(start) / step)
+		counts[i]++
+		for _, field := range fields {
+			sums[field][i] += clusterFields[field](m)
+		}
+	}
+
+	for _, field := range fields {
+		values := make([]*float64, buckets)
+		for i, count := range counts {
+			if count > 0 {
+				v := sums[field][i] / float64(count)
+				values[i] = &v
+			}
+		}
+		series.Series[field] = values
+	}
+
+	return series, nil
+}
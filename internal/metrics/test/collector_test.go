@@ -0,0 +1,101 @@
+package metrics_test
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+}
+
+func testPod(namespace, name string, owners []metav1.OwnerReference, phase corev1.PodPhase, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: owners},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+		},
+	}
+}
+
+func TestCollectClusterMetrics_NamespaceBreakdown(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-7d9f", Namespace: "team-a", OwnerReferences: controllerRef("Deployment", "web"),
+		}},
+		testPod("team-a", "web-7d9f-abc", controllerRef("ReplicaSet", "web-7d9f"), corev1.PodRunning, 1),
+		testPod("team-a", "web-7d9f-def", controllerRef("ReplicaSet", "web-7d9f"), corev1.PodRunning, 2),
+		testPod("team-a", "db-0", controllerRef("StatefulSet", "db"), corev1.PodFailed, 5),
+		testPod("team-b", "debug", nil, corev1.PodPending, 0),
+	)
+
+	manager := kubernetes.NewManagerWithClients(
+		[]config.ClusterConfig{{Name: "test"}},
+		map[string]k8sclient.Interface{"test": client},
+	)
+	clusterMetrics, err := metrics.NewCollector(manager).CollectClusterMetrics("test")
+	if err != nil {
+		t.Fatalf("CollectClusterMetrics() error = %v", err)
+	}
+
+	if len(clusterMetrics.Namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %d", len(clusterMetrics.Namespaces))
+	}
+
+	teamA := clusterMetrics.Namespace("team-a")
+	if teamA == nil {
+		t.Fatal("namespace team-a not found")
+	}
+	if teamA.Pods.Total != 3 || teamA.Pods.Running != 2 || teamA.Pods.Failed != 1 {
+		t.Errorf("unexpected team-a pod counts: %+v", teamA.Pods)
+	}
+	if teamA.Pods.Restarts != 8 {
+		t.Errorf("expected 8 restarts in team-a, got %d", teamA.Pods.Restarts)
+	}
+
+	// 已失败的Pod不再计入资源申请量
+	if teamA.Usage.CPURequests != 200 {
+		t.Errorf("expected 200m CPU requests in team-a, got %dm", teamA.Usage.CPURequests)
+	}
+
+	web := teamA.Workload("Deployment", "web")
+	if web == nil {
+		t.Fatalf("deployment web not found, workloads: %+v", teamA.Workloads)
+	}
+	if web.Pods.Total != 2 || web.Pods.Restarts != 3 {
+		t.Errorf("unexpected deployment web counts: %+v", web.Pods)
+	}
+
+	if teamA.Workload("StatefulSet", "db") == nil {
+		t.Error("statefulset db not found")
+	}
+
+	teamB := clusterMetrics.Namespace("team-b")
+	if teamB == nil || teamB.Workload("Pod", "debug") == nil {
+		t.Error("bare pod debug should be reported as its own workload")
+	}
+}
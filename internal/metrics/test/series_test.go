@@ -0,0 +1,53 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+func TestDownsample(t *testing.T) {
+	from := time.Unix(1699999980, 0)
+	sample := func(offset time.Duration, running int, cpuUsage int64) *metrics.ClusterMetrics {
+		return &metrics.ClusterMetrics{
+			ClusterName: "prod",
+			Timestamp:   from.Add(offset),
+			Pods:        metrics.PodMetricsSummary{Running: running},
+			Resources:   metrics.ResourceMetrics{CPUCapacity: 4000, CPUUsage: cpuUsage},
+		}
+	}
+	history := []*metrics.ClusterMetrics{
+		sample(10*time.Second, 4, 1000),
+		sample(40*time.Second, 6, 3000),
+		// 第二个桶没有采样，第三个桶一个采样
+		sample(130*time.Second, 9, 2000),
+	}
+
+	series, err := metrics.Downsample("prod", history, []string{"pods.running", "cpu.usage_percent"}, from, from.Add(2*time.Minute+30*time.Second), time.Minute)
+	if err != nil {
+		t.Fatalf("Downsample() error = %v", err)
+	}
+
+	if len(series.Timestamps) != 3 || series.Timestamps[1] != from.Add(time.Minute).UnixMilli() {
+		t.Fatalf("unexpected timestamps: %v", series.Timestamps)
+	}
+	if len(series.Series) != 2 {
+		t.Fatalf("expected only requested fields, got %d", len(series.Series))
+	}
+
+	running := series.Series["pods.running"]
+	if running[0] == nil || *running[0] != 5 {
+		t.Errorf("expected averaged value 5 in first bucket, got %v", running[0])
+	}
+	if running[1] != nil {
+		t.Errorf("expected gap in second bucket, got %v", *running[1])
+	}
+	if cpu := series.Series["cpu.usage_percent"][2]; cpu == nil || *cpu != 50 {
+		t.Errorf("expected 50%% CPU in third bucket, got %v", cpu)
+	}
+
+	if _, err := metrics.Downsample("prod", history, []string{"unknown"}, from, from.Add(time.Minute), time.Minute); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
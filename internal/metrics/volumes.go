@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	k8sclient "k8s.io/client-go/kubernetes"
+)
+
+// 读取kubelet卷统计的参数
+const (
+	// volumeStatsTimeout 每个节点/stats/summary请求的超时时间，避免某个kubelet无响应时阻塞整个指标采集
+	volumeStatsTimeout = 10 * time.Second
+	// volumeStatsConcurrency 同时请求的节点数
+	volumeStatsConcurrency = 8
+)
+
+// VolumeMetrics PVC卷的使用量，来自挂载该卷的节点上kubelet的统计，单位为字节
+type VolumeMetrics struct {
+	Namespace      string
+	PVC            string
+	Node           string
+	CapacityBytes  int64
+	UsedBytes      int64
+	AvailableBytes int64
+	Inodes         int64
+	InodesUsed     int64
+}
+
+// UsedPercent 卷空间使用率
+func (v *VolumeMetrics) UsedPercent() float64 {
+	return percent(v.UsedBytes, v.CapacityBytes)
+}
+
+// InodesUsedPercent 卷inode使用率
+func (v *VolumeMetrics) InodesUsedPercent() float64 {
+	return percent(v.InodesUsed, v.Inodes)
+}
+
+// Volume 获取PVC卷指标，不存在时返回nil
+func (m *ClusterMetrics) Volume(namespace, pvc string) *VolumeMetrics {
+	for i := range m.Volumes {
+		if m.Volumes[i].Namespace == namespace && m.Volumes[i].PVC == pvc {
+			return &m.Volumes[i]
+		}
+	}
+	return nil
+}
+
+// statsSummary kubelet /stats/summary 中用到的字段
+type statsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			PVCRef *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+			CapacityBytes  *int64 `json:"capacityBytes"`
+			UsedBytes      *int64 `json:"usedBytes"`
+			AvailableBytes *int64 `json:"availableBytes"`
+			Inodes         *int64 `json:"inodes"`
+			InodesUsed     *int64 `json:"inodesUsed"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// collectVolumeMetrics 通过API Server的节点代理并发读取各节点kubelet的/stats/summary，汇总PVC卷使用量。
+// 每个节点的请求有超时时间，同一PVC被多个Pod挂载时只记录一次。同时返回按名称排序的读取失败的节点
+func (c *Collector) collectVolumeMetrics(client k8sclient.Interface, nodes []NodeDetail) ([]VolumeMetrics, []string) {
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, nil
+	}
+
+	results := make([][]VolumeMetrics, len(nodes))
+	failed := make([]bool, len(nodes))
+	semaphore := make(chan struct{}, volumeStatsConcurrency)
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, nodeName string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), volumeStatsTimeout)
+			defer cancel()
+			data, err := restClient.Get().AbsPath("/api/v1/nodes", nodeName, "proxy", "stats", "summary").DoRaw(ctx)
+			if err != nil {
+				fmt.Printf("Failed to get volume stats from node %s: %v\n", nodeName, err)
+				failed[i] = true
+				return
+			}
+
+			nodeVolumes, err := parseVolumeStats(nodeName, data)
+			if err != nil {
+				fmt.Printf("Failed to parse volume stats from node %s: %v\n", nodeName, err)
+				failed[i] = true
+				return
+			}
+			results[i] = nodeVolumes
+		}(i, node.Name)
+	}
+	wg.Wait()
+
+	var failedNodes []string
+	for i, node := range nodes {
+		if failed[i] {
+			failedNodes = append(failedNodes, node.Name)
+		}
+	}
+	sort.Strings(failedNodes)
+
+	seen := make(map[string]bool)
+	var volumes []VolumeMetrics
+	for _, nodeVolumes := range results {
+		for _, volume := range nodeVolumes {
+			key := volume.Namespace + "/" + volume.PVC
+			if !seen[key] {
+				seen[key] = true
+				volumes = append(volumes, volume)
+			}
+		}
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Namespace != volumes[j].Namespace {
+			return volumes[i].Namespace < volumes[j].Namespace
+		}
+		return volumes[i].PVC < volumes[j].PVC
+	})
+	return volumes, failedNodes
+}
+
+// parseVolumeStats 解析kubelet统计中引用了PVC且有容量数据的卷
+func parseVolumeStats(nodeName string, data []byte) ([]VolumeMetrics, error) {
+	var summary statsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	var volumes []VolumeMetrics
+	for _, pod := range summary.Pods {
+		for _, v := range pod.Volumes {
+			if v.PVCRef == nil || v.CapacityBytes == nil || v.UsedBytes == nil {
+				continue
+			}
+			volumes = append(volumes, VolumeMetrics{
+				Namespace:      v.PVCRef.Namespace,
+				PVC:            v.PVCRef.Name,
+				Node:           nodeName,
+				CapacityBytes:  *v.CapacityBytes,
+				UsedBytes:      *v.UsedBytes,
+				AvailableBytes: valueOrZero(v.AvailableBytes),
+				Inodes:         valueOrZero(v.Inodes),
+				InodesUsed:     valueOrZero(v.InodesUsed),
+			})
+		}
+	}
+	return volumes, nil
+}
+
+// valueOrZero 返回指针指向的值，为空时返回0
+func valueOrZero(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
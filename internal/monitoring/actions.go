@@ -0,0 +1,73 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetAlert 获取指定告警的副本，包含完整时间线
+func (s *Service) GetAlert(alertID string) (*Alert, error) {
+	s.alertsMutex.RLock()
+	defer s.alertsMutex.RUnlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return nil, fmt.Errorf("alert not found: %s", alertID)
+	}
+	return alert.clone(), nil
+}
+
+// AckAlert 确认告警，确认后不再重复通知和升级，直到告警解决后再次触发
+func (s *Service) AckAlert(alertID, actor string) error {
+	return s.updateAlert(alertID, func(alert *Alert, now time.Time) error {
+		if alert.Status == AlertStatusResolved {
+			return fmt.Errorf("alert %s is already resolved", alertID)
+		}
+		if alert.Acknowledged() {
+			return nil
+		}
+		alert.AckedBy = actor
+		alert.AckedAt = now
+		alert.record(now, TimelineAcknowledged, actor, "")
+		return nil
+	})
+}
+
+// AssignAlert 将告警指派给处理人
+func (s *Service) AssignAlert(alertID, actor, assignee string) error {
+	if assignee == "" {
+		return fmt.Errorf("assignee is required")
+	}
+	return s.updateAlert(alertID, func(alert *Alert, now time.Time) error {
+		alert.Assignee = assignee
+		alert.record(now, TimelineAssigned, actor, assignee)
+		return nil
+	})
+}
+
+// CommentAlert 在告警时间线上添加备注
+func (s *Service) CommentAlert(alertID, actor, comment string) error {
+	if comment == "" {
+		return fmt.Errorf("comment is required")
+	}
+	return s.updateAlert(alertID, func(alert *Alert, now time.Time) error {
+		alert.record(now, TimelineCommented, actor, comment)
+		return nil
+	})
+}
+
+// updateAlert 在alertsMutex保护下修改告警并持久化
+func (s *Service) updateAlert(alertID string, update func(alert *Alert, now time.Time) error) error {
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return fmt.Errorf("alert not found: %s", alertID)
+	}
+	if err := update(alert, time.Now()); err != nil {
+		return err
+	}
+	s.persistAlert(alert)
+	return nil
+}
@@ -0,0 +1,130 @@
+package monitoring
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 告警状态
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// 告警时间线事件
+const (
+	TimelineFired        = "fired"
+	TimelineNotified     = "notified"
+	TimelineSilenced     = "silenced"
+	TimelineAcknowledged = "acknowledged"
+	TimelineAssigned     = "assigned"
+	TimelineCommented    = "commented"
+	TimelineEscalated    = "escalated"
+	TimelineResolved     = "resolved"
+)
+
+// maxTimelineEntries 单个告警保留的时间线事件数上限，超出时丢弃最早的事件
+const maxTimelineEntries = 200
+
+// TimelineEntry 告警时间线上的一次状态变化
+type TimelineEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Actor  string    `json:"actor"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Alert 告警信息，ID为由集群、规则和标签计算出的稳定指纹
+type Alert struct {
+	ID        string            `json:"id"`
+	Cluster   string            `json:"cluster"`
+	Namespace string            `json:"namespace,omitempty"`
+	Type      string            `json:"type"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// Annotations 告警的附加信息，如退出码、最后日志行，不参与指纹计算
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Source      string            `json:"source"`
+	// SilencedBy 抑制该告警通知的静默规则或维护窗口
+	SilencedBy     string    `json:"silenced_by,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastNotifiedAt time.Time `json:"last_notified_at"`
+	ResolvedAt     time.Time `json:"resolved_at,omitempty"`
+	Resolved       bool      `json:"resolved"`
+	AckedBy        string    `json:"acked_by,omitempty"`
+	AckedAt        time.Time `json:"acked_at,omitempty"`
+	Assignee       string    `json:"assignee,omitempty"`
+	// EscalationLevel 已执行的升级步骤数
+	EscalationLevel int             `json:"escalation_level,omitempty"`
+	Timeline        []TimelineEntry `json:"timeline,omitempty"`
+}
+
+// Acknowledged 判断告警是否已被确认
+func (a *Alert) Acknowledged() bool {
+	return !a.AckedAt.IsZero()
+}
+
+// record 在告警时间线上追加一条事件
+func (a *Alert) record(now time.Time, action, actor, detail string) {
+	a.Timeline = append(a.Timeline, TimelineEntry{Time: now, Action: action, Actor: actor, Detail: detail})
+	if len(a.Timeline) > maxTimelineEntries {
+		a.Timeline = a.Timeline[len(a.Timeline)-maxTimelineEntries:]
+	}
+}
+
+// clone 复制告警，时间线不与原告警共享
+func (a *Alert) clone() *Alert {
+	copied := *a
+	copied.Timeline = append([]TimelineEntry(nil), a.Timeline...)
+	return &copied
+}
+
+// Fingerprint 计算告警指纹，相同集群、规则和标签的告警指纹相同
+func Fingerprint(cluster, rule string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(cluster)
+	b.WriteByte(0)
+	b.WriteString(rule)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// resolve 将告警标记为已解决
+func (a *Alert) resolve(now time.Time) {
+	a.Status = AlertStatusResolved
+	a.Resolved = true
+	a.ResolvedAt = now
+}
+
+// formatLabels 将标签格式化为 k=v 形式，按键排序
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,145 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceAlertmanager Alertmanager推送的告警来源
+const SourceAlertmanager = "alertmanager"
+
+// defaultExternalCluster 外部告警既没有cluster标签也没有指定集群时使用的集群名
+const defaultExternalCluster = "external"
+
+// AlertmanagerWebhook Alertmanager webhook推送的消息体（version 4）
+type AlertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert Alertmanager webhook中的单条告警
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// ExternalAlert 外部系统推送的告警
+type ExternalAlert struct {
+	Cluster string
+	Finding
+	StartsAt time.Time
+	Resolved bool
+}
+
+// ExternalAlerts 将webhook中的告警转换为外部告警。集群取cluster标签，没有时使用defaultCluster；
+// 告警名取alertname标签，级别取severity标签，消息取summary和description注解
+func (w *AlertmanagerWebhook) ExternalAlerts(defaultCluster string) ([]ExternalAlert, error) {
+	if w.Version != "4" {
+		return nil, fmt.Errorf("unsupported alertmanager webhook version: %q", w.Version)
+	}
+	if defaultCluster == "" {
+		defaultCluster = defaultExternalCluster
+	}
+
+	alerts := make([]ExternalAlert, 0, len(w.Alerts))
+	for _, a := range w.Alerts {
+		name := a.Labels["alertname"]
+		if name == "" {
+			return nil, fmt.Errorf("alert %s has no alertname label", a.Fingerprint)
+		}
+
+		cluster := a.Labels["cluster"]
+		if cluster == "" {
+			cluster = defaultCluster
+		}
+		level := a.Labels["severity"]
+		if level == "" {
+			level = "warning"
+		}
+
+		labels := make(map[string]string, len(a.Labels))
+		for k, v := range a.Labels {
+			switch k {
+			case "alertname", "cluster", "severity":
+			default:
+				labels[k] = v
+			}
+		}
+
+		alerts = append(alerts, ExternalAlert{
+			Cluster: cluster,
+			Finding: Finding{
+				Type:        name,
+				Namespace:   a.Labels["namespace"],
+				Level:       level,
+				Message:     alertmanagerMessage(name, a.Annotations),
+				Labels:      labels,
+				Annotations: a.Annotations,
+			},
+			StartsAt: a.StartsAt,
+			Resolved: a.Status == AlertStatusResolved,
+		})
+	}
+	return alerts, nil
+}
+
+// alertmanagerMessage 由summary和description注解生成告警消息
+func alertmanagerMessage(name string, annotations map[string]string) string {
+	summary, description := annotations["summary"], annotations["description"]
+	switch {
+	case summary != "" && description != "":
+		return summary + " - " + description
+	case summary != "":
+		return summary
+	case description != "":
+		return description
+	}
+	return name
+}
+
+// IngestAlerts 将外部告警合并到告警状态中，与内置检测产生的告警共用去重、静默、路由和通知流程。
+// 外部系统负责发送解决状态，因此本次未出现的告警不会自动解决
+func (s *Service) IngestAlerts(source string, alerts []ExternalAlert) {
+	now := time.Now()
+	var notifications []notification
+
+	s.alertsMutex.Lock()
+	for _, external := range alerts {
+		if external.Resolved {
+			alert, ok := s.alerts[Fingerprint(external.Cluster, external.Type, external.Labels)]
+			if !ok || alert.Status != AlertStatusFiring {
+				continue
+			}
+			if message := s.resolveAlert(alert, now, source, "resolved by "+source); message != "" {
+				notifications = append(notifications, notification{alert: alert.clone(), message: message})
+			}
+			continue
+		}
+
+		alert := s.fireAlert(external.Cluster, source, external.Finding, now)
+		if alert.CreatedAt.Equal(now) && !external.StartsAt.IsZero() && external.StartsAt.Before(now) {
+			alert.CreatedAt = external.StartsAt
+		}
+		if message := s.alertNotification(alert, now); message != "" {
+			notifications = append(notifications, notification{alert: alert.clone(), message: message})
+		}
+		s.persistAlert(alert)
+	}
+	s.alertsMutex.Unlock()
+
+	s.dispatch(notifications, now)
+	s.flushNotifications(now)
+}
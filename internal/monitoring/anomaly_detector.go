@@ -0,0 +1,384 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+// 异常检测的基线计算方式
+const (
+	anomalyMethodRolling  = "rolling"
+	anomalyMethodSeasonal = "seasonal"
+)
+
+// 异常检测的默认参数
+const (
+	defaultAnomalyStep        = 5 * time.Minute
+	defaultAnomalyWindow      = 24 * time.Hour
+	defaultAnomalyWeeks       = 4
+	defaultAnomalySensitivity = 3
+	defaultRollingMinSamples  = 12
+	defaultSeasonalMinSamples = 2
+	// seasonalSlot seasonal基线中每周同一时刻的统计时长
+	seasonalSlot = time.Hour
+	// minRelativeStddev 标准差下限相对均值的比例，避免平稳序列的微小波动被判为异常
+	minRelativeStddev = 0.1
+	week              = 7 * 24 * time.Hour
+)
+
+// 由指标采样或事件归档计算的派生序列，值为每个统计时长内的次数
+const (
+	seriesRestartRate      = "pods.restart_rate"
+	seriesWarningEventRate = "events.warning_rate"
+)
+
+// defaultAnomalySeries 未配置序列时检测的序列
+var defaultAnomalySeries = []config.AnomalySeriesConfig{
+	{Field: "pods.total", MinDelta: 5},
+	{Field: seriesRestartRate, MinDelta: 3},
+	{Field: "cpu.usage_percent", MinDelta: 5},
+	{Field: "memory.usage_percent", MinDelta: 5},
+	{Field: seriesWarningEventRate, MinDelta: 10},
+}
+
+// EventHistory 查询集群在时间范围内归档的Warning事件
+type EventHistory func(clusterName string, from, to time.Time) ([]storage.EventRecord, error)
+
+// anomalySeries 单个序列的检测参数
+type anomalySeries struct {
+	field       string
+	method      string
+	sensitivity float64
+	minDelta    float64
+}
+
+// AnomalyDetector 统计异常检测器，从存储的历史中学习每个序列的基线（最近一段时间的均值和标准差，
+// 或之前各周同一时刻的值），当前值超出 均值±sensitivity×标准差 时告警
+type AnomalyDetector struct {
+	series             []anomalySeries
+	step               time.Duration
+	window             time.Duration
+	weeks              int
+	rollingMinSamples  int
+	seasonalMinSamples int
+	history            MetricsHistory
+	events             EventHistory
+}
+
+// NewAnomalyDetector 创建统计异常检测器，未配置的参数使用默认值。events为nil时不检测事件速率
+func NewAnomalyDetector(cfg config.AnomalyDetectorConfig, history MetricsHistory, events EventHistory) (*AnomalyDetector, error) {
+	d := &AnomalyDetector{
+		step:               cfg.Step,
+		window:             cfg.Window,
+		weeks:              cfg.Weeks,
+		rollingMinSamples:  cfg.MinSamples,
+		seasonalMinSamples: cfg.MinSamples,
+		history:            history,
+		events:             events,
+	}
+	if d.step <= 0 {
+		d.step = defaultAnomalyStep
+	}
+	if d.window <= d.step {
+		d.window = defaultAnomalyWindow
+	}
+	if d.weeks <= 0 {
+		d.weeks = defaultAnomalyWeeks
+	}
+	if cfg.MinSamples <= 0 {
+		d.rollingMinSamples = defaultRollingMinSamples
+		d.seasonalMinSamples = defaultSeasonalMinSamples
+	}
+
+	seriesConfigs := cfg.Series
+	if len(seriesConfigs) == 0 {
+		seriesConfigs = defaultAnomalySeries
+	}
+	fields := make(map[string]bool)
+	for _, field := range metrics.FieldNames() {
+		fields[field] = true
+	}
+	fields[seriesRestartRate] = true
+	fields[seriesWarningEventRate] = true
+
+	for _, sc := range seriesConfigs {
+		if !fields[sc.Field] {
+			return nil, fmt.Errorf("unknown anomaly series field: %s", sc.Field)
+		}
+		series := anomalySeries{field: sc.Field, method: sc.Method, sensitivity: sc.Sensitivity, minDelta: sc.MinDelta}
+		if series.method == "" {
+			series.method = cfg.Method
+		}
+		if series.method == "" {
+			series.method = anomalyMethodRolling
+		}
+		if series.method != anomalyMethodRolling && series.method != anomalyMethodSeasonal {
+			return nil, fmt.Errorf("unknown anomaly method for %s: %s", sc.Field, series.method)
+		}
+		if series.sensitivity <= 0 {
+			series.sensitivity = cfg.Sensitivity
+		}
+		if series.sensitivity <= 0 {
+			series.sensitivity = defaultAnomalySensitivity
+		}
+		d.series = append(d.series, series)
+	}
+	return d, nil
+}
+
+// Name 检测器名称
+func (d *AnomalyDetector) Name() string {
+	return "anomaly"
+}
+
+// Detect 计算各序列的基线，返回当前值超出预期范围的序列
+func (d *AnomalyDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	now := time.Now()
+
+	// 两种基线的数据按需加载，同一轮检测中各序列共用
+	var rolling, recent *seriesData
+	var seasonal []*seriesData
+	loadRolling := func() (*seriesData, error) {
+		if rolling == nil {
+			data, err := d.load(clusterName, now.Add(-d.window-d.step), now)
+			if err != nil {
+				return nil, err
+			}
+			rolling = data
+		}
+		return rolling, nil
+	}
+	loadSeasonal := func() (*seriesData, []*seriesData, error) {
+		if recent == nil {
+			data, err := d.load(clusterName, now.Add(-2*d.step), now)
+			if err != nil {
+				return nil, nil, err
+			}
+			recent = data
+			for w := 1; w <= d.weeks; w++ {
+				// 多取一个时段，供速率类序列计算时段开始前的最后一个采样
+				end := now.Add(-time.Duration(w) * week)
+				data, err := d.load(clusterName, end.Add(-2*seasonalSlot), end)
+				if err != nil {
+					return nil, nil, err
+				}
+				seasonal = append(seasonal, data)
+			}
+		}
+		return recent, seasonal, nil
+	}
+
+	var findings []Finding
+	for _, series := range d.series {
+		var observed float64
+		var ok bool
+		var baseline []float64
+		var minSamples int
+		var description string
+
+		switch series.method {
+		case anomalyMethodRolling:
+			data, err := loadRolling()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load history: %v", err)
+			}
+			observed, ok = data.value(series.field, now.Add(-d.step), now, d.step)
+			for end := now.Add(-d.step); !end.Add(-d.step).Before(now.Add(-d.window)); end = end.Add(-d.step) {
+				if v, ok := data.value(series.field, end.Add(-d.step), end, d.step); ok {
+					baseline = append(baseline, v)
+				}
+			}
+			minSamples = d.rollingMinSamples
+			description = fmt.Sprintf("rolling baseline over the last %s", d.window)
+		case anomalyMethodSeasonal:
+			current, weekly, err := loadSeasonal()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load history: %v", err)
+			}
+			observed, ok = current.value(series.field, now.Add(-d.step), now, d.step)
+			for w, data := range weekly {
+				end := now.Add(-time.Duration(w+1) * week)
+				if v, ok := data.value(series.field, end.Add(-seasonalSlot), end, d.step); ok {
+					baseline = append(baseline, v)
+				}
+			}
+			minSamples = d.seasonalMinSamples
+			description = fmt.Sprintf("same hour of week over the last %d weeks", d.weeks)
+		}
+		if !ok || len(baseline) < minSamples {
+			continue
+		}
+
+		mean, stddev := meanStddev(baseline)
+		if floor := minRelativeStddev * math.Abs(mean); stddev < floor {
+			stddev = floor
+		}
+		// 所有序列均非负，预期下限不低于0
+		low := math.Max(mean-series.sensitivity*stddev, 0)
+		high := mean + series.sensitivity*stddev
+		if (observed >= low && observed <= high) || math.Abs(observed-mean) < series.minDelta {
+			continue
+		}
+
+		unit := ""
+		if series.field == seriesRestartRate || series.field == seriesWarningEventRate {
+			unit = " per " + d.step.String()
+		}
+		findings = append(findings, Finding{
+			Type:  "Anomaly",
+			Level: "warning",
+			Message: fmt.Sprintf("%s is %s%s, expected %s - %s (%s, %d samples)", series.field,
+				formatAnomalyValue(observed), unit, formatAnomalyValue(low), formatAnomalyValue(high), description, len(baseline)),
+			Labels: map[string]string{"series": series.field},
+			Annotations: map[string]string{
+				"observed":     formatAnomalyValue(observed),
+				"expected_min": formatAnomalyValue(low),
+				"expected_max": formatAnomalyValue(high),
+				"mean":         formatAnomalyValue(mean),
+				"method":       series.method,
+			},
+		})
+	}
+	return findings, nil
+}
+
+// seriesData 计算序列值所需的指标采样和Warning事件次数，均按时间排序
+type seriesData struct {
+	samples []*metrics.ClusterMetrics
+	// events 为nil时没有事件数据，不计算事件速率
+	events []eventDelta
+}
+
+// eventDelta 一条事件记录相对同一事件上一条记录新增的次数
+type eventDelta struct {
+	time  time.Time
+	count int
+}
+
+// load 加载时间范围内的指标采样和Warning事件
+func (d *AnomalyDetector) load(clusterName string, from, to time.Time) (*seriesData, error) {
+	samples, err := d.history(clusterName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	data := &seriesData{samples: samples}
+
+	if d.events == nil || !d.usesEvents() {
+		return data, nil
+	}
+	records, err := d.events(clusterName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].LastSeen.Before(records[j].LastSeen) })
+
+	// 事件每次计数变化归档一条记录，按同一事件的上一条记录计算新增次数；
+	// 查询范围内的第一条记录在范围内首次发生时计全部次数，否则只计1次
+	data.events = []eventDelta{}
+	counts := make(map[string]int32)
+	for _, record := range records {
+		delta := 1
+		if previous, ok := counts[record.UID]; ok {
+			delta = int(record.Count - previous)
+		} else if !record.FirstSeen.Before(from) {
+			delta = int(record.Count)
+		}
+		counts[record.UID] = record.Count
+		if delta > 0 {
+			data.events = append(data.events, eventDelta{time: record.LastSeen, count: delta})
+		}
+	}
+	return data, nil
+}
+
+// usesEvents 判断是否检测事件速率
+func (d *AnomalyDetector) usesEvents() bool {
+	for _, series := range d.series {
+		if series.field == seriesWarningEventRate {
+			return true
+		}
+	}
+	return false
+}
+
+// value 计算序列在 [from, to) 内的值：普通字段取采样的平均值，速率类序列折算为每个step内的次数
+func (data *seriesData) value(field string, from, to time.Time, step time.Duration) (float64, bool) {
+	scale := float64(step) / float64(to.Sub(from))
+
+	switch field {
+	case seriesWarningEventRate:
+		if data.events == nil {
+			return 0, false
+		}
+		var total int
+		for _, e := range data.events {
+			if !e.time.Before(from) && e.time.Before(to) {
+				total += e.count
+			}
+		}
+		return float64(total) * scale, true
+	}
+
+	lo := sort.Search(len(data.samples), func(i int) bool { return !data.samples[i].Timestamp.Before(from) })
+	hi := sort.Search(len(data.samples), func(i int) bool { return !data.samples[i].Timestamp.Before(to) })
+
+	if field == seriesRestartRate {
+		// 从时段开始前的最后一个采样起累计重启次数的增量，Pod被删除导致的减少不计
+		if lo > 0 {
+			lo--
+		}
+		if hi-lo < 2 {
+			return 0, false
+		}
+		var total float64
+		for i := lo + 1; i < hi; i++ {
+			if delta := data.samples[i].Pods.Restarts - data.samples[i-1].Pods.Restarts; delta > 0 {
+				total += float64(delta)
+			}
+		}
+		return total * scale, true
+	}
+
+	if lo == hi {
+		return 0, false
+	}
+	var sum float64
+	for _, m := range data.samples[lo:hi] {
+		v, _ := m.Field(field)
+		sum += v
+	}
+	return sum / float64(hi-lo), true
+}
+
+// meanStddev 计算均值和总体标准差
+func meanStddev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// formatAnomalyValue 格式化序列值，整数不带小数
+func formatAnomalyValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,388 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// 证书检测器的默认参数
+const (
+	defaultCertWarningDays  = 30
+	defaultCertCriticalDays = 7
+	// defaultCertScanInterval 证书变化不频繁，默认每6小时重新扫描一次
+	defaultCertScanInterval = 6 * time.Hour
+	// certManagerGroupVersion cert-manager Certificate CRD的API版本
+	certManagerGroupVersion = "cert-manager.io/v1"
+	// certManagerNameAnnotation cert-manager写入Secret的Certificate名注解
+	certManagerNameAnnotation = "cert-manager.io/certificate-name"
+)
+
+// CertificateInfo TLS证书信息
+type CertificateInfo struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	// Secret 保存证书的Secret名
+	Secret     string    `json:"secret"`
+	CommonName string    `json:"common_name,omitempty"`
+	DNSNames   []string  `json:"dns_names,omitempty"`
+	Issuer     string    `json:"issuer,omitempty"`
+	NotBefore  time.Time `json:"not_before"`
+	// NotAfter 证书链中最早的过期时间
+	NotAfter time.Time `json:"not_after"`
+	// Ingresses 引用该Secret的Ingress
+	Ingresses []string `json:"ingresses,omitempty"`
+	// Certificate 管理该Secret的cert-manager Certificate名
+	Certificate string `json:"certificate,omitempty"`
+	// Error 证书无法解析时的错误信息
+	Error string `json:"error,omitempty"`
+}
+
+// DaysRemaining 距离过期的天数，已过期时为负数
+func (c *CertificateInfo) DaysRemaining(now time.Time) int {
+	return int(c.NotAfter.Sub(now).Hours() / 24)
+}
+
+// certManagerCertificateList cert-manager CertificateList 中用到的字段
+type certManagerCertificateList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			SecretName string `json:"secretName"`
+		} `json:"spec"`
+		Status struct {
+			NotAfter   *metav1.Time `json:"notAfter"`
+			Conditions []struct {
+				Type    string `json:"type"`
+				Status  string `json:"status"`
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// certificateScan 一次扫描的结果
+type certificateScan struct {
+	scannedAt    time.Time
+	certificates []CertificateInfo
+	// certManager cert-manager Certificate未就绪的检测结果
+	certManager []Finding
+}
+
+// CertificateDetector TLS证书检测器，解析kubernetes.io/tls类型Secret中的证书，按剩余天数告警；
+// 集群安装了cert-manager时同时检查Certificate的Ready状况。每个集群按scanInterval重新扫描，
+// 两次扫描之间使用缓存的结果，最近一次扫描结果也用于证书列表查询
+type CertificateDetector struct {
+	warningDays  int
+	criticalDays int
+	scanInterval time.Duration
+
+	mutex sync.RWMutex
+	scans map[string]*certificateScan
+}
+
+// NewCertificateDetector 创建证书检测器，未配置的参数使用默认值
+func NewCertificateDetector(cfg config.CertificateDetectorConfig) *CertificateDetector {
+	d := &CertificateDetector{
+		warningDays:  cfg.WarningDays,
+		criticalDays: cfg.CriticalDays,
+		scanInterval: cfg.ScanInterval,
+		scans:        make(map[string]*certificateScan),
+	}
+	if d.warningDays <= 0 {
+		d.warningDays = defaultCertWarningDays
+	}
+	if d.criticalDays <= 0 {
+		d.criticalDays = defaultCertCriticalDays
+	}
+	if d.scanInterval <= 0 {
+		d.scanInterval = defaultCertScanInterval
+	}
+	return d
+}
+
+// Name 检测器名称
+func (d *CertificateDetector) Name() string {
+	return "certificate"
+}
+
+// Detect 距上次扫描超过scanInterval时重新扫描TLS Secret和cert-manager Certificate，
+// 否则使用缓存的证书按当前时间重新计算过期告警
+func (d *CertificateDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	now := time.Now()
+	d.mutex.RLock()
+	scan, ok := d.scans[clusterName]
+	d.mutex.RUnlock()
+
+	if !ok || now.Sub(scan.scannedAt) >= d.scanInterval {
+		var err error
+		scan, err = d.scan(ctx, clusterName, client, now)
+		if err != nil {
+			return nil, err
+		}
+		d.mutex.Lock()
+		d.scans[clusterName] = scan
+		d.mutex.Unlock()
+	}
+
+	var findings []Finding
+	for i := range scan.certificates {
+		if finding, ok := d.expiryFinding(&scan.certificates[i], now); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return append(findings, scan.certManager...), nil
+}
+
+// scan 列出集群中的TLS Secret并解析证书，同时检查cert-manager Certificate
+func (d *CertificateDetector) scan(ctx context.Context, clusterName string, client k8sclient.Interface, now time.Time) (*certificateScan, error) {
+	secrets, err := client.CoreV1().Secrets("").List(ctx, metav1.ListOptions{
+		FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tls secrets: %v", err)
+	}
+
+	ingresses := secretIngresses(ctx, client)
+
+	scan := &certificateScan{scannedAt: now}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != corev1.SecretTypeTLS {
+			continue
+		}
+
+		info := parseCertificate(secret.Data[corev1.TLSCertKey])
+		info.Cluster = clusterName
+		info.Namespace = secret.Namespace
+		info.Secret = secret.Name
+		info.Ingresses = ingresses[secret.Namespace+"/"+secret.Name]
+		info.Certificate = secret.Annotations[certManagerNameAnnotation]
+		scan.certificates = append(scan.certificates, info)
+	}
+	sortCertificates(scan.certificates)
+
+	scan.certManager = d.certManagerFindings(ctx, client)
+	return scan, nil
+}
+
+// expiryFinding 按剩余天数生成告警：已过期为CertificateExpired，剩余天数不超过critical或warning阈值为CertificateExpiring
+func (d *CertificateDetector) expiryFinding(info *CertificateInfo, now time.Time) (Finding, bool) {
+	labels := map[string]string{"namespace": info.Namespace, "secret": info.Secret}
+	annotations := map[string]string{}
+	if info.CommonName != "" {
+		annotations["common_name"] = info.CommonName
+	}
+	if len(info.DNSNames) > 0 {
+		annotations["dns_names"] = strings.Join(info.DNSNames, ",")
+	}
+	if len(info.Ingresses) > 0 {
+		annotations["ingresses"] = strings.Join(info.Ingresses, ",")
+	}
+	if info.Certificate != "" {
+		annotations["certificate"] = info.Certificate
+	}
+
+	if info.Error != "" {
+		return Finding{
+			Type:        "CertificateInvalid",
+			Namespace:   info.Namespace,
+			Level:       "warning",
+			Message:     fmt.Sprintf("Certificate in secret %s/%s cannot be parsed: %s", info.Namespace, info.Secret, info.Error),
+			Labels:      labels,
+			Annotations: annotations,
+		}, true
+	}
+
+	annotations["not_after"] = info.NotAfter.Format(time.RFC3339)
+	days := info.DaysRemaining(now)
+	subject := fmt.Sprintf("Certificate %s in secret %s/%s", certificateSubject(info), info.Namespace, info.Secret)
+	if len(info.Ingresses) > 0 {
+		subject += " (used by ingress " + strings.Join(info.Ingresses, ", ") + ")"
+	}
+
+	switch {
+	case !now.Before(info.NotAfter):
+		return Finding{
+			Type:        "CertificateExpired",
+			Namespace:   info.Namespace,
+			Level:       "critical",
+			Message:     fmt.Sprintf("%s expired at %s", subject, info.NotAfter.Format("2006-01-02 15:04:05")),
+			Labels:      labels,
+			Annotations: annotations,
+		}, true
+	case days <= d.warningDays:
+		level := "warning"
+		if days <= d.criticalDays {
+			level = "critical"
+		}
+		annotations["days_remaining"] = strconv.Itoa(days)
+		return Finding{
+			Type:        "CertificateExpiring",
+			Namespace:   info.Namespace,
+			Level:       level,
+			Message:     fmt.Sprintf("%s expires in %d days (%s)", subject, days, info.NotAfter.Format("2006-01-02")),
+			Labels:      labels,
+			Annotations: annotations,
+		}, true
+	}
+	return Finding{}, false
+}
+
+// certManagerFindings 检查cert-manager Certificate的Ready状况，集群未安装cert-manager时跳过
+func (d *CertificateDetector) certManagerFindings(ctx context.Context, client k8sclient.Interface) []Finding {
+	if _, err := client.Discovery().ServerResourcesForGroupVersion(certManagerGroupVersion); err != nil {
+		return nil
+	}
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil
+	}
+
+	data, err := restClient.Get().AbsPath("/apis/" + certManagerGroupVersion + "/certificates").DoRaw(ctx)
+	if err != nil {
+		fmt.Printf("Failed to list cert-manager certificates: %v\n", err)
+		return nil
+	}
+	var list certManagerCertificateList
+	if err := json.Unmarshal(data, &list); err != nil {
+		fmt.Printf("Failed to parse cert-manager certificates: %v\n", err)
+		return nil
+	}
+
+	var findings []Finding
+	for _, cert := range list.Items {
+		for _, condition := range cert.Status.Conditions {
+			if condition.Type != "Ready" || condition.Status != string(corev1.ConditionFalse) {
+				continue
+			}
+			annotations := map[string]string{"reason": condition.Reason, "secret": cert.Spec.SecretName}
+			if cert.Status.NotAfter != nil {
+				annotations["not_after"] = cert.Status.NotAfter.Format(time.RFC3339)
+			}
+			findings = append(findings, Finding{
+				Type:      "CertificateNotReady",
+				Namespace: cert.Metadata.Namespace,
+				Level:     "warning",
+				Message: fmt.Sprintf("cert-manager Certificate %s/%s is not ready (%s): %s",
+					cert.Metadata.Namespace, cert.Metadata.Name, condition.Reason, condition.Message),
+				Labels:      map[string]string{"namespace": cert.Metadata.Namespace, "certificate": cert.Metadata.Name},
+				Annotations: annotations,
+			})
+		}
+	}
+	return findings
+}
+
+// Certificates 返回最近一次扫描到的证书，within大于0时只返回在该时长内过期的证书
+func (d *CertificateDetector) Certificates(within time.Duration) []CertificateInfo {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	deadline := time.Now().Add(within)
+	var result []CertificateInfo
+	for _, scan := range d.scans {
+		for _, info := range scan.certificates {
+			if within > 0 && (info.Error != "" || info.NotAfter.After(deadline)) {
+				continue
+			}
+			result = append(result, info)
+		}
+	}
+	sortCertificates(result)
+	return result
+}
+
+// parseCertificate 解析PEM格式的证书链，证书信息取第一个证书，过期时间取链中最早的过期时间
+func parseCertificate(data []byte) CertificateInfo {
+	var info CertificateInfo
+	found := false
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return CertificateInfo{Error: fmt.Sprintf("invalid certificate: %v", err)}
+		}
+		if !found {
+			found = true
+			info.CommonName = cert.Subject.CommonName
+			info.DNSNames = cert.DNSNames
+			info.Issuer = cert.Issuer.CommonName
+			info.NotBefore = cert.NotBefore
+			info.NotAfter = cert.NotAfter
+		} else if cert.NotAfter.Before(info.NotAfter) {
+			info.NotAfter = cert.NotAfter
+		}
+	}
+
+	if !found {
+		return CertificateInfo{Error: "no PEM certificate found in " + corev1.TLSCertKey}
+	}
+	return info
+}
+
+// secretIngresses 返回引用各TLS Secret的Ingress，键为 namespace/secret
+func secretIngresses(ctx context.Context, client k8sclient.Interface) map[string][]string {
+	result := make(map[string][]string)
+	ingresses, err := client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return result
+	}
+	for _, ingress := range ingresses.Items {
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			key := ingress.Namespace + "/" + tls.SecretName
+			result[key] = append(result[key], ingress.Name)
+		}
+	}
+	return result
+}
+
+// certificateSubject 证书的显示名称，优先使用CN，否则使用第一个DNS名
+func certificateSubject(info *CertificateInfo) string {
+	if info.CommonName != "" {
+		return info.CommonName
+	}
+	if len(info.DNSNames) > 0 {
+		return info.DNSNames[0]
+	}
+	return "<no subject>"
+}
+
+// sortCertificates 按过期时间排序，无法解析的证书排在最前面
+func sortCertificates(certificates []CertificateInfo) {
+	sort.SliceStable(certificates, func(i, j int) bool {
+		a, b := certificates[i], certificates[j]
+		if (a.Error != "") != (b.Error != "") {
+			return a.Error != ""
+		}
+		if !a.NotAfter.Equal(b.NotAfter) {
+			return a.NotAfter.Before(b.NotAfter)
+		}
+		return a.Cluster+"/"+a.Namespace+"/"+a.Secret < b.Cluster+"/"+b.Namespace+"/"+b.Secret
+	})
+}
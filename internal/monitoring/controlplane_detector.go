@@ -0,0 +1,268 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// 控制面检测器的默认参数
+const (
+	defaultAPILatencyThreshold = time.Second
+	// latencySamples 计算API Server延迟中位数使用的最近采样数
+	latencySamples = 5
+	// controlPlaneNamespace 控制面组件和集群插件所在的命名空间
+	controlPlaneNamespace = "kube-system"
+)
+
+// 控制面组件名，作为告警的component标签
+const (
+	componentAPIServer = "apiserver"
+	componentEtcd      = "etcd"
+	componentCoreDNS   = "coredns"
+	componentKubeProxy = "kube-proxy"
+)
+
+// healthCheck /readyz或/livez中的一项检查结果
+type healthCheck struct {
+	name   string
+	ok     bool
+	detail string
+}
+
+// ControlPlaneDetector 控制面检测器，检查API Server的/readyz和/livez、请求延迟、etcd，
+// 以及CoreDNS和kube-proxy的就绪情况。失败按组件分别告警
+type ControlPlaneDetector struct {
+	latencyThreshold time.Duration
+
+	mutex     sync.Mutex
+	latencies map[string][]time.Duration
+}
+
+// NewControlPlaneDetector 创建控制面检测器，未配置的参数使用默认值
+func NewControlPlaneDetector(cfg config.ControlPlaneDetectorConfig) *ControlPlaneDetector {
+	d := &ControlPlaneDetector{
+		latencyThreshold: cfg.LatencyThreshold,
+		latencies:        make(map[string][]time.Duration),
+	}
+	if d.latencyThreshold <= 0 {
+		d.latencyThreshold = defaultAPILatencyThreshold
+	}
+	return d
+}
+
+// Name 检测器名称
+func (d *ControlPlaneDetector) Name() string {
+	return "control-plane"
+}
+
+// Detect 检查控制面各组件的健康状态
+func (d *ControlPlaneDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	failures := make(map[string][]string)
+	var componentOrder []string
+	fail := func(component, detail string) {
+		if _, ok := failures[component]; !ok {
+			componentOrder = append(componentOrder, component)
+		}
+		failures[component] = append(failures[component], detail)
+	}
+
+	var findings []Finding
+	for _, endpoint := range []string{"/readyz", "/livez"} {
+		start := time.Now()
+		checks, err := d.healthChecks(ctx, client, endpoint)
+		if endpoint == "/readyz" && err == nil {
+			if finding, ok := d.observeLatency(clusterName, time.Since(start)); ok {
+				findings = append(findings, finding)
+			}
+		}
+		if err != nil {
+			fail(componentAPIServer, fmt.Sprintf("%s request failed: %v", endpoint, err))
+			continue
+		}
+		for _, check := range checks {
+			if !check.ok {
+				fail(checkComponent(check.name), fmt.Sprintf("%s check %s failing: %s", endpoint, check.name, check.detail))
+			}
+		}
+	}
+
+	// 查询kube-system失败时记录为API Server的失败并继续检查，etcd故障时列表请求通常也会失败，不能因此丢弃已有的检查结果
+	if etcdFailures, err := podFailures(ctx, client, "component=etcd"); err != nil {
+		fail(componentAPIServer, fmt.Sprintf("failed to list etcd pods: %v", err))
+	} else {
+		for _, failure := range etcdFailures {
+			fail(componentEtcd, failure)
+		}
+	}
+
+	if detail, err := d.deploymentHealth(ctx, client, "coredns"); err != nil {
+		fail(componentAPIServer, err.Error())
+	} else if detail != "" {
+		fail(componentCoreDNS, detail)
+	}
+	if detail, err := d.daemonSetHealth(ctx, client, "kube-proxy"); err != nil {
+		fail(componentAPIServer, err.Error())
+	} else if detail != "" {
+		fail(componentKubeProxy, detail)
+	}
+
+	for _, component := range componentOrder {
+		level := "warning"
+		if component == componentAPIServer || component == componentEtcd {
+			level = "critical"
+		}
+		findings = append(findings, Finding{
+			Type:        "ControlPlaneComponentUnhealthy",
+			Level:       level,
+			Message:     fmt.Sprintf("%s check failing: %s", component, strings.Join(failures[component], "; ")),
+			Labels:      map[string]string{"component": component},
+			Annotations: map[string]string{"checks": strings.Join(failures[component], "\n")},
+		})
+	}
+	return findings, nil
+}
+
+// healthChecks 请求API Server的健康检查端点并解析verbose输出。集群不允许访问该端点时返回空
+func (d *ControlPlaneDetector) healthChecks(ctx context.Context, client k8sclient.Interface, endpoint string) ([]healthCheck, error) {
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, nil
+	}
+
+	// 检查失败时API Server返回500，响应体中仍包含各项检查的结果
+	body, err := restClient.Get().AbsPath(endpoint).Param("verbose", "").DoRaw(ctx)
+	checks := parseHealthChecks(string(body))
+	if err != nil && len(checks) == 0 {
+		if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) || apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return checks, nil
+}
+
+// parseHealthChecks 解析verbose健康检查输出，每行形如 "[+]etcd ok" 或 "[-]etcd failed: reason withheld"
+func parseHealthChecks(body string) []healthCheck {
+	var checks []healthCheck
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		var ok bool
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			ok = true
+		case strings.HasPrefix(line, "[-]"):
+		default:
+			continue
+		}
+
+		name, detail := line[3:], ""
+		if i := strings.Index(name, " "); i >= 0 {
+			name, detail = name[:i], strings.TrimSpace(name[i+1:])
+		}
+		checks = append(checks, healthCheck{name: name, ok: ok, detail: detail})
+	}
+	return checks
+}
+
+// checkComponent 返回健康检查项所属的组件，etcd相关的检查归属etcd，其余归属API Server
+func checkComponent(check string) string {
+	if strings.HasPrefix(check, "etcd") {
+		return componentEtcd
+	}
+	return componentAPIServer
+}
+
+// observeLatency 记录/readyz请求耗时，最近采样的中位数超过阈值时返回告警
+func (d *ControlPlaneDetector) observeLatency(clusterName string, latency time.Duration) (Finding, bool) {
+	d.mutex.Lock()
+	series := append(d.latencies[clusterName], latency)
+	if len(series) > latencySamples {
+		series = series[len(series)-latencySamples:]
+	}
+	d.latencies[clusterName] = series
+	sorted := append([]time.Duration{}, series...)
+	d.mutex.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	if median <= d.latencyThreshold {
+		return Finding{}, false
+	}
+	return Finding{
+		Type:  "APIServerLatencyHigh",
+		Level: "warning",
+		Message: fmt.Sprintf("apiserver latency is %s (median of last %d requests), threshold %s",
+			median.Round(time.Millisecond), len(sorted), d.latencyThreshold),
+		Labels:      map[string]string{"component": componentAPIServer},
+		Annotations: map[string]string{"latency": median.Round(time.Millisecond).String()},
+	}, true
+}
+
+// podFailures 返回kube-system中匹配标签的静态Pod中未就绪的Pod，托管集群中没有这些Pod时返回空
+func podFailures(ctx context.Context, client k8sclient.Interface, selector string) ([]string, error) {
+	pods, err := client.CoreV1().Pods(controlPlaneNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for _, pod := range pods.Items {
+		ready := false
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady {
+				ready = condition.Status == corev1.ConditionTrue
+			}
+		}
+		if !ready {
+			failures = append(failures, fmt.Sprintf("pod %s on node %s is not ready (%s)", pod.Name, pod.Spec.NodeName, pod.Status.Phase))
+		}
+	}
+	return failures, nil
+}
+
+// deploymentHealth 检查kube-system中Deployment的就绪副本数，不存在时视为未使用该组件
+func (d *ControlPlaneDetector) deploymentHealth(ctx context.Context, client k8sclient.Interface, name string) (string, error) {
+	deployment, err := client.AppsV1().Deployments(controlPlaneNamespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get deployment %s: %v", name, err)
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if deployment.Status.ReadyReplicas < desired {
+		return fmt.Sprintf("deployment %s has %d/%d ready replicas", name, deployment.Status.ReadyReplicas, desired), nil
+	}
+	return "", nil
+}
+
+// daemonSetHealth 检查kube-system中DaemonSet的就绪Pod数，不存在时视为未使用该组件（如使用了kube-proxy替代方案）
+func (d *ControlPlaneDetector) daemonSetHealth(ctx context.Context, client k8sclient.Interface, name string) (string, error) {
+	daemonSet, err := client.AppsV1().DaemonSets(controlPlaneNamespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get daemonset %s: %v", name, err)
+	}
+
+	if daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("daemonset %s has %d/%d ready pods", name, daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,65 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+)
+
+// Finding 一次检测发现的问题，由告警规则或检测器产生，按集群、类型和标签去重为告警
+type Finding struct {
+	Type      string
+	Namespace string
+	Level     string
+	Message   string
+	Labels    map[string]string
+	// Annotations 附加信息，每轮检测更新，不参与告警去重
+	Annotations map[string]string
+}
+
+// Detector 直接访问集群API的检测器
+type Detector interface {
+	// Name 检测器名称，作为其产生告警的来源
+	Name() string
+	// Detect 检测集群并返回当前存在的全部问题，上一轮存在而本轮未返回的问题自动解决。
+	// 返回错误时本轮不会解决该检测器的任何告警
+	Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error)
+}
+
+// NodeReadyDetector 节点就绪检测器，逐个节点检查Ready状况，告警附带节点污点和会被驱逐的Pod
+type NodeReadyDetector struct{}
+
+// NewNodeReadyDetector 创建节点就绪检测器
+func NewNodeReadyDetector() *NodeReadyDetector {
+	return &NodeReadyDetector{}
+}
+
+// Name 检测器名称
+func (d *NodeReadyDetector) Name() string {
+	return "node-ready"
+}
+
+// Detect 检查所有节点的Ready状况
+func (d *NodeReadyDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	impact := newNodeImpact(ctx, client)
+	var findings []Finding
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status != corev1.ConditionTrue {
+				findings = append(findings, impact.finding(node, "NodeNotReady", "warning",
+					fmt.Sprintf("Node %s is not ready: %s", node.Name, condition.Message), corev1.NodeReady))
+			}
+		}
+	}
+
+	return findings, nil
+}
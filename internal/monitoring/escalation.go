@@ -0,0 +1,147 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// escalationPolicy 解析后的升级策略
+type escalationPolicy struct {
+	name     string
+	severity string
+	matcher  Matcher
+	steps    []config.EscalationStepConfig
+}
+
+// escalation 一次待发送的升级通知
+type escalation struct {
+	alert   *Alert
+	message string
+	step    config.EscalationStepConfig
+}
+
+// parseEscalationPolicy 校验升级策略配置，步骤的触发时间必须为正数且递增，引用的接收方必须存在
+func parseEscalationPolicy(cfg config.EscalationPolicyConfig, receivers map[string]*receiver) (*escalationPolicy, error) {
+	if len(cfg.Steps) == 0 {
+		return nil, fmt.Errorf("escalation policy %s: at least one step is required", cfg.Name)
+	}
+
+	var last time.Duration
+	for i, step := range cfg.Steps {
+		if step.After <= last {
+			return nil, fmt.Errorf("escalation policy %s: step %d must start after the previous step", cfg.Name, i+1)
+		}
+		last = step.After
+		for _, name := range step.Receivers {
+			if _, ok := receivers[name]; !ok {
+				return nil, fmt.Errorf("escalation policy %s: step %d references unknown receiver: %s", cfg.Name, i+1, name)
+			}
+		}
+	}
+
+	severity := cfg.Severity
+	if severity == "" {
+		severity = "critical"
+	}
+
+	return &escalationPolicy{
+		name:     cfg.Name,
+		severity: severity,
+		matcher:  matcherFromConfig(cfg.Match),
+		steps:    cfg.Steps,
+	}, nil
+}
+
+// matches 判断策略是否适用于告警
+func (p *escalationPolicy) matches(alert *Alert) bool {
+	return alert.Level == p.severity && p.matcher.Matches(alert)
+}
+
+// SetEscalationPolicies 设置升级策略。步骤引用的接收方需要先通过SetRoutes设置
+func (s *Service) SetEscalationPolicies(cfgs []config.EscalationPolicyConfig) error {
+	s.dispatcher.mutex.Lock()
+	receivers := s.dispatcher.receivers
+	s.dispatcher.mutex.Unlock()
+
+	policies := make([]*escalationPolicy, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		policy, err := parseEscalationPolicy(cfg, receivers)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, policy)
+	}
+
+	s.alertsMutex.Lock()
+	s.escalationPolicies = policies
+	s.alertsMutex.Unlock()
+	return nil
+}
+
+// escalateAlerts 对已通知但超过截止时间仍未确认的firing告警执行下一个升级步骤，每轮最多升级一级。
+// 调用方需持有alertsMutex
+func (s *Service) escalateAlerts(now time.Time) []escalation {
+	var escalations []escalation
+	for _, alert := range s.alerts {
+		if alert.Status != AlertStatusFiring || alert.Acknowledged() || alert.SilencedBy != "" || alert.LastNotifiedAt.IsZero() {
+			continue
+		}
+
+		policy := s.escalationPolicyFor(alert)
+		if policy == nil || alert.EscalationLevel >= len(policy.steps) {
+			continue
+		}
+		step := policy.steps[alert.EscalationLevel]
+		if now.Sub(alert.CreatedAt) < step.After {
+			continue
+		}
+
+		alert.EscalationLevel++
+		alert.record(now, TimelineEscalated, actorSystem,
+			fmt.Sprintf("policy %s step %d/%d", policy.name, alert.EscalationLevel, len(policy.steps)))
+		s.persistAlert(alert)
+
+		escalations = append(escalations, escalation{
+			alert: alert.clone(),
+			message: fmt.Sprintf("[Kubernetes Alert Escalated] %s - %s\nCluster: %s\nLevel: %s\nMessage: %s\nUnacknowledged for: %s\nAcknowledge with: monitor ack %s",
+				alert.Type, alert.ID, alert.Cluster, alert.Level, alert.Message,
+				now.Sub(alert.CreatedAt).Round(time.Second), alert.ID),
+			step: step,
+		})
+	}
+	return escalations
+}
+
+// escalationPolicyFor 返回告警适用的第一个升级策略。调用方需持有alertsMutex
+func (s *Service) escalationPolicyFor(alert *Alert) *escalationPolicy {
+	for _, policy := range s.escalationPolicies {
+		if policy.matches(alert) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// notifyEscalation 立即发送升级通知到告警匹配的所有接收方和步骤配置的接收方，并@对应的值班人
+func (s *Service) notifyEscalation(e escalation) {
+	receivers := s.receiversFor(e.alert)
+	seen := make(map[string]bool)
+	for _, r := range receivers {
+		seen[r.name] = true
+	}
+
+	s.dispatcher.mutex.Lock()
+	for _, name := range e.step.Receivers {
+		if r, ok := s.dispatcher.receivers[name]; ok && !seen[name] {
+			seen[name] = true
+			receivers = append(receivers, r)
+		}
+	}
+	s.dispatcher.mutex.Unlock()
+
+	for _, r := range receivers {
+		s.deliver(r, e.message, &e.step)
+	}
+}
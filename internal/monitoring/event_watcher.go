@@ -0,0 +1,576 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// 事件监听的默认参数
+const (
+	defaultSpikeWindow    = 5 * time.Minute
+	defaultSpikeThreshold = 10
+	defaultSpikeFactor    = 3
+	defaultBaselineWindow = time.Hour
+	// eventCountRetention 记录事件上次计数的保留时长，需长于API Server中事件的TTL
+	eventCountRetention = 2 * time.Hour
+	// eventRetryInterval 事件监听断开后重新连接的间隔
+	eventRetryInterval = 5 * time.Second
+	// eventSubscriberBuffer 实时事件订阅的缓冲大小，订阅方处理不及时时丢弃事件
+	eventSubscriberBuffer = 100
+	// maxSpikeObjects 突增告警中列出的涉及对象数上限
+	maxSpikeObjects = 5
+	// maxPendingArchive 等待归档的事件数上限，归档持续失败时丢弃最早的事件
+	maxPendingArchive = 10000
+)
+
+// EventArchive 归档观察到的事件
+type EventArchive func(events []ClusterEvent) error
+
+// ClusterEvent 从集群事件流中观察到的事件
+type ClusterEvent struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	// Kind 和 Name 事件涉及的对象
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Source  string `json:"source,omitempty"`
+	// Count 事件对象上的累计次数
+	Count     int32     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	UID       string    `json:"uid"`
+}
+
+// object 事件涉及对象的显示名称
+func (e *ClusterEvent) object() string {
+	if e.Namespace == "" {
+		return e.Kind + " " + e.Name
+	}
+	return e.Kind + " " + e.Namespace + "/" + e.Name
+}
+
+// EventAggregate 按涉及对象和原因聚合的Warning事件
+type EventAggregate struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	// Message 最近一次事件的消息
+	Message   string    `json:"message"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// eventFilter 编译后的事件过滤条件
+type eventFilter struct {
+	reason    string
+	namespace string
+	kind      string
+	message   *regexp.Regexp
+}
+
+// matches 判断事件是否匹配过滤条件的所有已配置字段
+func (f *eventFilter) matches(e *ClusterEvent) bool {
+	return (f.reason == "" || f.reason == e.Reason) &&
+		(f.namespace == "" || f.namespace == e.Namespace) &&
+		(f.kind == "" || f.kind == e.Kind) &&
+		(f.message == nil || f.message.MatchString(e.Message))
+}
+
+// eventOccurrence 一次观察到的Warning事件发生次数
+type eventOccurrence struct {
+	time   time.Time
+	reason string
+	object string
+	count  int
+}
+
+// clusterEventState 单个集群的事件监听状态
+type clusterEventState struct {
+	cancel    context.CancelFunc
+	startedAt time.Time
+	// counts 和 seen 按事件UID记录上次的计数和观察时间，用于计算事件更新时新增的次数
+	counts      map[string]int32
+	seen        map[string]time.Time
+	aggregates  map[string]*EventAggregate
+	occurrences []eventOccurrence
+}
+
+// EventWatcher Warning事件监听器。首次检测某个集群时开始watch该集群的事件，
+// 按涉及对象和原因聚合Warning事件，丢弃匹配过滤条件的噪声事件，并在某个原因的事件速率突增时告警。
+// 设置了归档时，所有观察到的事件（包括Normal事件和噪声事件）在每轮检测时批量归档
+type EventWatcher struct {
+	filters        []eventFilter
+	spikeWindow    time.Duration
+	spikeThreshold int
+	spikeFactor    float64
+	baselineWindow time.Duration
+	archive        EventArchive
+
+	mutex          sync.Mutex
+	clusters       map[string]*clusterEventState
+	subscribers    map[int]chan ClusterEvent
+	nextSubscriber int
+	pending        []ClusterEvent
+}
+
+// NewEventWatcher 创建Warning事件监听器，未配置的参数使用默认值。archive为nil时不归档事件
+func NewEventWatcher(cfg config.EventDetectorConfig, archive EventArchive) (*EventWatcher, error) {
+	w := &EventWatcher{
+		spikeWindow:    cfg.SpikeWindow,
+		spikeThreshold: cfg.SpikeThreshold,
+		spikeFactor:    cfg.SpikeFactor,
+		baselineWindow: cfg.BaselineWindow,
+		archive:        archive,
+		clusters:       make(map[string]*clusterEventState),
+		subscribers:    make(map[int]chan ClusterEvent),
+	}
+	if w.spikeWindow <= 0 {
+		w.spikeWindow = defaultSpikeWindow
+	}
+	if w.spikeThreshold <= 0 {
+		w.spikeThreshold = defaultSpikeThreshold
+	}
+	if w.spikeFactor <= 0 {
+		w.spikeFactor = defaultSpikeFactor
+	}
+	if w.baselineWindow <= w.spikeWindow {
+		w.baselineWindow = defaultBaselineWindow
+	}
+
+	for i, filter := range cfg.Filters {
+		compiled := eventFilter{reason: filter.Reason, namespace: filter.Namespace, kind: filter.Kind}
+		if filter.Message != "" {
+			re, err := regexp.Compile(filter.Message)
+			if err != nil {
+				return nil, fmt.Errorf("invalid message pattern in event filter %d: %v", i+1, err)
+			}
+			compiled.message = re
+		}
+		if compiled == (eventFilter{}) {
+			return nil, fmt.Errorf("event filter %d has no conditions", i+1)
+		}
+		w.filters = append(w.filters, compiled)
+	}
+	return w, nil
+}
+
+// Name 检测器名称
+func (w *EventWatcher) Name() string {
+	return "events"
+}
+
+// Detect 确保集群的事件监听已启动，归档新观察到的事件，并返回事件速率突增的原因
+func (w *EventWatcher) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	if err := w.ensureWatching(clusterName, client); err != nil {
+		return nil, err
+	}
+	w.flushArchive()
+	return w.spikes(clusterName, time.Now()), nil
+}
+
+// flushArchive 归档等待中的事件，失败时保留到下一轮重试
+func (w *EventWatcher) flushArchive() {
+	w.mutex.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mutex.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := w.archive(pending); err != nil {
+		fmt.Printf("Failed to archive %d events: %v\n", len(pending), err)
+		w.mutex.Lock()
+		w.pending = append(pending, w.pending...)
+		w.trimPending()
+		w.mutex.Unlock()
+	}
+}
+
+// trimPending 等待归档的事件超过上限时丢弃最早的事件。调用方需持有mutex
+func (w *EventWatcher) trimPending() {
+	if len(w.pending) > maxPendingArchive {
+		w.pending = w.pending[len(w.pending)-maxPendingArchive:]
+	}
+}
+
+// Stop 停止所有集群的事件监听，并关闭所有订阅者的通道，使订阅者（如事件流接口）结束并重新订阅替换后的监听器
+func (w *EventWatcher) Stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for name, state := range w.clusters {
+		state.cancel()
+		delete(w.clusters, name)
+	}
+	for id, subscriber := range w.subscribers {
+		close(subscriber)
+		delete(w.subscribers, id)
+	}
+}
+
+// ensureWatching 集群尚未监听时列出现有事件作为计数起点并开始watch。启动前已存在的事件不计入聚合和速率
+func (w *EventWatcher) ensureWatching(clusterName string, client k8sclient.Interface) error {
+	w.mutex.Lock()
+	_, ok := w.clusters[clusterName]
+	w.mutex.Unlock()
+	if ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &clusterEventState{
+		cancel:     cancel,
+		startedAt:  time.Now(),
+		counts:     make(map[string]int32),
+		seen:       make(map[string]time.Time),
+		aggregates: make(map[string]*EventAggregate),
+	}
+
+	w.mutex.Lock()
+	if _, ok := w.clusters[clusterName]; ok {
+		w.mutex.Unlock()
+		cancel()
+		return nil
+	}
+	w.clusters[clusterName] = state
+	w.mutex.Unlock()
+
+	watcher, err := w.listAndWatch(ctx, clusterName, client, false)
+	if err != nil {
+		cancel()
+		w.mutex.Lock()
+		delete(w.clusters, clusterName)
+		w.mutex.Unlock()
+		return fmt.Errorf("failed to watch events: %v", err)
+	}
+
+	go w.run(ctx, clusterName, client, watcher)
+	return nil
+}
+
+// listAndWatch 列出集群中的事件并从列表的resourceVersion开始watch。emit为false时只记录计数
+func (w *EventWatcher) listAndWatch(ctx context.Context, clusterName string, client k8sclient.Interface, emit bool) (watch.Interface, error) {
+	events, err := client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range events.Items {
+		w.observe(clusterName, &events.Items[i], emit)
+	}
+	return client.CoreV1().Events("").Watch(ctx, metav1.ListOptions{ResourceVersion: events.ResourceVersion})
+}
+
+// run 处理watch到的事件，连接断开或resourceVersion过期时重新list并watch，直到监听被停止
+func (w *EventWatcher) run(ctx context.Context, clusterName string, client k8sclient.Interface, watcher watch.Interface) {
+	for {
+		for event := range watcher.ResultChan() {
+			if event.Type == watch.Error {
+				break
+			}
+			if ev, ok := event.Object.(*corev1.Event); ok && (event.Type == watch.Added || event.Type == watch.Modified) {
+				w.observe(clusterName, ev, true)
+			}
+		}
+		watcher.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(eventRetryInterval):
+			}
+
+			next, err := w.listAndWatch(ctx, clusterName, client, true)
+			if err == nil {
+				watcher = next
+				break
+			}
+			fmt.Printf("Failed to watch events for cluster %s: %v\n", clusterName, err)
+		}
+	}
+}
+
+// observe 记录事件的最新计数，计数增加时等待归档。Warning事件计数增加且未被过滤时计入聚合和速率，并推送给订阅方
+func (w *EventWatcher) observe(clusterName string, ev *corev1.Event, emit bool) {
+	e := toClusterEvent(clusterName, ev)
+	now := time.Now()
+	if e.LastSeen.IsZero() {
+		e.LastSeen = now
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	state, ok := w.clusters[clusterName]
+	if !ok {
+		return
+	}
+	delta := int(e.Count - state.counts[e.UID])
+	state.counts[e.UID] = e.Count
+	state.seen[e.UID] = now
+	if delta <= 0 {
+		return
+	}
+	if w.archive != nil {
+		w.pending = append(w.pending, e)
+		w.trimPending()
+	}
+	if !emit || e.Type != corev1.EventTypeWarning || w.filtered(&e) {
+		return
+	}
+
+	key := e.Namespace + "/" + e.Kind + "/" + e.Name + "/" + e.Reason
+	aggregate, ok := state.aggregates[key]
+	if !ok {
+		aggregate = &EventAggregate{
+			Cluster:   clusterName,
+			Namespace: e.Namespace,
+			Kind:      e.Kind,
+			Name:      e.Name,
+			Reason:    e.Reason,
+			FirstSeen: e.FirstSeen,
+		}
+		state.aggregates[key] = aggregate
+	}
+	aggregate.Count += delta
+	aggregate.Message = e.Message
+	aggregate.LastSeen = e.LastSeen
+
+	state.occurrences = append(state.occurrences, eventOccurrence{time: now, reason: e.Reason, object: e.object(), count: delta})
+
+	for _, subscriber := range w.subscribers {
+		select {
+		case subscriber <- e:
+		default:
+		}
+	}
+}
+
+// filtered 判断事件是否匹配任一过滤条件
+func (w *EventWatcher) filtered(e *ClusterEvent) bool {
+	for i := range w.filters {
+		if w.filters[i].matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// spikes 清理过期的状态，返回时间窗口内事件数达到阈值且达到基线速率指定倍数的原因
+func (w *EventWatcher) spikes(clusterName string, now time.Time) []Finding {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	state, ok := w.clusters[clusterName]
+	if !ok {
+		return nil
+	}
+	w.prune(state, now)
+
+	current := make(map[string]int)
+	baseline := make(map[string]int)
+	objects := make(map[string]map[string]int)
+	for _, o := range state.occurrences {
+		if now.Sub(o.time) > w.spikeWindow {
+			baseline[o.reason] += o.count
+			continue
+		}
+		current[o.reason] += o.count
+		if objects[o.reason] == nil {
+			objects[o.reason] = make(map[string]int)
+		}
+		objects[o.reason][o.object] += o.count
+	}
+
+	// 监听时间不足一个完整基线窗口时按实际监听时长折算
+	observed := now.Sub(state.startedAt)
+	if observed > w.baselineWindow {
+		observed = w.baselineWindow
+	}
+	baselineWindows := float64(observed-w.spikeWindow) / float64(w.spikeWindow)
+
+	var reasons []string
+	for reason := range current {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	var findings []Finding
+	for _, reason := range reasons {
+		count := current[reason]
+		var rate float64
+		if baselineWindows >= 1 {
+			rate = float64(baseline[reason]) / baselineWindows
+		}
+		if count < w.spikeThreshold || float64(count) < w.spikeFactor*rate {
+			continue
+		}
+
+		top := topObjects(objects[reason], maxSpikeObjects)
+		findings = append(findings, Finding{
+			Type:  "EventRateSpike",
+			Level: "warning",
+			Message: fmt.Sprintf("%d %s events in the last %s (baseline %.1f per %s), top objects: %s",
+				count, reason, w.spikeWindow, rate, w.spikeWindow, strings.Join(top, ", ")),
+			Labels: map[string]string{"reason": reason},
+			Annotations: map[string]string{
+				"count":    strconv.Itoa(count),
+				"baseline": fmt.Sprintf("%.1f", rate),
+				"objects":  strings.Join(top, "\n"),
+			},
+		})
+	}
+	return findings
+}
+
+// prune 清理超过基线窗口的事件次数和聚合结果，以及超过保留时长的事件计数。调用方需持有mutex
+func (w *EventWatcher) prune(state *clusterEventState, now time.Time) {
+	i := 0
+	for i < len(state.occurrences) && now.Sub(state.occurrences[i].time) > w.baselineWindow {
+		i++
+	}
+	state.occurrences = state.occurrences[i:]
+
+	for key, aggregate := range state.aggregates {
+		if now.Sub(aggregate.LastSeen) > w.baselineWindow {
+			delete(state.aggregates, key)
+		}
+	}
+	for uid, seen := range state.seen {
+		if now.Sub(seen) > eventCountRetention {
+			delete(state.seen, uid)
+			delete(state.counts, uid)
+		}
+	}
+}
+
+// Aggregates 返回聚合后的Warning事件，按最近发生时间倒序。clusterName为空时返回所有集群
+func (w *EventWatcher) Aggregates(clusterName string) []EventAggregate {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var result []EventAggregate
+	for name, state := range w.clusters {
+		if clusterName != "" && name != clusterName {
+			continue
+		}
+		for _, aggregate := range state.aggregates {
+			result = append(result, *aggregate)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result
+}
+
+// Subscribe 订阅实时的Warning事件，返回的函数用于取消订阅
+func (w *EventWatcher) Subscribe() (<-chan ClusterEvent, func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	id := w.nextSubscriber
+	w.nextSubscriber++
+	ch := make(chan ClusterEvent, eventSubscriberBuffer)
+	w.subscribers[id] = ch
+
+	return ch, func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		if _, ok := w.subscribers[id]; ok {
+			delete(w.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// toClusterEvent 转换事件对象，兼容只设置了eventTime和series的新版事件
+func toClusterEvent(clusterName string, ev *corev1.Event) ClusterEvent {
+	count := ev.Count
+	if ev.Series != nil && ev.Series.Count > count {
+		count = ev.Series.Count
+	}
+	if count <= 0 {
+		count = 1
+	}
+
+	last := ev.LastTimestamp.Time
+	if last.IsZero() && ev.Series != nil {
+		last = ev.Series.LastObservedTime.Time
+	}
+	if last.IsZero() {
+		last = ev.EventTime.Time
+	}
+	first := ev.FirstTimestamp.Time
+	if first.IsZero() {
+		first = ev.EventTime.Time
+	}
+	if first.IsZero() {
+		first = last
+	}
+
+	namespace := ev.InvolvedObject.Namespace
+	if namespace == "" {
+		namespace = ev.Namespace
+	}
+	source := ev.Source.Component
+	if source == "" {
+		source = ev.ReportingController
+	}
+
+	return ClusterEvent{
+		Cluster:   clusterName,
+		Namespace: namespace,
+		Kind:      ev.InvolvedObject.Kind,
+		Name:      ev.InvolvedObject.Name,
+		Type:      ev.Type,
+		Reason:    ev.Reason,
+		Message:   ev.Message,
+		Source:    source,
+		Count:     count,
+		FirstSeen: first,
+		LastSeen:  last,
+		UID:       string(ev.UID),
+	}
+}
+
+// topObjects 返回事件次数最多的对象，格式为 "对象 (次数)"
+func topObjects(counts map[string]int, limit int) []string {
+	objects := make([]string, 0, len(counts))
+	for object := range counts {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if counts[objects[i]] != counts[objects[j]] {
+			return counts[objects[i]] > counts[objects[j]]
+		}
+		return objects[i] < objects[j]
+	})
+	if len(objects) > limit {
+		objects = objects[:limit]
+	}
+	for i, object := range objects {
+		objects[i] = fmt.Sprintf("%s (%d)", object, counts[object])
+	}
+	return objects
+}
@@ -0,0 +1,230 @@
+package monitoring
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collectionStats 单个集群的指标收集统计
+type collectionStats struct {
+	lastDuration time.Duration
+	lastSuccess  time.Time
+	successTotal int
+	failureTotal int
+}
+
+// selfStats klaw自身运行指标
+type selfStats struct {
+	mutex        sync.Mutex
+	collections  map[string]*collectionStats
+	sendFailures map[string]int
+}
+
+// newSelfStats 创建自身运行指标
+func newSelfStats() *selfStats {
+	return &selfStats{
+		collections:  make(map[string]*collectionStats),
+		sendFailures: make(map[string]int),
+	}
+}
+
+// observeCollection 记录一次指标收集的耗时和结果
+func (st *selfStats) observeCollection(clusterName string, duration time.Duration, err error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	stats, ok := st.collections[clusterName]
+	if !ok {
+		stats = &collectionStats{}
+		st.collections[clusterName] = stats
+	}
+
+	stats.lastDuration = duration
+	if err != nil {
+		stats.failureTotal++
+		return
+	}
+	stats.successTotal++
+	stats.lastSuccess = time.Now()
+}
+
+// recordSendFailure 记录一次消息发送失败
+func (st *selfStats) recordSendFailure(platform string) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.sendFailures[platform]++
+}
+
+// promSample Prometheus样本
+type promSample struct {
+	labels []string
+	value  float64
+}
+
+// promFamily Prometheus指标族
+type promFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []promSample
+}
+
+// promRegistry 按名称汇总指标族，保证同一指标的样本连续输出
+type promRegistry struct {
+	families map[string]*promFamily
+}
+
+// add 添加样本，labels为键值交替的标签列表
+func (r *promRegistry) add(name, typ, help string, value float64, labels ...string) {
+	family, ok := r.families[name]
+	if !ok {
+		family = &promFamily{name: name, help: help, typ: typ}
+		r.families[name] = family
+	}
+	family.samples = append(family.samples, promSample{labels: labels, value: value})
+}
+
+// gauge 添加gauge样本
+func (r *promRegistry) gauge(name, help string, value float64, labels ...string) {
+	r.add(name, "gauge", help, value, labels...)
+}
+
+// counter 添加counter样本
+func (r *promRegistry) counter(name, help string, value float64, labels ...string) {
+	r.add(name, "counter", help, value, labels...)
+}
+
+// writeTo 以Prometheus文本格式输出
+func (r *promRegistry) writeTo(w io.Writer) error {
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		family := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", family.name, family.typ)
+		for _, sample := range family.samples {
+			b.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i+1 < len(sample.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", sample.labels[i], escapeLabelValue(sample.labels[i+1]))
+				}
+				b.WriteByte('}')
+			}
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(sample.value, 'g', -1, 64))
+			b.WriteByte('\n')
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabelValue 转义标签值中的反斜杠、双引号和换行
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+// WriteMetrics 以Prometheus文本格式输出最新的集群指标、活跃告警和klaw自身运行指标
+func (s *Service) WriteMetrics(w io.Writer) error {
+	registry := &promRegistry{families: make(map[string]*promFamily)}
+
+	s.historyMutex.RLock()
+	for clusterName, history := range s.metricsHistory {
+		if len(history) == 0 {
+			continue
+		}
+		m := history[len(history)-1]
+
+		registry.gauge("klaw_cluster_nodes", "Number of nodes by readiness.", float64(m.Nodes.Ready), "cluster", clusterName, "status", "ready")
+		registry.gauge("klaw_cluster_nodes", "Number of nodes by readiness.", float64(m.Nodes.NotReady), "cluster", clusterName, "status", "not_ready")
+		for _, node := range m.Nodes.Details {
+			ready := 0.0
+			for _, condition := range node.Conditions {
+				if condition.Type == "Ready" && condition.Status == "True" {
+					ready = 1
+				}
+			}
+			registry.gauge("klaw_node_ready", "Whether the node reports the Ready condition.", ready, "cluster", clusterName, "node", node.Name)
+			registry.gauge("klaw_node_cpu_usage_percent", "Node CPU usage as a percentage of allocatable.", node.CPUUsagePercent, "cluster", clusterName, "node", node.Name)
+			registry.gauge("klaw_node_memory_usage_percent", "Node memory usage as a percentage of allocatable.", node.MemoryUsagePercent, "cluster", clusterName, "node", node.Name)
+		}
+
+		for _, phase := range []struct {
+			name  string
+			count int
+		}{
+			{"Running", m.Pods.Running},
+			{"Pending", m.Pods.Pending},
+			{"Failed", m.Pods.Failed},
+			{"Succeeded", m.Pods.Succeeded},
+		} {
+			registry.gauge("klaw_cluster_pods", "Number of pods by phase.", float64(phase.count), "cluster", clusterName, "phase", phase.name)
+		}
+		registry.gauge("klaw_cluster_pod_restarts", "Sum of container restarts across all pods.", float64(m.Pods.Restarts), "cluster", clusterName)
+
+		registry.gauge("klaw_cluster_cpu_capacity_cores", "Total node CPU capacity in cores.", float64(m.Resources.CPUCapacity)/1000, "cluster", clusterName)
+		registry.gauge("klaw_cluster_memory_capacity_bytes", "Total node memory capacity in bytes.", float64(m.Resources.MemoryCapacity), "cluster", clusterName)
+		registry.gauge("klaw_cluster_cpu_usage_cores", "CPU usage reported by metrics-server in cores.", float64(m.Resources.CPUUsage)/1000, "cluster", clusterName)
+		registry.gauge("klaw_cluster_memory_usage_bytes", "Memory usage reported by metrics-server in bytes.", float64(m.Resources.MemoryUsage), "cluster", clusterName)
+
+		for _, ns := range m.Namespaces {
+			registry.gauge("klaw_namespace_pods", "Number of pods in the namespace.", float64(ns.Pods.Total), "cluster", clusterName, "namespace", ns.Name)
+			registry.gauge("klaw_namespace_pod_restarts", "Sum of container restarts in the namespace.", float64(ns.Pods.Restarts), "cluster", clusterName, "namespace", ns.Name)
+			registry.gauge("klaw_namespace_cpu_usage_cores", "CPU usage of the namespace in cores.", float64(ns.Usage.CPUUsage)/1000, "cluster", clusterName, "namespace", ns.Name)
+			registry.gauge("klaw_namespace_memory_usage_bytes", "Memory usage of the namespace in bytes.", float64(ns.Usage.MemoryUsage), "cluster", clusterName, "namespace", ns.Name)
+			registry.gauge("klaw_namespace_cpu_requests_cores", "CPU requests of the namespace in cores.", float64(ns.Usage.CPURequests)/1000, "cluster", clusterName, "namespace", ns.Name)
+			registry.gauge("klaw_namespace_memory_requests_bytes", "Memory requests of the namespace in bytes.", float64(ns.Usage.MemoryRequests), "cluster", clusterName, "namespace", ns.Name)
+			for _, workload := range ns.Workloads {
+				registry.gauge("klaw_workload_pods_ready", "Number of ready pods owned by the workload.", float64(workload.Pods.Ready),
+					"cluster", clusterName, "namespace", ns.Name, "kind", workload.Kind, "workload", workload.Name)
+				registry.gauge("klaw_workload_pod_restarts", "Sum of container restarts of pods owned by the workload.", float64(workload.Pods.Restarts),
+					"cluster", clusterName, "namespace", ns.Name, "kind", workload.Kind, "workload", workload.Name)
+			}
+		}
+	}
+	s.historyMutex.RUnlock()
+
+	activeAlerts := make(map[[3]string]int)
+	for _, alert := range s.GetAlerts() {
+		if !alert.Resolved {
+			activeAlerts[[3]string{alert.Cluster, alert.Type, alert.Level}]++
+		}
+	}
+	for key, count := range activeAlerts {
+		registry.gauge("klaw_alerts_active", "Number of unresolved alerts.", float64(count), "cluster", key[0], "type", key[1], "level", key[2])
+	}
+
+	s.stats.mutex.Lock()
+	for clusterName, stats := range s.stats.collections {
+		registry.gauge("klaw_collection_duration_seconds", "Duration of the last metrics collection.", stats.lastDuration.Seconds(), "cluster", clusterName)
+		registry.counter("klaw_collections_total", "Number of successful metrics collections.", float64(stats.successTotal), "cluster", clusterName)
+		registry.counter("klaw_collection_failures_total", "Number of failed metrics collections.", float64(stats.failureTotal), "cluster", clusterName)
+		if !stats.lastSuccess.IsZero() {
+			registry.gauge("klaw_last_collection_timestamp_seconds", "Unix time of the last successful metrics collection.", float64(stats.lastSuccess.Unix()), "cluster", clusterName)
+		}
+	}
+	for platform, count := range s.stats.sendFailures {
+		registry.counter("klaw_messaging_send_failures_total", "Number of failed messaging deliveries.", float64(count), "platform", platform)
+	}
+	s.stats.mutex.Unlock()
+
+	return registry.writeTo(w)
+}
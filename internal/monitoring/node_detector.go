@@ -0,0 +1,351 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// 节点健康检测器的默认参数
+const (
+	defaultFlapThreshold = 3
+	defaultFlapWindow    = 30 * time.Minute
+	defaultLeaseTimeout  = time.Minute
+	// nodeLeaseNamespace kubelet心跳Lease所在的命名空间
+	nodeLeaseNamespace = "kube-node-lease"
+	// maxListedPods 告警中列出的受影响Pod数上限
+	maxListedPods = 20
+)
+
+// nodeConditionAlerts 节点状况为True时对应的告警类型
+var nodeConditionAlerts = map[corev1.NodeConditionType]string{
+	corev1.NodeMemoryPressure:     "NodeMemoryPressure",
+	corev1.NodeDiskPressure:       "NodeDiskPressure",
+	corev1.NodePIDPressure:        "NodePIDPressure",
+	corev1.NodeNetworkUnavailable: "NodeNetworkUnavailable",
+}
+
+// readySample 节点某次检测时的Ready状态
+type readySample struct {
+	time  time.Time
+	ready bool
+}
+
+// NodeHealthDetector 节点健康检测器，检查资源压力和网络状况、Ready状态抖动以及kubelet心跳Lease
+type NodeHealthDetector struct {
+	flapThreshold int
+	flapWindow    time.Duration
+	leaseTimeout  time.Duration
+
+	mutex sync.Mutex
+	ready map[string][]readySample
+}
+
+// NewNodeHealthDetector 创建节点健康检测器，未配置的参数使用默认值
+func NewNodeHealthDetector(cfg config.NodeDetectorConfig) *NodeHealthDetector {
+	d := &NodeHealthDetector{
+		flapThreshold: cfg.FlapThreshold,
+		flapWindow:    cfg.FlapWindow,
+		leaseTimeout:  cfg.LeaseTimeout,
+		ready:         make(map[string][]readySample),
+	}
+	if d.flapThreshold <= 0 {
+		d.flapThreshold = defaultFlapThreshold
+	}
+	if d.flapWindow <= 0 {
+		d.flapWindow = defaultFlapWindow
+	}
+	if d.leaseTimeout <= 0 {
+		d.leaseTimeout = defaultLeaseTimeout
+	}
+	return d
+}
+
+// Name 检测器名称
+func (d *NodeHealthDetector) Name() string {
+	return "node-health"
+}
+
+// Detect 检查所有节点的压力状况、Ready抖动和心跳
+func (d *NodeHealthDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	now := time.Now()
+	leases := d.leaseRenewTimes(ctx, client)
+	impact := newNodeImpact(ctx, client)
+
+	var findings []Finding
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+
+		for _, condition := range node.Status.Conditions {
+			alertType, ok := nodeConditionAlerts[condition.Type]
+			if !ok || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			message := fmt.Sprintf("Node %s has %s", node.Name, condition.Type)
+			if condition.Message != "" {
+				message += ": " + condition.Message
+			}
+			findings = append(findings, impact.finding(node, alertType, "warning", message, condition.Type))
+		}
+
+		if transitions := d.observeReady(clusterName, node, now); transitions >= d.flapThreshold {
+			findings = append(findings, impact.finding(node, "NodeFlapping", "warning",
+				fmt.Sprintf("Node %s changed between Ready and NotReady %d times in the last %s", node.Name, transitions, d.flapWindow),
+				corev1.NodeReady))
+		}
+
+		if renewTime, ok := leases[node.Name]; ok && now.Sub(renewTime) > d.leaseTimeout {
+			findings = append(findings, impact.finding(node, "NodeHeartbeatStale", "critical",
+				fmt.Sprintf("Kubelet on node %s has not renewed its lease for %s", node.Name, now.Sub(renewTime).Round(time.Second)),
+				corev1.NodeReady))
+		}
+	}
+
+	d.forgetRemovedNodes(clusterName, nodes.Items)
+	return findings, nil
+}
+
+// leaseRenewTimes 获取kube-node-lease中各节点Lease的最后续约时间，没有权限或集群不支持时返回空
+func (d *NodeHealthDetector) leaseRenewTimes(ctx context.Context, client k8sclient.Interface) map[string]time.Time {
+	renewTimes := make(map[string]time.Time)
+	leases, err := client.CoordinationV1().Leases(nodeLeaseNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Failed to list node leases: %v\n", err)
+		return renewTimes
+	}
+	for _, lease := range leases.Items {
+		if lease.Spec.RenewTime != nil {
+			renewTimes[lease.Name] = lease.Spec.RenewTime.Time
+		}
+	}
+	return renewTimes
+}
+
+// observeReady 记录节点的Ready状态，返回时间窗口内Ready状态的变化次数
+func (d *NodeHealthDetector) observeReady(clusterName string, node *corev1.Node, now time.Time) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := clusterName + "/" + node.Name
+	series := append(d.ready[key], readySample{time: now, ready: nodeIsReady(node)})
+	for len(series) > 1 && now.Sub(series[0].time) > d.flapWindow {
+		series = series[1:]
+	}
+	d.ready[key] = series
+
+	transitions := 0
+	for i := 1; i < len(series); i++ {
+		if series[i].ready != series[i-1].ready {
+			transitions++
+		}
+	}
+	return transitions
+}
+
+// forgetRemovedNodes 清理已删除节点的Ready记录
+func (d *NodeHealthDetector) forgetRemovedNodes(clusterName string, nodes []corev1.Node) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	existing := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		existing[clusterName+"/"+node.Name] = true
+	}
+	for key := range d.ready {
+		if strings.HasPrefix(key, clusterName+"/") && !existing[key] {
+			delete(d.ready, key)
+		}
+	}
+}
+
+// nodeIsReady 判断节点Ready状况是否为True
+func nodeIsReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeImpact 为节点告警附加污点和会被驱逐的Pod，集群的Pod列表只在需要时获取一次
+type nodeImpact struct {
+	ctx    context.Context
+	client k8sclient.Interface
+	pods   []corev1.Pod
+	loaded bool
+}
+
+func newNodeImpact(ctx context.Context, client k8sclient.Interface) *nodeImpact {
+	return &nodeImpact{ctx: ctx, client: client}
+}
+
+// finding 创建带有节点污点和受影响Pod的检测结果
+func (n *nodeImpact) finding(node *corev1.Node, alertType, level, message string, condition corev1.NodeConditionType) Finding {
+	annotations := map[string]string{}
+	if taints := formatTaints(node.Spec.Taints); taints != "" {
+		annotations["taints"] = taints
+	}
+
+	evicted := n.evictionCandidates(node.Name, condition)
+	if len(evicted) > 0 {
+		listed := evicted
+		if len(listed) > maxListedPods {
+			listed = append(listed[:maxListedPods:maxListedPods], fmt.Sprintf("... and %d more", len(evicted)-maxListedPods))
+		}
+		annotations["evicted_pods"] = strings.Join(listed, ", ")
+		message += fmt.Sprintf(" (%d pods would be evicted)", len(evicted))
+	}
+
+	return Finding{
+		Type:        alertType,
+		Level:       level,
+		Message:     message,
+		Labels:      map[string]string{"node": node.Name},
+		Annotations: annotations,
+	}
+}
+
+// evictionCandidates 返回节点上会被驱逐的Pod。资源压力时kubelet按QoS驱逐BestEffort和Burstable的Pod，
+// 节点NotReady或失联时节点控制器驱逐不能永久容忍not-ready/unreachable污点的Pod。静态Pod和系统关键Pod不会被驱逐，
+// NetworkUnavailable等其他状况不会触发驱逐
+func (n *nodeImpact) evictionCandidates(nodeName string, condition corev1.NodeConditionType) []string {
+	switch condition {
+	case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeReady:
+	default:
+		return nil
+	}
+
+	if !n.loaded {
+		n.loaded = true
+		pods, err := n.client.CoreV1().Pods("").List(n.ctx, metav1.ListOptions{})
+		if err != nil {
+			fmt.Printf("Failed to list pods for eviction analysis: %v\n", err)
+		} else {
+			n.pods = pods.Items
+		}
+	}
+
+	type candidate struct {
+		name     string
+		priority int
+	}
+	var candidates []candidate
+	for i := range n.pods {
+		pod := &n.pods[i]
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		if pod.Spec.PriorityClassName == "system-node-critical" || pod.Spec.PriorityClassName == "system-cluster-critical" {
+			continue
+		}
+
+		priority := 0
+		switch condition {
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			switch podQOSClass(pod) {
+			case corev1.PodQOSBestEffort:
+				priority = 0
+			case corev1.PodQOSBurstable:
+				priority = 1
+			default:
+				continue
+			}
+		case corev1.NodeReady:
+			if toleratesNodeFailure(pod) {
+				continue
+			}
+		}
+		candidates = append(candidates, candidate{name: pod.Namespace + "/" + pod.Name, priority: priority})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// podQOSClass 返回Pod的QoS等级，状态中没有时按容器的requests和limits计算
+func podQOSClass(pod *corev1.Pod) corev1.PodQOSClass {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass
+	}
+
+	guaranteed := len(pod.Spec.Containers) > 0
+	bestEffort := true
+	for _, container := range pod.Spec.Containers {
+		if len(container.Resources.Requests) > 0 || len(container.Resources.Limits) > 0 {
+			bestEffort = false
+		}
+		for _, resource := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			limit, hasLimit := container.Resources.Limits[resource]
+			request, hasRequest := container.Resources.Requests[resource]
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case bestEffort:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	}
+	return corev1.PodQOSBurstable
+}
+
+// toleratesNodeFailure 判断Pod是否永久容忍节点not-ready和unreachable的NoExecute污点，如DaemonSet的Pod
+func toleratesNodeFailure(pod *corev1.Pod) bool {
+	for _, key := range []string{corev1.TaintNodeNotReady, corev1.TaintNodeUnreachable} {
+		taint := &corev1.Taint{Key: key, Effect: corev1.TaintEffectNoExecute}
+		tolerated := false
+		for _, toleration := range pod.Spec.Tolerations {
+			if toleration.ToleratesTaint(taint) && toleration.TolerationSeconds == nil {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTaints 将污点格式化为 key=value:Effect 形式
+func formatTaints(taints []corev1.Taint) string {
+	parts := make([]string, 0, len(taints))
+	for _, taint := range taints {
+		part := taint.Key
+		if taint.Value != "" {
+			part += "=" + taint.Value
+		}
+		parts = append(parts, part+":"+string(taint.Effect))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,288 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// 告警来源
+const (
+	sourceConnectivity = "connectivity"
+	sourceCollector    = "collector"
+	sourceRules        = "rules"
+)
+
+// actorSystem 监控服务自动产生的告警状态变化的操作者
+const actorSystem = "system"
+
+// detectorTimeout 单个检测器对单个集群检测的超时时间，慢的集群或检测器不会占用其他集群和检测器的时间
+const detectorTimeout = 20 * time.Second
+
+// pipelineLoop 监控流水线循环
+func (s *Service) pipelineLoop() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runPipeline()
+		}
+	}
+}
+
+// RunOnce 立即执行一轮监控流水线
+func (s *Service) RunOnce() {
+	s.runPipeline()
+}
+
+// runPipeline 依次对每个集群执行连通性检查、指标采集、规则计算和检测器，产生的问题统一进入告警处理
+func (s *Service) runPipeline() {
+	now := time.Now()
+	for _, cluster := range s.k8sManager.GetClusters() {
+		s.runClusterPipeline(cluster.Name, now)
+	}
+
+	s.alertsMutex.Lock()
+	escalations := s.escalateAlerts(now)
+	s.pruneResolvedAlerts(now)
+	s.pruneExpiredSilences(now)
+	s.alertsMutex.Unlock()
+
+	s.flushNotifications(time.Now())
+	for _, escalation := range escalations {
+		s.notifyEscalation(escalation)
+	}
+}
+
+// runClusterPipeline 对单个集群执行监控流水线，集群不可达时跳过后续检测。每个检测器使用独立的超时
+func (s *Service) runClusterPipeline(clusterName string, now time.Time) {
+	client, err := s.k8sManager.GetClient(clusterName)
+	if err == nil {
+		_, err = client.Discovery().ServerVersion()
+	}
+	if err != nil {
+		s.applyFindings(clusterName, sourceConnectivity, []Finding{{
+			Type:    "ClusterUnreachable",
+			Level:   "critical",
+			Message: fmt.Sprintf("Failed to connect to cluster: %v", err),
+		}}, now)
+		return
+	}
+	s.applyFindings(clusterName, sourceConnectivity, nil, now)
+
+	clusterMetrics, err := s.collectMetrics(clusterName)
+	if err != nil {
+		s.applyFindings(clusterName, sourceCollector, []Finding{{
+			Type:    "MetricsCollectionFailed",
+			Level:   "warning",
+			Message: fmt.Sprintf("Failed to collect metrics: %v", err),
+		}}, now)
+	} else {
+		s.applyFindings(clusterName, sourceCollector, nil, now)
+		s.applyFindings(clusterName, sourceRules, s.evaluateRules(clusterName, clusterMetrics, now), now)
+	}
+
+	for _, detector := range s.detectors {
+		ctx, cancel := context.WithTimeout(context.Background(), detectorTimeout)
+		findings, err := detector.Detect(ctx, clusterName, client)
+		cancel()
+		if err != nil {
+			fmt.Printf("Detector %s failed for cluster %s: %v\n", detector.Name(), clusterName, err)
+			continue
+		}
+		s.applyFindings(clusterName, detector.Name(), findings, now)
+	}
+}
+
+// evaluateRules 计算告警规则，将firing状态的实例转换为检测结果
+func (s *Service) evaluateRules(clusterName string, clusterMetrics *metrics.ClusterMetrics, now time.Time) []Finding {
+	var findings []Finding
+	for _, inst := range s.ruleEngine.Evaluate(clusterName, clusterMetrics, now) {
+		findings = append(findings, Finding{
+			Type:      inst.Rule,
+			Namespace: inst.Namespace,
+			Level:     inst.Severity,
+			Message:   inst.Message,
+			Labels:    inst.Labels,
+		})
+	}
+	return findings
+}
+
+// applyFindings 将某个来源对集群的一轮检测结果合并到告警状态中：
+// 新问题触发告警，持续的问题按间隔重复通知，本轮未出现的问题自动解决
+func (s *Service) applyFindings(clusterName, source string, findings []Finding, now time.Time) {
+	var notifications []notification
+
+	s.alertsMutex.Lock()
+	active := make(map[string]bool)
+	for _, finding := range findings {
+		alert := s.fireAlert(clusterName, source, finding, now)
+		active[alert.ID] = true
+		if message := s.alertNotification(alert, now); message != "" {
+			notifications = append(notifications, notification{alert: alert.clone(), message: message})
+		}
+		s.persistAlert(alert)
+	}
+	for _, alert := range s.alerts {
+		if alert.Cluster == clusterName && alert.Source == source && alert.Status == AlertStatusFiring && !active[alert.ID] {
+			if message := s.resolveAlert(alert, now, actorSystem, "condition cleared"); message != "" {
+				notifications = append(notifications, notification{alert: alert.clone(), message: message})
+			}
+		}
+	}
+	s.alertsMutex.Unlock()
+
+	s.dispatch(notifications, now)
+}
+
+// fireAlert 按指纹查找或创建firing状态的告警。调用方需持有alertsMutex
+func (s *Service) fireAlert(clusterName, source string, finding Finding, now time.Time) *Alert {
+	id := Fingerprint(clusterName, finding.Type, finding.Labels)
+
+	alert, ok := s.alerts[id]
+	if ok && alert.Status == AlertStatusFiring {
+		alert.Level = finding.Level
+		alert.Message = finding.Message
+		alert.Annotations = finding.Annotations
+		return alert
+	}
+
+	alert = &Alert{
+		ID:          id,
+		Cluster:     clusterName,
+		Namespace:   finding.Namespace,
+		Type:        finding.Type,
+		Level:       finding.Level,
+		Message:     finding.Message,
+		Labels:      finding.Labels,
+		Annotations: finding.Annotations,
+		Source:      source,
+		Status:      AlertStatusFiring,
+		CreatedAt:   now,
+	}
+	alert.record(now, TimelineFired, actorSystem, finding.Message)
+	s.alerts[id] = alert
+	return alert
+}
+
+// alertNotification 判断firing告警是否需要通知：未被静默或维护窗口抑制、未被确认，且从未通知过或距上次通知已超过repeatInterval。
+// 需要通知时更新通知时间并返回消息。调用方需持有alertsMutex
+func (s *Service) alertNotification(alert *Alert, now time.Time) string {
+	silencedBy := s.suppressedBy(alert, now)
+	if silencedBy != "" && silencedBy != alert.SilencedBy {
+		alert.record(now, TimelineSilenced, actorSystem, silencedBy)
+	}
+	alert.SilencedBy = silencedBy
+	if alert.SilencedBy != "" || alert.Acknowledged() {
+		return ""
+	}
+	if !alert.LastNotifiedAt.IsZero() && now.Sub(alert.LastNotifiedAt) < s.repeatInterval {
+		return ""
+	}
+
+	alert.LastNotifiedAt = now
+	alert.record(now, TimelineNotified, actorSystem, "")
+	return formatAlertMessage(alert)
+}
+
+// resolveAlert 将告警标记为已解决，返回解决通知消息。告警从未通知过或当前被抑制时不发送解决通知。
+// 调用方需持有alertsMutex
+func (s *Service) resolveAlert(alert *Alert, now time.Time, actor, detail string) string {
+	alert.resolve(now)
+	alert.record(now, TimelineResolved, actor, detail)
+	alert.SilencedBy = s.suppressedBy(alert, now)
+	s.persistAlert(alert)
+
+	if alert.LastNotifiedAt.IsZero() || alert.SilencedBy != "" {
+		return ""
+	}
+	return fmt.Sprintf("[Kubernetes Alert Resolved] %s - %s\nCluster: %s\nLevel: %s\nMessage: %s\nDuration: %s\nTime: %s",
+		alert.Type, alert.ID, alert.Cluster, alert.Level, alert.Message,
+		now.Sub(alert.CreatedAt).Round(time.Second), now.Format("2006-01-02 15:04:05"))
+}
+
+// pruneResolvedAlerts 清理超过保留时长的已解决告警。调用方需持有alertsMutex
+func (s *Service) pruneResolvedAlerts(now time.Time) {
+	for id, alert := range s.alerts {
+		if alert.Status == AlertStatusResolved && now.Sub(alert.ResolvedAt) > resolvedRetention {
+			delete(s.alerts, id)
+			if s.store != nil {
+				if err := s.store.Delete(alertsBucket, id); err != nil {
+					fmt.Printf("Failed to delete alert %s: %v\n", id, err)
+				}
+			}
+		}
+	}
+}
+
+// formatAlertMessage 格式化告警通知消息
+func formatAlertMessage(alert *Alert) string {
+	message := fmt.Sprintf("[Kubernetes Alert] %s - %s\nCluster: %s\nLevel: %s\nMessage: %s\nSince: %s",
+		alert.Type, alert.ID, alert.Cluster, alert.Level, alert.Message, alert.CreatedAt.Format("2006-01-02 15:04:05"))
+	if labels := formatLabels(alert.Labels); labels != "" {
+		message += "\nLabels: " + labels
+	}
+	if taints := alert.Annotations["taints"]; taints != "" {
+		message += "\nTaints: " + taints
+	}
+	if pods := alert.Annotations["evicted_pods"]; pods != "" {
+		message += "\nPods to be evicted: " + pods
+	}
+	if logs := alert.Annotations["logs"]; logs != "" {
+		message += "\nLast logs:\n" + logs
+	}
+	return message
+}
+
+// GetAlerts 获取所有告警的副本，按触发时间排序
+func (s *Service) GetAlerts() []*Alert {
+	s.alertsMutex.RLock()
+	defer s.alertsMutex.RUnlock()
+
+	alerts := make([]*Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		alerts = append(alerts, alert.clone())
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].CreatedAt.Before(alerts[j].CreatedAt) })
+	return alerts
+}
+
+// ResolveAlert 手动解决告警，告警条件仍满足时下一轮检测会重新触发
+func (s *Service) ResolveAlert(alertID, actor string) error {
+	s.alertsMutex.Lock()
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		s.alertsMutex.Unlock()
+		return fmt.Errorf("alert not found: %s", alertID)
+	}
+	if alert.Status == AlertStatusResolved {
+		s.alertsMutex.Unlock()
+		return nil
+	}
+	now := time.Now()
+	message := s.resolveAlert(alert, now, actor, "manually resolved")
+	resolved := alert.clone()
+	s.alertsMutex.Unlock()
+
+	if message != "" {
+		s.dispatch([]notification{{alert: resolved, message: message}}, now)
+		s.flushNotifications(now)
+	}
+	return nil
+}
+
+// persistAlert 持久化告警
+func (s *Service) persistAlert(alert *Alert) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(alertsBucket, alert.ID, alert); err != nil {
+		fmt.Printf("Failed to persist alert %s: %v\n", alert.ID, err)
+	}
+}
@@ -0,0 +1,324 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// Pod故障检测器的默认参数
+const (
+	defaultPodLogLines            = 10
+	defaultOOMWindow              = time.Hour
+	defaultRestartThreshold       = 5
+	defaultRestartWindow          = 10 * time.Minute
+	maxPodLogBytes          int64 = 4096
+)
+
+// podFailure 容器的一种故障模式
+type podFailure struct {
+	alertType string
+	level     string
+	// withLogs 是否附带上一次运行的日志，容器从未启动的故障没有日志
+	withLogs bool
+}
+
+// 容器等待原因对应的故障模式
+var waitingFailures = map[string]podFailure{
+	"CrashLoopBackOff":           {alertType: "PodCrashLooping", level: "critical", withLogs: true},
+	"ImagePullBackOff":           {alertType: "PodImagePullFailed", level: "warning"},
+	"ErrImagePull":               {alertType: "PodImagePullFailed", level: "warning"},
+	"CreateContainerConfigError": {alertType: "PodContainerConfigError", level: "warning"},
+}
+
+// oomFailure 容器最近被OOMKilled
+var oomFailure = podFailure{alertType: "PodOOMKilled", level: "warning", withLogs: true}
+
+// workloadRef 工作负载标识
+type workloadRef struct {
+	namespace string
+	kind      string
+	name      string
+}
+
+// restartSample 工作负载某一时刻的容器重启总数
+type restartSample struct {
+	time     time.Time
+	restarts int
+}
+
+// PodFailureDetector Pod故障模式检测器，检查容器状态和上一次终止状态，
+// 以及工作负载在时间窗口内的重启次数。同一工作负载同一容器的同类故障合并为一条告警
+type PodFailureDetector struct {
+	logLines         int64
+	oomWindow        time.Duration
+	restartThreshold int
+	restartWindow    time.Duration
+
+	mutex    sync.Mutex
+	restarts map[string][]restartSample
+}
+
+// NewPodFailureDetector 创建Pod故障检测器，未配置的参数使用默认值
+func NewPodFailureDetector(cfg config.PodDetectorConfig) *PodFailureDetector {
+	d := &PodFailureDetector{
+		logLines:         cfg.LogLines,
+		oomWindow:        cfg.OOMWindow,
+		restartThreshold: cfg.RestartThreshold,
+		restartWindow:    cfg.RestartWindow,
+		restarts:         make(map[string][]restartSample),
+	}
+	if d.logLines <= 0 {
+		d.logLines = defaultPodLogLines
+	}
+	if d.oomWindow <= 0 {
+		d.oomWindow = defaultOOMWindow
+	}
+	if d.restartThreshold <= 0 {
+		d.restartThreshold = defaultRestartThreshold
+	}
+	if d.restartWindow <= 0 {
+		d.restartWindow = defaultRestartWindow
+	}
+	return d
+}
+
+// Name 检测器名称
+func (d *PodFailureDetector) Name() string {
+	return "pod-failure"
+}
+
+// containerProblem 一个工作负载中某个容器的一类故障
+type containerProblem struct {
+	failure   podFailure
+	namespace string
+	kind      string
+	workload  string
+	container string
+	reason    string
+	pods      []string
+	// sample 用于获取退出码和日志的Pod及其容器状态
+	sample      *corev1.Pod
+	sampleState corev1.ContainerStatus
+}
+
+// Detect 检查所有Pod的容器状态和工作负载重启次数
+func (d *PodFailureDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	owners := metrics.WorkloadOwners(ctx, client)
+
+	now := time.Now()
+	problems := make(map[string]*containerProblem)
+	var keys []string
+	restarts := make(map[string]int)
+	workloads := make(map[string]workloadRef)
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		kind, workload := metrics.ResolveWorkload(pod, owners)
+		workloadKey := pod.Namespace + "/" + kind + "/" + workload
+		workloads[workloadKey] = workloadRef{namespace: pod.Namespace, kind: kind, name: workload}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			restarts[workloadKey] += int(status.RestartCount)
+
+			failure, reason, ok := d.containerFailure(status, now)
+			if !ok {
+				continue
+			}
+
+			key := workloadKey + "/" + status.Name + "/" + failure.alertType
+			problem, ok := problems[key]
+			if !ok {
+				problem = &containerProblem{
+					failure:     failure,
+					namespace:   pod.Namespace,
+					kind:        kind,
+					workload:    workload,
+					container:   status.Name,
+					reason:      reason,
+					sample:      pod,
+					sampleState: status,
+				}
+				problems[key] = problem
+				keys = append(keys, key)
+			}
+			problem.pods = append(problem.pods, pod.Name)
+		}
+	}
+
+	sort.Strings(keys)
+	var findings []Finding
+	for _, key := range keys {
+		findings = append(findings, d.problemFinding(ctx, client, problems[key]))
+	}
+	findings = append(findings, d.restartSpikes(clusterName, restarts, workloads, now)...)
+
+	return findings, nil
+}
+
+// containerFailure 判断容器当前是否处于某种故障模式
+func (d *PodFailureDetector) containerFailure(status corev1.ContainerStatus, now time.Time) (podFailure, string, bool) {
+	if waiting := status.State.Waiting; waiting != nil {
+		if failure, ok := waitingFailures[waiting.Reason]; ok {
+			return failure, waiting.Reason, true
+		}
+	}
+
+	for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+		if terminated != nil && terminated.Reason == "OOMKilled" && now.Sub(terminated.FinishedAt.Time) <= d.oomWindow {
+			return oomFailure, terminated.Reason, true
+		}
+	}
+
+	return podFailure{}, "", false
+}
+
+// problemFinding 将容器故障转换为检测结果，附带退出码和上一次运行的最后日志
+func (d *PodFailureDetector) problemFinding(ctx context.Context, client k8sclient.Interface, p *containerProblem) Finding {
+	annotations := map[string]string{
+		"pods":   strings.Join(p.pods, ","),
+		"reason": p.reason,
+	}
+
+	message := fmt.Sprintf("Container %s of %s %s/%s is %s (%d pods: %s)",
+		p.container, p.kind, p.namespace, p.workload, p.reason, len(p.pods), strings.Join(p.pods, ", "))
+	if terminated := lastTermination(p.sampleState); terminated != nil {
+		annotations["exit_code"] = strconv.Itoa(int(terminated.ExitCode))
+		message += fmt.Sprintf(", last exit code %d (%s)", terminated.ExitCode, terminated.Reason)
+	}
+	if waiting := p.sampleState.State.Waiting; waiting != nil && waiting.Message != "" {
+		message += ": " + waiting.Message
+	}
+
+	if p.failure.withLogs {
+		if logs, err := d.previousLogs(ctx, client, p.sample, p.container); err == nil && logs != "" {
+			annotations["logs"] = logs
+		}
+	}
+
+	return Finding{
+		Type:      p.failure.alertType,
+		Namespace: p.namespace,
+		Level:     p.failure.level,
+		Message:   message,
+		Labels: map[string]string{
+			"namespace": p.namespace,
+			"kind":      p.kind,
+			"workload":  p.workload,
+			"container": p.container,
+		},
+		Annotations: annotations,
+	}
+}
+
+// lastTermination 返回容器当前或上一次的终止状态
+func lastTermination(status corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status.State.Terminated != nil {
+		return status.State.Terminated
+	}
+	return status.LastTerminationState.Terminated
+}
+
+// previousLogs 获取容器上一次运行的最后几行日志，容器没有重启过时获取当前日志
+func (d *PodFailureDetector) previousLogs(ctx context.Context, client k8sclient.Interface, pod *corev1.Pod, container string) (string, error) {
+	previous := false
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && status.RestartCount > 0 {
+			previous = true
+		}
+	}
+
+	limit := maxPodLogBytes
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &d.logLines,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs: %v", err)
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs: %v", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// restartSpikes 记录工作负载的重启总数，返回时间窗口内重启次数达到阈值的工作负载。
+// Pod被删除导致总数下降时不计入
+func (d *PodFailureDetector) restartSpikes(clusterName string, restarts map[string]int, workloads map[string]workloadRef, now time.Time) []Finding {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var keys []string
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var findings []Finding
+	for _, key := range keys {
+		seriesKey := clusterName + "/" + key
+		series := append(d.restarts[seriesKey], restartSample{time: now, restarts: restarts[key]})
+		for len(series) > 1 && now.Sub(series[0].time) > d.restartWindow {
+			series = series[1:]
+		}
+		d.restarts[seriesKey] = series
+
+		increase := 0
+		for i := 1; i < len(series); i++ {
+			if delta := series[i].restarts - series[i-1].restarts; delta > 0 {
+				increase += delta
+			}
+		}
+		if increase < d.restartThreshold {
+			continue
+		}
+
+		workload := workloads[key]
+		findings = append(findings, Finding{
+			Type:      "WorkloadRestartSpike",
+			Namespace: workload.namespace,
+			Level:     "warning",
+			Message: fmt.Sprintf("%s %s/%s restarted %d times in the last %s",
+				workload.kind, workload.namespace, workload.name, increase, d.restartWindow),
+			Labels: map[string]string{
+				"namespace": workload.namespace,
+				"kind":      workload.kind,
+				"workload":  workload.name,
+			},
+			Annotations: map[string]string{"restarts": strconv.Itoa(increase)},
+		})
+	}
+
+	// 清理已不存在的工作负载
+	for seriesKey := range d.restarts {
+		if strings.HasPrefix(seriesKey, clusterName+"/") {
+			if _, ok := workloads[strings.TrimPrefix(seriesKey, clusterName+"/")]; !ok {
+				delete(d.restarts, seriesKey)
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,407 @@
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
+)
+
+// defaultReceiver 未配置通知路由时使用的接收方，发送到服务的钉钉和飞书客户端
+const defaultReceiver = "default"
+
+// receiver 通知接收方
+type receiver struct {
+	name     string
+	dingtalk []*dingtalk.Client
+	feishu   []string
+}
+
+// route 编译后的通知路由节点
+type route struct {
+	receiver      string
+	match         map[string]string
+	matchRE       map[string]*regexp.Regexp
+	continueMatch bool
+	groupBy       []string
+	groupWait     time.Duration
+	groupInterval time.Duration
+	routes        []*route
+}
+
+// notification 一条待发送的告警通知
+type notification struct {
+	alert   *Alert
+	message string
+}
+
+// alertGroup 同一路由和分组标签下待合并发送的告警通知
+type alertGroup struct {
+	route     *route
+	labels    map[string]string
+	pending   map[string]notification
+	firing    map[string]bool
+	nextFlush time.Time
+	lastFlush time.Time
+}
+
+// dispatcher 按路由树将告警通知分组并定期合并发送
+type dispatcher struct {
+	mutex     sync.Mutex
+	root      *route
+	receivers map[string]*receiver
+	groups    map[string]*alertGroup
+}
+
+// newDispatcher 创建只有默认接收方的分发器，所有告警立即发送
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		root:      &route{receiver: defaultReceiver},
+		receivers: map[string]*receiver{defaultReceiver: {name: defaultReceiver}},
+		groups:    make(map[string]*alertGroup),
+	}
+}
+
+// compileRoute 编译路由节点，未配置的receiver和分组参数继承父路由
+func compileRoute(cfg config.RouteConfig, parent *route, receivers map[string]*receiver) (*route, error) {
+	r := &route{
+		receiver:      cfg.Receiver,
+		match:         cfg.Match,
+		matchRE:       make(map[string]*regexp.Regexp),
+		continueMatch: cfg.Continue,
+		groupBy:       cfg.GroupBy,
+		groupWait:     cfg.GroupWait,
+		groupInterval: cfg.GroupInterval,
+	}
+	if parent != nil {
+		if r.receiver == "" {
+			r.receiver = parent.receiver
+		}
+		if r.groupBy == nil {
+			r.groupBy = parent.groupBy
+		}
+		if r.groupWait == 0 {
+			r.groupWait = parent.groupWait
+		}
+		if r.groupInterval == 0 {
+			r.groupInterval = parent.groupInterval
+		}
+	}
+	if r.receiver == "" {
+		return nil, fmt.Errorf("route requires a receiver")
+	}
+	if _, ok := receivers[r.receiver]; !ok {
+		return nil, fmt.Errorf("route references unknown receiver: %s", r.receiver)
+	}
+
+	for label, pattern := range cfg.MatchRE {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid match_re for label %s: %v", label, err)
+		}
+		r.matchRE[label] = re
+	}
+
+	for _, child := range cfg.Routes {
+		compiled, err := compileRoute(child, r, receivers)
+		if err != nil {
+			return nil, err
+		}
+		r.routes = append(r.routes, compiled)
+	}
+	return r, nil
+}
+
+// matches 判断路由节点自身的匹配条件是否满足
+func (r *route) matches(labels map[string]string) bool {
+	for label, value := range r.match {
+		if labels[label] != value {
+			return false
+		}
+	}
+	for label, re := range r.matchRE {
+		if !re.MatchString(labels[label]) {
+			return false
+		}
+	}
+	return true
+}
+
+// find 返回处理告警的路由节点：依次匹配子路由，命中且未设置continue时停止；没有子路由命中时由当前节点处理
+func (r *route) find(labels map[string]string) []*route {
+	var result []*route
+	for _, child := range r.routes {
+		if !child.matches(labels) {
+			continue
+		}
+		result = append(result, child.find(labels)...)
+		if !child.continueMatch {
+			break
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, r)
+	}
+	return result
+}
+
+// groupLabels 返回告警在该路由下的分组标签
+func (r *route) groupLabels(labels map[string]string) map[string]string {
+	group := make(map[string]string)
+	for _, label := range r.groupBy {
+		if label == "..." {
+			return labels
+		}
+		group[label] = labels[label]
+	}
+	return group
+}
+
+// routingLabels 用于路由匹配的标签，包括告警标签以及alertname、cluster、namespace和severity
+func (a *Alert) routingLabels() map[string]string {
+	labels := make(map[string]string, len(a.Labels)+4)
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = a.Type
+	labels["cluster"] = a.Cluster
+	labels["severity"] = a.Level
+	if a.Namespace != "" {
+		labels["namespace"] = a.Namespace
+	}
+	return labels
+}
+
+// SetRoutes 设置通知接收方和路由树，route为空时所有告警发送到默认的钉钉和飞书
+func (s *Service) SetRoutes(root *config.RouteConfig, receiverCfgs []config.ReceiverConfig) error {
+	receivers := map[string]*receiver{defaultReceiver: {name: defaultReceiver}}
+	for _, cfg := range receiverCfgs {
+		if cfg.Name == "" || cfg.Name == defaultReceiver {
+			return fmt.Errorf("invalid receiver name: %q", cfg.Name)
+		}
+		if _, ok := receivers[cfg.Name]; ok {
+			return fmt.Errorf("duplicate receiver name: %s", cfg.Name)
+		}
+
+		r := &receiver{name: cfg.Name}
+		for _, robot := range cfg.DingTalk {
+			client, err := dingtalk.NewClient(config.DingTalkConfig{Webhook: robot.Webhook, Secret: robot.Secret})
+			if err != nil {
+				return fmt.Errorf("receiver %s: failed to create dingtalk client: %v", cfg.Name, err)
+			}
+			r.dingtalk = append(r.dingtalk, client)
+		}
+		for _, chat := range cfg.Feishu {
+			if chat.ChatID == "" {
+				return fmt.Errorf("receiver %s: feishu chat_id is required", cfg.Name)
+			}
+			r.feishu = append(r.feishu, chat.ChatID)
+		}
+		receivers[cfg.Name] = r
+	}
+
+	// 升级策略引用的接收方不能被移除
+	s.alertsMutex.Lock()
+	for _, policy := range s.escalationPolicies {
+		for _, step := range policy.steps {
+			for _, name := range step.Receivers {
+				if _, ok := receivers[name]; !ok {
+					s.alertsMutex.Unlock()
+					return fmt.Errorf("receiver %s is referenced by escalation policy %s", name, policy.name)
+				}
+			}
+		}
+	}
+	s.alertsMutex.Unlock()
+
+	rootRoute := &route{receiver: defaultReceiver}
+	if root != nil {
+		var err error
+		rootRoute, err = compileRoute(*root, nil, receivers)
+		if err != nil {
+			return fmt.Errorf("invalid notification route: %v", err)
+		}
+	}
+
+	s.dispatcher.mutex.Lock()
+	s.dispatcher.root = rootRoute
+	s.dispatcher.receivers = receivers
+	s.dispatcher.groups = make(map[string]*alertGroup)
+	s.dispatcher.mutex.Unlock()
+	return nil
+}
+
+// dispatch 将告警通知放入对应路由的分组，等待flushNotifications发送
+func (s *Service) dispatch(notifications []notification, now time.Time) {
+	d := s.dispatcher
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, n := range notifications {
+		labels := n.alert.routingLabels()
+		for _, r := range d.root.find(labels) {
+			groupLabels := r.groupLabels(labels)
+			key := fmt.Sprintf("%p|%s", r, formatLabels(groupLabels))
+
+			group, ok := d.groups[key]
+			if !ok {
+				group = &alertGroup{
+					route:     r,
+					labels:    groupLabels,
+					pending:   make(map[string]notification),
+					firing:    make(map[string]bool),
+					nextFlush: now.Add(r.groupWait),
+				}
+				d.groups[key] = group
+			} else if len(group.pending) == 0 {
+				group.nextFlush = group.lastFlush.Add(r.groupInterval)
+				if group.nextFlush.Before(now) {
+					group.nextFlush = now
+				}
+			}
+
+			group.pending[n.alert.ID] = n
+			if n.alert.Status == AlertStatusFiring {
+				group.firing[n.alert.ID] = true
+			} else {
+				delete(group.firing, n.alert.ID)
+			}
+		}
+	}
+}
+
+// flushNotifications 发送已到发送时间的分组，分组内告警全部解决后删除分组
+func (s *Service) flushNotifications(now time.Time) {
+	type delivery struct {
+		receiver *receiver
+		message  string
+	}
+	var deliveries []delivery
+
+	d := s.dispatcher
+	d.mutex.Lock()
+	for key, group := range d.groups {
+		if len(group.pending) == 0 || now.Before(group.nextFlush) {
+			continue
+		}
+		deliveries = append(deliveries, delivery{
+			receiver: d.receivers[group.route.receiver],
+			message:  group.message(),
+		})
+		group.pending = make(map[string]notification)
+		group.lastFlush = now
+		if len(group.firing) == 0 {
+			delete(d.groups, key)
+		}
+	}
+	d.mutex.Unlock()
+
+	// 在锁外发送，避免消息平台响应慢时阻塞告警处理
+	for _, delivery := range deliveries {
+		s.deliver(delivery.receiver, delivery.message, nil)
+	}
+}
+
+// message 合并分组内待发送的通知，只有一条时保持单条告警的格式
+func (g *alertGroup) message() string {
+	notifications := make([]notification, 0, len(g.pending))
+	for _, n := range g.pending {
+		notifications = append(notifications, n)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].alert.CreatedAt.Before(notifications[j].alert.CreatedAt)
+	})
+	if len(notifications) == 1 {
+		return notifications[0].message
+	}
+
+	firing := 0
+	for _, n := range notifications {
+		if n.alert.Status == AlertStatusFiring {
+			firing++
+		}
+	}
+
+	header := fmt.Sprintf("[Kubernetes Alerts] %d firing, %d resolved", firing, len(notifications)-firing)
+	if labels := formatLabels(g.labels); labels != "" {
+		header += "\nGroup: " + labels
+	}
+	parts := []string{header}
+	for _, n := range notifications {
+		parts = append(parts, n.message)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// notificationLoop 定期发送到期的告警分组
+func (s *Service) notificationLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushNotifications(time.Now())
+		}
+	}
+}
+
+// receiversFor 返回告警匹配的所有接收方，用于不分组的即时通知
+func (s *Service) receiversFor(alert *Alert) []*receiver {
+	d := s.dispatcher
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var result []*receiver
+	seen := make(map[string]bool)
+	for _, r := range d.root.find(alert.routingLabels()) {
+		if !seen[r.receiver] {
+			seen[r.receiver] = true
+			result = append(result, d.receivers[r.receiver])
+		}
+	}
+	return result
+}
+
+// deliver 发送消息到接收方，step不为空时按升级步骤@值班人
+func (s *Service) deliver(r *receiver, message string, step *config.EscalationStepConfig) {
+	var mobiles, userIDs []string
+	var atAll bool
+	if step != nil {
+		mobiles, userIDs, atAll = step.DingTalkMobiles, step.FeishuUserIDs, step.MentionAll
+	}
+
+	dingtalkClients := r.dingtalk
+	if r.name == defaultReceiver && s.dingtalkClient != nil {
+		dingtalkClients = []*dingtalk.Client{s.dingtalkClient}
+	}
+	for _, client := range dingtalkClients {
+		if err := client.SendMentionMessage(message, mobiles, atAll); err != nil {
+			fmt.Printf("Failed to send alert to DingTalk (receiver %s): %v\n", r.name, err)
+			s.stats.recordSendFailure("dingtalk")
+		}
+	}
+
+	if r.name == defaultReceiver && s.feishuClient != nil {
+		if err := s.feishuClient.SendMentionMessage(message, userIDs, atAll); err != nil {
+			fmt.Printf("Failed to send alert to Feishu (receiver %s): %v\n", r.name, err)
+			s.stats.recordSendFailure("feishu")
+		}
+	}
+	for _, chatID := range r.feishu {
+		if s.feishuClient == nil {
+			fmt.Printf("Failed to send alert to Feishu chat %s (receiver %s): feishu is not configured\n", chatID, r.name)
+			s.stats.recordSendFailure("feishu")
+			continue
+		}
+		if err := s.feishuClient.SendMentionMessageToChat(chatID, message, userIDs, atAll); err != nil {
+			fmt.Printf("Failed to send alert to Feishu chat %s (receiver %s): %v\n", chatID, r.name, err)
+			s.stats.recordSendFailure("feishu")
+		}
+	}
+}
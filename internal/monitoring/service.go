@@ -1,38 +1,79 @@
 package monitoring
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/kudig-io/klaw/internal/chart"
+	"github.com/kudig-io/klaw/internal/config"
 	"github.com/kudig-io/klaw/internal/kubernetes"
-	"github.com/kudig-io/klaw/internal/metrics"
 	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
 	"github.com/kudig-io/klaw/internal/messaging/feishu"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/prometheus"
+	"github.com/kudig-io/klaw/internal/rules"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+const (
+	// alertsBucket 告警在存储中的bucket名
+	alertsBucket = "alerts"
+	// defaultRepeatInterval 告警持续firing时重复通知的默认间隔
+	defaultRepeatInterval = 4 * time.Hour
+	// chartRange 定期发送的监控图表覆盖的时间范围
+	chartRange = time.Hour
+	// resolvedRetention 已解决告警的保留时长
+	resolvedRetention = 24 * time.Hour
 )
 
 // Service 监控服务
 type Service struct {
-	k8sManager      *kubernetes.Manager
-	dingtalkClient   *dingtalk.Client
-	feishuClient     *feishu.Client
-	metricsCollector  *metrics.Collector
-	chartGenerator   *chart.Generator
-	alerts          map[string]*Alert
-	metricsHistory   map[string][]*metrics.ClusterMetrics
-	historyMutex    sync.RWMutex
+	k8sManager         *kubernetes.Manager
+	dingtalkClient     *dingtalk.Client
+	feishuClient       *feishu.Client
+	prometheusClient   *prometheus.Client
+	store              storage.Store
+	metricsCollector   *metrics.Collector
+	chartGenerator     *chart.Generator
+	ruleEngine         *rules.Engine
+	repeatInterval     time.Duration
+	detectors          []Detector
+	alerts             map[string]*Alert
+	alertsMutex        sync.RWMutex
+	silences           map[string]*Silence
+	maintenanceWindows []*maintenanceWindow
+	escalationPolicies []*escalationPolicy
+	dispatcher         *dispatcher
+	metricsHistory     map[string][]*metrics.ClusterMetrics
+	historyMutex       sync.RWMutex
+	stats              *selfStats
 }
 
 // NewService 创建监控服务
 func NewService(k8sManager *kubernetes.Manager) *Service {
-	return &Service{
-		k8sManager:     k8sManager,
+	s := &Service{
+		k8sManager:       k8sManager,
 		metricsCollector: metrics.NewCollector(k8sManager),
-		chartGenerator:  chart.NewGenerator(800, 600),
-		alerts:         make(map[string]*Alert),
-		metricsHistory: make(map[string][]*metrics.ClusterMetrics),
+		chartGenerator:   chart.NewGenerator(800, 600),
+		ruleEngine:       rules.NewEngine(rules.Defaults()),
+		detectors:        []Detector{NewNodeReadyDetector(), NewNodeHealthDetector(config.NodeDetectorConfig{}), NewPodFailureDetector(config.PodDetectorConfig{})},
+		repeatInterval:   defaultRepeatInterval,
+		alerts:           make(map[string]*Alert),
+		silences:         make(map[string]*Silence),
+		dispatcher:       newDispatcher(),
+		metricsHistory:   make(map[string][]*metrics.ClusterMetrics),
+		stats:            newSelfStats(),
 	}
+	s.detectors = append(s.detectors,
+		NewVolumeDetector(config.VolumeDetectorConfig{}, s.storedHistory),
+		NewCertificateDetector(config.CertificateDetectorConfig{}),
+		NewControlPlaneDetector(config.ControlPlaneDetectorConfig{}))
+	// 默认配置没有过滤条件，不会返回错误
+	events, _ := NewEventWatcher(config.EventDetectorConfig{}, s.archiveEvents)
+	s.detectors = append(s.detectors, events)
+	return s
 }
 
 // SetDingTalkClient 设置钉钉客户端
@@ -45,33 +86,174 @@ func (s *Service) SetFeishuClient(client *feishu.Client) {
 	s.feishuClient = client
 }
 
-// Start 启动监控服务
-func (s *Service) Start() {
-	fmt.Println("Monitoring service started")
+// SetPrometheusClient 设置Prometheus数据源，设置后历史查询通过range query获取
+func (s *Service) SetPrometheusClient(client *prometheus.Client) {
+	s.prometheusClient = client
+}
 
-	// 启动指标收集循环
-	go s.metricsCollectionLoop()
+// SetRules 设置告警规则，未配置规则时保留默认规则
+func (s *Service) SetRules(cfgs []config.AlertRuleConfig) error {
+	if len(cfgs) == 0 {
+		return nil
+	}
 
-	// 启动图表发送循环
-	go s.chartSendingLoop()
+	compiled, err := rules.CompileAll(cfgs)
+	if err != nil {
+		return fmt.Errorf("failed to compile alert rules: %v", err)
+	}
+	s.ruleEngine.SetRules(compiled)
+	return nil
+}
 
-	// 启动告警检查循环
-	go s.alertCheckingLoop()
+// AddDetector 添加检测器
+func (s *Service) AddDetector(detector Detector) {
+	s.detectors = append(s.detectors, detector)
 }
 
-// metricsCollectionLoop 指标收集循环
-func (s *Service) metricsCollectionLoop() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// SetDetectorsConfig 按配置重新创建内置检测器，替换同名的检测器
+func (s *Service) SetDetectorsConfig(cfg config.DetectorsConfig) error {
+	events, err := NewEventWatcher(cfg.Events, s.archiveEvents)
+	if err != nil {
+		return fmt.Errorf("failed to create event watcher: %v", err)
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			s.collectMetrics()
+	s.replaceDetector(NewNodeHealthDetector(cfg.Nodes))
+	s.replaceDetector(NewPodFailureDetector(cfg.Pods))
+	s.replaceDetector(NewVolumeDetector(cfg.Volumes, s.storedHistory))
+	s.replaceDetector(NewCertificateDetector(cfg.Certificates))
+	s.replaceDetector(NewControlPlaneDetector(cfg.ControlPlane))
+	s.replaceDetector(events)
+
+	if !cfg.Anomaly.Enabled {
+		s.removeDetector("anomaly")
+		return nil
+	}
+	anomaly, err := NewAnomalyDetector(cfg.Anomaly, s.storedHistory, s.warningEventHistory)
+	if err != nil {
+		return fmt.Errorf("failed to create anomaly detector: %v", err)
+	}
+	s.replaceDetector(anomaly)
+	return nil
+}
+
+// SetSLOs 设置服务等级目标，按多窗口燃烧率告警，列表为空时移除SLO检测器
+func (s *Service) SetSLOs(cfgs []config.SLOConfig) error {
+	if len(cfgs) == 0 {
+		s.removeDetector("slo")
+		return nil
+	}
+	slos, err := NewSLODetector(cfgs, s.storedHistory)
+	if err != nil {
+		return fmt.Errorf("failed to create slo detector: %v", err)
+	}
+	s.replaceDetector(slos)
+	return nil
+}
+
+// replaceDetector 替换同名的检测器，不存在时添加。被替换的检测器有后台任务时将其停止
+func (s *Service) replaceDetector(detector Detector) {
+	for i, existing := range s.detectors {
+		if existing.Name() == detector.Name() {
+			if stopper, ok := existing.(interface{ Stop() }); ok {
+				stopper.Stop()
+			}
+			s.detectors[i] = detector
+			return
+		}
+	}
+	s.detectors = append(s.detectors, detector)
+}
+
+// removeDetector 移除同名的检测器
+func (s *Service) removeDetector(name string) {
+	for i, existing := range s.detectors {
+		if existing.Name() == name {
+			s.detectors = append(s.detectors[:i], s.detectors[i+1:]...)
+			return
 		}
 	}
 }
 
+// SetRepeatInterval 设置告警持续firing时重复通知的间隔
+func (s *Service) SetRepeatInterval(interval time.Duration) {
+	if interval > 0 {
+		s.repeatInterval = interval
+	}
+}
+
+// SetStore 设置持久化存储，并从中恢复告警和最近的指标历史
+func (s *Service) SetStore(store storage.Store) error {
+	s.store = store
+
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+
+	err := store.List(alertsBucket, func(key string, data []byte) error {
+		var alert Alert
+		if err := json.Unmarshal(data, &alert); err != nil {
+			return fmt.Errorf("failed to unmarshal alert %s: %v", key, err)
+		}
+		if alert.Status == "" {
+			alert.Status = AlertStatusFiring
+			if alert.Resolved {
+				alert.Status = AlertStatusResolved
+			}
+		}
+		s.alerts[alert.ID] = &alert
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load alerts: %v", err)
+	}
+
+	err = store.List(silencesBucket, func(key string, data []byte) error {
+		var silence Silence
+		if err := json.Unmarshal(data, &silence); err != nil {
+			return fmt.Errorf("failed to unmarshal silence %s: %v", key, err)
+		}
+		s.silences[silence.ID] = &silence
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load silences: %v", err)
+	}
+
+	now := time.Now()
+	s.historyMutex.Lock()
+	defer s.historyMutex.Unlock()
+	for _, cluster := range s.k8sManager.GetClusters() {
+		history, err := store.QuerySamples(cluster.Name, now.Add(-time.Hour), now)
+		if err != nil {
+			return fmt.Errorf("failed to load metrics history for cluster %s: %v", cluster.Name, err)
+		}
+		if len(history) > 100 {
+			history = history[len(history)-100:]
+		}
+		s.metricsHistory[cluster.Name] = history
+	}
+
+	return nil
+}
+
+// Start 启动监控服务
+func (s *Service) Start() {
+	fmt.Println("Monitoring service started")
+
+	// 启动存储维护循环
+	if s.store != nil {
+		go s.storageMaintenanceLoop()
+	}
+
+	// 启动监控流水线
+	go s.pipelineLoop()
+
+	// 启动告警通知分组发送循环
+	go s.notificationLoop()
+
+	// 启动图表发送循环
+	go s.chartSendingLoop()
+}
+
 // chartSendingLoop 图表发送循环
 func (s *Service) chartSendingLoop() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -85,61 +267,76 @@ func (s *Service) chartSendingLoop() {
 	}
 }
 
-// alertCheckingLoop 告警检查循环
-func (s *Service) alertCheckingLoop() {
-	ticker := time.NewTicker(10 * time.Second)
+// storageMaintenanceLoop 存储维护循环，定期降采样并清理过期数据
+func (s *Service) storageMaintenanceLoop() {
+	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			s.checkAlerts()
+			if err := s.store.Compact(time.Now()); err != nil {
+				fmt.Printf("Failed to compact storage: %v\n", err)
+			}
 		}
 	}
 }
 
-// collectMetrics 收集指标
-func (s *Service) collectMetrics() {
-	clusters := s.k8sManager.GetClusters()
+// collectMetrics 收集集群指标并保存到指标历史
+func (s *Service) collectMetrics(clusterName string) (*metrics.ClusterMetrics, error) {
+	start := time.Now()
+	clusterMetrics, err := s.metricsCollector.CollectClusterMetrics(clusterName)
+	s.stats.observeCollection(clusterName, time.Since(start), err)
+	if err != nil {
+		fmt.Printf("Failed to collect metrics for cluster %s: %v\n", clusterName, err)
+		return nil, err
+	}
 
-	for _, cluster := range clusters {
-		metrics, err := s.metricsCollector.CollectClusterMetrics(cluster.Name)
-		if err != nil {
-			fmt.Printf("Failed to collect metrics for cluster %s: %v\n", cluster.Name, err)
-			continue
-		}
+	// 保存指标历史
+	s.historyMutex.Lock()
+	if _, ok := s.metricsHistory[clusterName]; !ok {
+		s.metricsHistory[clusterName] = make([]*metrics.ClusterMetrics, 0, 100)
+	}
+	s.metricsHistory[clusterName] = append(s.metricsHistory[clusterName], clusterMetrics)
+	if len(s.metricsHistory[clusterName]) > 100 {
+		s.metricsHistory[clusterName] = s.metricsHistory[clusterName][1:]
+	}
+	s.historyMutex.Unlock()
 
-		// 保存指标历史
-		s.historyMutex.Lock()
-		if _, ok := s.metricsHistory[cluster.Name]; !ok {
-			s.metricsHistory[cluster.Name] = make([]*metrics.ClusterMetrics, 0, 100)
+	if s.store != nil {
+		if err := s.store.AppendSample(clusterMetrics); err != nil {
+			fmt.Printf("Failed to persist metrics for cluster %s: %v\n", clusterName, err)
 		}
-		s.metricsHistory[cluster.Name] = append(s.metricsHistory[cluster.Name], metrics)
-		if len(s.metricsHistory[cluster.Name]) > 100 {
-			s.metricsHistory[cluster.Name] = s.metricsHistory[cluster.Name][1:]
-		}
-		s.historyMutex.Unlock()
-
-		fmt.Printf("Collected metrics for cluster %s: %d nodes, %d pods\n",
-			cluster.Name, metrics.Nodes.Total, metrics.Pods.Total)
 	}
+
+	fmt.Printf("Collected metrics for cluster %s: %d nodes, %d pods\n",
+		clusterName, clusterMetrics.Nodes.Total, clusterMetrics.Pods.Total)
+	return clusterMetrics, nil
 }
 
-// sendCharts 发送图表
+// sendCharts 发送最近一段时间的集群监控图表
 func (s *Service) sendCharts() {
 	s.historyMutex.RLock()
-	defer s.historyMutex.RUnlock()
+	clusterNames := make([]string, 0, len(s.metricsHistory))
+	for clusterName := range s.metricsHistory {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	s.historyMutex.RUnlock()
 
-	for clusterName, history := range s.metricsHistory {
+	to := time.Now()
+	from := to.Add(-chartRange)
+	for _, clusterName := range clusterNames {
+		history, err := s.GetMetricsHistoryRange(clusterName, from, to, chart.DefaultStep(from, to))
+		if err != nil {
+			fmt.Printf("Failed to query metrics history for cluster %s: %v\n", clusterName, err)
+			continue
+		}
 		if len(history) == 0 {
 			continue
 		}
 
-		// 获取最新的指标
-		latestMetrics := history[len(history)-1]
-
 		// 生成集群监控图表
-		chartData, err := s.chartGenerator.GenerateClusterMetricsChart(latestMetrics)
+		chartData, err := s.chartGenerator.GenerateClusterMetricsChart(clusterName, history, from, to)
 		if err != nil {
 			fmt.Printf("Failed to generate chart for cluster %s: %v\n", clusterName, err)
 			continue
@@ -149,6 +346,7 @@ func (s *Service) sendCharts() {
 		if s.dingtalkClient != nil {
 			if err := s.dingtalkClient.SendChart(chartData, fmt.Sprintf("集群监控 - %s", clusterName)); err != nil {
 				fmt.Printf("Failed to send chart to DingTalk: %v\n", err)
+				s.stats.recordSendFailure("dingtalk")
 			}
 		}
 
@@ -156,6 +354,7 @@ func (s *Service) sendCharts() {
 		if s.feishuClient != nil {
 			if err := s.feishuClient.SendChart(chartData, fmt.Sprintf("集群监控 - %s", clusterName)); err != nil {
 				fmt.Printf("Failed to send chart to Feishu: %v\n", err)
+				s.stats.recordSendFailure("feishu")
 			}
 		}
 
@@ -163,127 +362,176 @@ func (s *Service) sendCharts() {
 	}
 }
 
-// checkAlerts 检查告警
-func (s *Service) checkAlerts() {
+// GetRuleStates 获取告警规则及其当前命中状态
+func (s *Service) GetRuleStates() []rules.RuleState {
+	return s.ruleEngine.States()
+}
+
+// GetMetricsHistory 获取指标历史
+func (s *Service) GetMetricsHistory(clusterName string) []*metrics.ClusterMetrics {
 	s.historyMutex.RLock()
 	defer s.historyMutex.RUnlock()
 
-	for clusterName, history := range s.metricsHistory {
-		if len(history) == 0 {
-			continue
-		}
+	if history, ok := s.metricsHistory[clusterName]; ok {
+		return history
+	}
+	return nil
+}
 
-		latestMetrics := history[len(history)-1]
+// GetMetricsHistoryRange 获取指定时间范围内的指标历史
+// 数据源优先级为Prometheus、持久化存储、内存历史
+func (s *Service) GetMetricsHistoryRange(clusterName string, from, to time.Time, step time.Duration) ([]*metrics.ClusterMetrics, error) {
+	if s.prometheusClient != nil {
+		return s.prometheusClient.QueryClusterHistory(clusterName, from, to, step)
+	}
 
-		// 检查节点状态
-		if latestMetrics.Nodes.NotReady > 0 {
-			s.createAlert(clusterName, "node", "warning",
-				fmt.Sprintf("%d nodes are not ready", latestMetrics.Nodes.NotReady))
-		}
+	if s.store != nil {
+		return s.store.QuerySamples(clusterName, from, to)
+	}
 
-		// 检查Pod状态
-		if latestMetrics.Pods.Failed > 0 {
-			s.createAlert(clusterName, "pod", "critical",
-				fmt.Sprintf("%d pods have failed", latestMetrics.Pods.Failed))
+	var history []*metrics.ClusterMetrics
+	for _, m := range s.GetMetricsHistory(clusterName) {
+		if !m.Timestamp.Before(from) && !m.Timestamp.After(to) {
+			history = append(history, m)
 		}
+	}
+	return history, nil
+}
 
-		if latestMetrics.Pods.Pending > 10 {
-			s.createAlert(clusterName, "pod", "warning",
-				fmt.Sprintf("%d pods are pending", latestMetrics.Pods.Pending))
+// GetCertificates 获取所有集群最近一次扫描到的TLS证书，按过期时间排序，within大于0时只返回在该时长内过期的证书
+func (s *Service) GetCertificates(within time.Duration) []CertificateInfo {
+	for _, detector := range s.detectors {
+		if certificates, ok := detector.(*CertificateDetector); ok {
+			return certificates.Certificates(within)
 		}
 	}
+	return nil
 }
 
-// createAlert 创建告警
-func (s *Service) createAlert(clusterName, alertType, level, message string) {
-	alertID := fmt.Sprintf("%s-%s-%s", clusterName, alertType, time.Now().Format("20060102150405"))
+// GetSLOStatus 获取所有SLO的达成率、剩余错误预算和燃烧率，未配置SLO时返回nil
+func (s *Service) GetSLOStatus() []SLOStatus {
+	for _, detector := range s.detectors {
+		if slos, ok := detector.(*SLODetector); ok {
+			return slos.Status()
+		}
+	}
+	return nil
+}
 
-	// 检查是否已经存在相同的告警
-	if _, ok := s.alerts[alertID]; ok {
-		return
+// GetEventAggregates 获取按涉及对象和原因聚合的Warning事件，clusterName为空时返回所有集群
+func (s *Service) GetEventAggregates(clusterName string) []EventAggregate {
+	if events := s.eventWatcher(); events != nil {
+		return events.Aggregates(clusterName)
 	}
+	return nil
+}
 
-	alert := &Alert{
-		ID:        alertID,
-		Cluster:   clusterName,
-		Type:      alertType,
-		Level:     level,
-		Message:   message,
-		CreatedAt: time.Now(),
-		Resolved:  false,
+// SubscribeEvents 订阅所有集群实时的Warning事件，返回的函数用于取消订阅
+func (s *Service) SubscribeEvents() (<-chan ClusterEvent, func()) {
+	if events := s.eventWatcher(); events != nil {
+		return events.Subscribe()
 	}
-	s.alerts[alertID] = alert
+	ch := make(chan ClusterEvent)
+	return ch, func() {}
+}
 
-	// 发送告警通知
-	s.sendAlert(alert)
+// SearchEvents 查询归档的集群事件，按时间倒序
+func (s *Service) SearchEvents(query storage.EventQuery) ([]storage.EventRecord, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not enabled")
+	}
+	return s.store.QueryEvents(query)
 }
 
-// sendAlert 发送告警
-func (s *Service) sendAlert(alert *Alert) {
-	message := fmt.Sprintf("[Kubernetes Alert] %s - %s\nCluster: %s\nLevel: %s\nMessage: %s\nTime: %s",
-		alert.Type, alert.ID, alert.Cluster, alert.Level, alert.Message, alert.CreatedAt.Format("2006-01-02 15:04:05"))
+// archiveEvents 将事件监听器观察到的事件写入持久化存储，未启用存储时丢弃
+func (s *Service) archiveEvents(events []ClusterEvent) error {
+	if s.store == nil {
+		return nil
+	}
 
-	// 发送到钉钉
-	if s.dingtalkClient != nil {
-		if err := s.dingtalkClient.SendMessage(message); err != nil {
-			fmt.Printf("Failed to send alert to DingTalk: %v\n", err)
-		}
+	records := make([]storage.EventRecord, 0, len(events))
+	for _, e := range events {
+		records = append(records, storage.EventRecord{
+			Cluster:   e.Cluster,
+			Namespace: e.Namespace,
+			Kind:      e.Kind,
+			Name:      e.Name,
+			Type:      e.Type,
+			Reason:    e.Reason,
+			Message:   e.Message,
+			Source:    e.Source,
+			Count:     e.Count,
+			FirstSeen: e.FirstSeen,
+			LastSeen:  e.LastSeen,
+			UID:       e.UID,
+		})
 	}
+	return s.store.AppendEvents(records)
+}
 
-	// 发送到飞书
-	if s.feishuClient != nil {
-		if err := s.feishuClient.SendMessage(message); err != nil {
-			fmt.Printf("Failed to send alert to Feishu: %v\n", err)
+// eventWatcher 返回已注册的事件监听器
+func (s *Service) eventWatcher() *EventWatcher {
+	for _, detector := range s.detectors {
+		if events, ok := detector.(*EventWatcher); ok {
+			return events
 		}
 	}
+	return nil
 }
 
-// GetMetricsHistory 获取指标历史
-func (s *Service) GetMetricsHistory(clusterName string) []*metrics.ClusterMetrics {
-	s.historyMutex.RLock()
-	defer s.historyMutex.RUnlock()
+// storedHistory 查询本服务采集的指标历史，供卷检测、异常检测和SLO计算使用。Prometheus中没有kubelet卷统计，
+// 因此只使用持久化存储，并补上内存历史中尚未写入存储汇总层级的最新采样
+func (s *Service) storedHistory(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+	var history []*metrics.ClusterMetrics
+	if s.store != nil {
+		samples, err := s.store.QuerySamples(clusterName, from, to)
+		if err != nil {
+			return nil, err
+		}
+		history = samples
+	}
 
-	if history, ok := s.metricsHistory[clusterName]; ok {
-		return history
+	for _, m := range s.GetMetricsHistory(clusterName) {
+		if m.Timestamp.Before(from) || m.Timestamp.After(to) {
+			continue
+		}
+		if len(history) == 0 || m.Timestamp.After(history[len(history)-1].Timestamp) {
+			history = append(history, m)
+		}
 	}
-	return nil
+	return history, nil
 }
 
-// GetAlerts 获取所有告警
-func (s *Service) GetAlerts() []*Alert {
-	var alerts []*Alert
-	for _, alert := range s.alerts {
-		alerts = append(alerts, alert)
+// warningEventHistory 查询归档的Warning事件每次计数变化的记录，供异常检测计算事件速率，未启用存储时没有数据
+func (s *Service) warningEventHistory(clusterName string, from, to time.Time) ([]storage.EventRecord, error) {
+	if s.store == nil {
+		return nil, nil
 	}
-	return alerts
+	return s.store.QueryEvents(storage.EventQuery{Cluster: clusterName, Type: "Warning", From: from, To: to, AllChanges: true})
 }
 
-// ResolveAlert 解决告警
-func (s *Service) ResolveAlert(alertID string) error {
-	alert, ok := s.alerts[alertID]
-	if !ok {
-		return fmt.Errorf("alert not found: %s", alertID)
+// RecordAudit 记录审计日志，未配置持久化存储时忽略
+func (s *Service) RecordAudit(actor, action, target, detail string) {
+	if s.store == nil {
+		return
 	}
 
-	alert.Resolved = true
-
-	// 发送解决通知
-	message := fmt.Sprintf("[Kubernetes Alert Resolved] %s - %s\nCluster: %s\nLevel: %s\nMessage: %s\nTime: %s",
-		alert.Type, alert.ID, alert.Cluster, alert.Level, alert.Message, time.Now().Format("2006-01-02 15:04:05"))
-
-	// 发送到钉钉
-	if s.dingtalkClient != nil {
-		if err := s.dingtalkClient.SendMessage(message); err != nil {
-			fmt.Printf("Failed to send resolved alert to DingTalk: %v\n", err)
-		}
+	entry := storage.AuditEntry{
+		Time:   time.Now(),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Detail: detail,
 	}
-
-	// 发送到飞书
-	if s.feishuClient != nil {
-		if err := s.feishuClient.SendMessage(message); err != nil {
-			fmt.Printf("Failed to send resolved alert to Feishu: %v\n", err)
-		}
+	if err := s.store.AppendAudit(entry); err != nil {
+		fmt.Printf("Failed to record audit entry: %v\n", err)
 	}
+}
 
-	return nil
+// GetAuditLog 查询审计日志
+func (s *Service) GetAuditLog(from, to time.Time) ([]storage.AuditEntry, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not enabled")
+	}
+	return s.store.QueryAudit(from, to)
 }
@@ -0,0 +1,293 @@
+package monitoring
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+// silencesBucket 静默规则在存储中的bucket名
+const silencesBucket = "silences"
+
+// Matcher 告警匹配条件，所有非空条件都满足时匹配
+type Matcher struct {
+	Cluster   string            `json:"cluster,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Node      string            `json:"node,omitempty"`
+	Rule      string            `json:"rule,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Empty 判断是否没有任何匹配条件
+func (m Matcher) Empty() bool {
+	return m.Cluster == "" && m.Namespace == "" && m.Node == "" && m.Rule == "" && len(m.Labels) == 0
+}
+
+// Matches 判断告警是否满足匹配条件
+func (m Matcher) Matches(alert *Alert) bool {
+	if m.Cluster != "" && m.Cluster != alert.Cluster {
+		return false
+	}
+	if m.Namespace != "" && m.Namespace != alert.Namespace && m.Namespace != alert.Labels["namespace"] {
+		return false
+	}
+	if m.Node != "" && m.Node != alert.Labels["node"] {
+		return false
+	}
+	if m.Rule != "" && m.Rule != alert.Type {
+		return false
+	}
+	for k, v := range m.Labels {
+		if alert.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// String 以 key=value 形式描述匹配条件
+func (m Matcher) String() string {
+	var parts []string
+	for _, field := range []struct{ key, value string }{
+		{"cluster", m.Cluster}, {"namespace", m.Namespace}, {"node", m.Node}, {"rule", m.Rule},
+	} {
+		if field.value != "" {
+			parts = append(parts, field.key+"="+field.value)
+		}
+	}
+	if labels := formatLabels(m.Labels); labels != "" {
+		parts = append(parts, labels)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matcherFromConfig 将配置中的匹配条件转换为Matcher
+func matcherFromConfig(cfg config.AlertMatcherConfig) Matcher {
+	return Matcher{
+		Cluster:   cfg.Cluster,
+		Namespace: cfg.Namespace,
+		Node:      cfg.Node,
+		Rule:      cfg.Rule,
+		Labels:    cfg.Labels,
+	}
+}
+
+// Silence 静默规则，生效期间匹配的告警照常记录但不发送通知
+type Silence struct {
+	ID string `json:"id"`
+	Matcher
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Active 判断静默规则在指定时间是否生效
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// maintenanceWindow 解析后的周期性维护窗口
+type maintenanceWindow struct {
+	name     string
+	days     map[time.Weekday]bool
+	start    time.Duration
+	duration time.Duration
+	location *time.Location
+	matcher  Matcher
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseMaintenanceWindow 解析维护窗口配置
+func parseMaintenanceWindow(cfg config.MaintenanceWindowConfig) (*maintenanceWindow, error) {
+	if cfg.Duration <= 0 {
+		return nil, fmt.Errorf("maintenance window %s: duration must be positive", cfg.Name)
+	}
+
+	window := &maintenanceWindow{
+		name:     cfg.Name,
+		days:     make(map[time.Weekday]bool),
+		duration: cfg.Duration,
+		location: time.Local,
+		matcher:  matcherFromConfig(cfg.Match),
+	}
+
+	for _, day := range cfg.Days {
+		key := strings.ToLower(day)
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		weekday, ok := weekdays[key]
+		if !ok {
+			return nil, fmt.Errorf("maintenance window %s: invalid day: %s", cfg.Name, day)
+		}
+		window.days[weekday] = true
+	}
+
+	hour, minute, ok := strings.Cut(cfg.Start, ":")
+	h, errH := strconv.Atoi(hour)
+	m, errM := strconv.Atoi(minute)
+	if !ok || errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return nil, fmt.Errorf("maintenance window %s: invalid start time: %s", cfg.Name, cfg.Start)
+	}
+	window.start = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+
+	if cfg.Timezone != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %s: invalid timezone: %v", cfg.Name, err)
+		}
+		window.location = location
+	}
+
+	return window, nil
+}
+
+// Active 判断维护窗口在指定时间是否生效，窗口可以跨越午夜
+func (w *maintenanceWindow) Active(now time.Time) bool {
+	local := now.In(w.location)
+	for offset := 0; offset <= int(w.duration/(24*time.Hour))+1; offset++ {
+		day := local.AddDate(0, 0, -offset)
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, w.location).Add(w.start)
+		if len(w.days) > 0 && !w.days[start.Weekday()] {
+			continue
+		}
+		if !local.Before(start) && local.Before(start.Add(w.duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaintenanceWindows 设置周期性维护窗口
+func (s *Service) SetMaintenanceWindows(cfgs []config.MaintenanceWindowConfig) error {
+	windows := make([]*maintenanceWindow, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		window, err := parseMaintenanceWindow(cfg)
+		if err != nil {
+			return err
+		}
+		windows = append(windows, window)
+	}
+
+	s.alertsMutex.Lock()
+	s.maintenanceWindows = windows
+	s.alertsMutex.Unlock()
+	return nil
+}
+
+// CreateSilence 创建静默规则
+func (s *Service) CreateSilence(silence Silence) (*Silence, error) {
+	if silence.Matcher.Empty() {
+		return nil, fmt.Errorf("silence requires at least one matcher")
+	}
+	if silence.CreatedBy == "" {
+		return nil, fmt.Errorf("silence requires a creator")
+	}
+
+	now := time.Now()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if !silence.EndsAt.After(silence.StartsAt) || !silence.EndsAt.After(now) {
+		return nil, fmt.Errorf("silence must end in the future and after it starts")
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate silence id: %v", err)
+	}
+	silence.ID = hex.EncodeToString(id)
+	silence.CreatedAt = now
+
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+
+	s.silences[silence.ID] = &silence
+	s.persistSilence(&silence)
+
+	copied := silence
+	return &copied, nil
+}
+
+// ExpireSilence 使静默规则立即失效
+func (s *Service) ExpireSilence(id string) error {
+	s.alertsMutex.Lock()
+	defer s.alertsMutex.Unlock()
+
+	silence, ok := s.silences[id]
+	if !ok {
+		return fmt.Errorf("silence not found: %s", id)
+	}
+
+	now := time.Now()
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+		s.persistSilence(silence)
+	}
+	return nil
+}
+
+// GetSilences 获取所有静默规则，按创建时间排序
+func (s *Service) GetSilences() []*Silence {
+	s.alertsMutex.RLock()
+	defer s.alertsMutex.RUnlock()
+
+	silences := make([]*Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		copied := *silence
+		silences = append(silences, &copied)
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].CreatedAt.Before(silences[j].CreatedAt) })
+	return silences
+}
+
+// suppressedBy 返回抑制告警通知的静默规则或维护窗口，为空表示不抑制。调用方需持有alertsMutex
+func (s *Service) suppressedBy(alert *Alert, now time.Time) string {
+	for _, silence := range s.silences {
+		if silence.Active(now) && silence.Matches(alert) {
+			return "silence:" + silence.ID
+		}
+	}
+	for _, window := range s.maintenanceWindows {
+		if window.Active(now) && window.matcher.Matches(alert) {
+			return "maintenance:" + window.name
+		}
+	}
+	return ""
+}
+
+// pruneExpiredSilences 清理失效超过保留时长的静默规则。调用方需持有alertsMutex
+func (s *Service) pruneExpiredSilences(now time.Time) {
+	for id, silence := range s.silences {
+		if now.Sub(silence.EndsAt) > resolvedRetention {
+			delete(s.silences, id)
+			if s.store != nil {
+				if err := s.store.Delete(silencesBucket, id); err != nil {
+					fmt.Printf("Failed to delete silence %s: %v\n", id, err)
+				}
+			}
+		}
+	}
+}
+
+// persistSilence 持久化静默规则
+func (s *Service) persistSilence(silence *Silence) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(silencesBucket, silence.ID, silence); err != nil {
+		fmt.Printf("Failed to persist silence %s: %v\n", silence.ID, err)
+	}
+}
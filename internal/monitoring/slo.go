@@ -0,0 +1,361 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// defaultSLOWindow SLO默认的统计窗口
+const defaultSLOWindow = 30 * 24 * time.Hour
+
+// defaultSampleInterval 历史中只有一个原始采样、无法估计采集间隔时使用的采样覆盖时长
+const defaultSampleInterval = time.Minute
+
+// sloBurnWindow 多窗口燃烧率告警的一组窗口，长窗口和短窗口的燃烧率都达到阈值时告警。
+// 阈值为在长窗口内消耗budget比例的错误预算对应的燃烧率，30天窗口下依次为14.4、6、3和1
+type sloBurnWindow struct {
+	long   time.Duration
+	short  time.Duration
+	budget float64
+	level  string
+}
+
+// sloBurnWindows 按告警紧急程度排列的燃烧率窗口
+var sloBurnWindows = []sloBurnWindow{
+	{long: time.Hour, short: 5 * time.Minute, budget: 0.02, level: "critical"},
+	{long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05, level: "critical"},
+	{long: 24 * time.Hour, short: 2 * time.Hour, budget: 0.10, level: "warning"},
+	{long: 72 * time.Hour, short: 6 * time.Hour, budget: 0.10, level: "warning"},
+}
+
+// slo 解析后的服务等级目标
+type slo struct {
+	name      string
+	cluster   string
+	namespace string
+	kind      string
+	workload  string
+	minReady  int
+	// objective 目标达成率，0到1之间
+	objective float64
+	window    time.Duration
+}
+
+// SLOBurnRate 一组窗口的错误预算燃烧率，燃烧率为1表示恰好在SLO窗口结束时耗尽错误预算
+type SLOBurnRate struct {
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	LongRate    float64 `json:"long_rate"`
+	ShortRate   float64 `json:"short_rate"`
+	Threshold   float64 `json:"threshold"`
+	Level       string  `json:"level"`
+	// Firing 长短窗口的燃烧率都达到阈值
+	Firing bool `json:"firing"`
+}
+
+// SLOStatus SLO的达成情况
+type SLOStatus struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Workload  string `json:"workload"`
+	MinReady  int    `json:"min_ready"`
+	// Objective 和 Compliance 为百分比
+	Objective  float64 `json:"objective"`
+	Window     string  `json:"window"`
+	Compliance float64 `json:"compliance"`
+	// ErrorBudgetRemaining 剩余错误预算的百分比，超支时为负数
+	ErrorBudgetRemaining float64       `json:"error_budget_remaining"`
+	Samples              int           `json:"samples"`
+	BurnRates            []SLOBurnRate `json:"burn_rates"`
+	Error                string        `json:"error,omitempty"`
+}
+
+// SLODetector SLO检测器，根据存储的指标采样计算工作负载就绪副本数SLO的达成率和错误预算，
+// 并按多窗口燃烧率告警
+type SLODetector struct {
+	slos    []slo
+	history MetricsHistory
+	// now 返回当前时间，测试中可替换为固定时间
+	now func() time.Time
+}
+
+// NewSLODetector 创建SLO检测器
+func NewSLODetector(cfgs []config.SLOConfig, history MetricsHistory) (*SLODetector, error) {
+	d := &SLODetector{history: history, now: time.Now}
+	names := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if cfg.Name == "" || cfg.Cluster == "" || cfg.Namespace == "" || cfg.Workload == "" {
+			return nil, fmt.Errorf("slo requires name, cluster, namespace and workload")
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("duplicate slo name: %s", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if cfg.Objective <= 0 || cfg.Objective >= 100 {
+			return nil, fmt.Errorf("slo %s objective must be between 0 and 100, got %v", cfg.Name, cfg.Objective)
+		}
+
+		s := slo{
+			name:      cfg.Name,
+			cluster:   cfg.Cluster,
+			namespace: cfg.Namespace,
+			kind:      cfg.Kind,
+			workload:  cfg.Workload,
+			minReady:  cfg.MinReady,
+			objective: cfg.Objective / 100,
+			window:    cfg.Window,
+		}
+		if s.kind == "" {
+			s.kind = "Deployment"
+		}
+		if s.minReady <= 0 {
+			s.minReady = 1
+		}
+		if s.window <= 0 {
+			s.window = defaultSLOWindow
+		}
+		d.slos = append(d.slos, s)
+	}
+	return d, nil
+}
+
+// SetClock 设置检测器获取当前时间的函数，用于以固定时间计算SLO
+func (d *SLODetector) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// Name 检测器名称
+func (d *SLODetector) Name() string {
+	return "slo"
+}
+
+// Detect 计算集群中各SLO的燃烧率，返回最紧急的一组达到阈值的窗口
+func (d *SLODetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	statuses, err := d.evaluate(clusterName, d.now())
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, status := range statuses {
+		for _, burn := range status.BurnRates {
+			if !burn.Firing {
+				continue
+			}
+			findings = append(findings, Finding{
+				Type:  "SLOBurnRate",
+				Level: burn.Level,
+				Message: fmt.Sprintf("SLO %s (%s %s/%s at least %d ready) is burning error budget %.1fx over %s and %.1fx over %s, threshold %.1fx; %.1f%% of error budget remaining",
+					status.Name, status.Kind, status.Namespace, status.Workload, status.MinReady,
+					burn.LongRate, burn.LongWindow, burn.ShortRate, burn.ShortWindow, burn.Threshold, status.ErrorBudgetRemaining),
+				Labels: map[string]string{"slo": status.Name, "namespace": status.Namespace},
+				Annotations: map[string]string{
+					"burn_rate":              fmt.Sprintf("%.2f", burn.LongRate),
+					"window":                 burn.LongWindow,
+					"compliance":             fmt.Sprintf("%.3f", status.Compliance),
+					"error_budget_remaining": fmt.Sprintf("%.1f", status.ErrorBudgetRemaining),
+				},
+			})
+			break
+		}
+	}
+	return findings, nil
+}
+
+// Status 计算所有SLO的达成情况，按名称排序。查询失败的SLO在Error中说明
+func (d *SLODetector) Status() []SLOStatus {
+	clusters := make(map[string]bool)
+	for _, s := range d.slos {
+		clusters[s.cluster] = true
+	}
+
+	now := d.now()
+	var result []SLOStatus
+	for clusterName := range clusters {
+		statuses, err := d.evaluate(clusterName, now)
+		if err != nil {
+			for _, s := range d.slos {
+				if s.cluster == clusterName {
+					status := s.status()
+					status.Error = err.Error()
+					result = append(result, status)
+				}
+			}
+			continue
+		}
+		result = append(result, statuses...)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// evaluate 计算集群中各SLO的达成率、错误预算和燃烧率。同一时长的历史只查询一次，供集群中所有SLO共用
+func (d *SLODetector) evaluate(clusterName string, now time.Time) ([]SLOStatus, error) {
+	cache := make(map[time.Duration][]*metrics.ClusterMetrics)
+	history := func(window time.Duration) ([]*metrics.ClusterMetrics, error) {
+		if samples, ok := cache[window]; ok {
+			return samples, nil
+		}
+		samples, err := d.history(clusterName, now.Add(-window), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history: %v", err)
+		}
+		cache[window] = samples
+		return samples, nil
+	}
+
+	var statuses []SLOStatus
+	for _, s := range d.slos {
+		if s.cluster != clusterName {
+			continue
+		}
+
+		samples, err := history(s.window)
+		if err != nil {
+			return nil, err
+		}
+		status := s.status()
+		badRatio, total := s.badRatio(samples, now)
+		status.Samples = total
+		if total > 0 {
+			status.Compliance = (1 - badRatio) * 100
+			status.ErrorBudgetRemaining = (1 - badRatio/(1-s.objective)) * 100
+		}
+
+		for _, window := range sloBurnWindows {
+			if window.long > s.window {
+				continue
+			}
+			long, err := history(window.long)
+			if err != nil {
+				return nil, err
+			}
+			short, err := history(window.short)
+			if err != nil {
+				return nil, err
+			}
+			longBad, longTotal := s.badRatio(long, now)
+			shortBad, shortTotal := s.badRatio(short, now)
+
+			// 阈值按SLO窗口与长窗口的比例换算，使告警时消耗的错误预算比例与窗口长度无关
+			burn := SLOBurnRate{
+				LongWindow:  window.long.String(),
+				ShortWindow: window.short.String(),
+				LongRate:    longBad / (1 - s.objective),
+				ShortRate:   shortBad / (1 - s.objective),
+				Threshold:   window.budget * float64(s.window) / float64(window.long),
+				Level:       window.level,
+			}
+			burn.Firing = longTotal > 0 && shortTotal > 0 && burn.LongRate >= burn.Threshold && burn.ShortRate >= burn.Threshold
+			status.BurnRates = append(status.BurnRates, burn)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// status 返回只包含SLO定义的状态
+func (s *slo) status() SLOStatus {
+	return SLOStatus{
+		Name:      s.name,
+		Cluster:   s.cluster,
+		Namespace: s.namespace,
+		Kind:      s.kind,
+		Workload:  s.workload,
+		MinReady:  s.minReady,
+		Objective: s.objective * 100,
+		Window:    s.window.String(),
+	}
+}
+
+// badRatio 返回截至end的采样中就绪副本数不足的时间比例和采样数。每个采样按覆盖的时长加权：汇总采样覆盖其汇总窗口，
+// 原始采样覆盖一个采集间隔，且都不超过到下一个采样的间隔，避免汇总采样与之后的原始采样重复计算，
+// 采样之间超出覆盖时长的空白不参与计算
+func (s *slo) badRatio(samples []*metrics.ClusterMetrics, end time.Time) (float64, int) {
+	interval := rawSampleInterval(samples)
+	var bad, total float64
+	for i, sample := range samples {
+		weight := sample.Interval
+		if weight <= 0 {
+			weight = interval
+		}
+		next := end
+		if i+1 < len(samples) {
+			next = samples[i+1].Timestamp
+		}
+		if gap := next.Sub(sample.Timestamp); gap < weight {
+			weight = gap
+		}
+		if weight <= 0 {
+			continue
+		}
+		total += float64(weight)
+		bad += float64(weight) * s.badFraction(sample)
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return bad / total, len(samples)
+}
+
+// rawSampleInterval 返回相邻原始采样间隔的中位数，作为原始采样覆盖的时长
+func rawSampleInterval(samples []*metrics.ClusterMetrics) time.Duration {
+	var intervals []time.Duration
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1].Interval == 0 && samples[i].Interval == 0 {
+			intervals = append(intervals, samples[i].Timestamp.Sub(samples[i-1].Timestamp))
+		}
+	}
+	if len(intervals) == 0 {
+		return defaultSampleInterval
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	return intervals[len(intervals)/2]
+}
+
+// badFraction 返回采样覆盖的时间内就绪副本数不足的比例。汇总采样按就绪副本数的分布计算，
+// 原始采样为0或1，采样中没有该工作负载时视为没有就绪副本
+func (s *slo) badFraction(sample *metrics.ClusterMetrics) float64 {
+	pods, ok := s.workloadPods(sample)
+	if !ok {
+		return 1
+	}
+	if len(pods.ReadyFractions) == 0 {
+		if pods.Ready < s.minReady {
+			return 1
+		}
+		return 0
+	}
+
+	bad := 0.0
+	for ready, fraction := range pods.ReadyFractions {
+		if ready < s.minReady {
+			bad += fraction
+		}
+	}
+	return bad
+}
+
+// workloadPods 返回采样中工作负载的Pod数量统计
+func (s *slo) workloadPods(sample *metrics.ClusterMetrics) (metrics.PodCounts, bool) {
+	for _, ns := range sample.Namespaces {
+		if ns.Name != s.namespace {
+			continue
+		}
+		for _, workload := range ns.Workloads {
+			if workload.Kind == s.kind && workload.Name == s.workload {
+				return workload.Pods, true
+			}
+		}
+	}
+	return metrics.PodCounts{}, false
+}
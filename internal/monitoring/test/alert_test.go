@@ -0,0 +1,26 @@
+package monitoring_test
+
+import (
+	"testing"
+
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := monitoring.Fingerprint("prod", "PodsFailed", map[string]string{"namespace": "default", "team": "web"})
+	b := monitoring.Fingerprint("prod", "PodsFailed", map[string]string{"team": "web", "namespace": "default"})
+	if a != b {
+		t.Errorf("expected fingerprint to ignore label order, got %s and %s", a, b)
+	}
+
+	others := []string{
+		monitoring.Fingerprint("staging", "PodsFailed", map[string]string{"namespace": "default", "team": "web"}),
+		monitoring.Fingerprint("prod", "PodsPending", map[string]string{"namespace": "default", "team": "web"}),
+		monitoring.Fingerprint("prod", "PodsFailed", map[string]string{"namespace": "kube-system", "team": "web"}),
+	}
+	for _, other := range others {
+		if other == a {
+			t.Errorf("expected different fingerprint, got %s", other)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package monitoring_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"KubeDeploymentReplicasMismatch\"}",
+  "status": "%s",
+  "receiver": "klaw",
+  "alerts": [{
+    "status": "%s",
+    "labels": {"alertname": "KubeDeploymentReplicasMismatch", "severity": "critical", "namespace": "web", "deployment": "api"},
+    "annotations": {"summary": "Deployment web/api has not matched the expected number of replicas"},
+    "startsAt": "2024-01-01T00:00:00Z",
+    "endsAt": "0001-01-01T00:00:00Z",
+    "generatorURL": "http://prometheus:9090/graph",
+    "fingerprint": "c8e1f3a2b4d5e6f7"
+  }]
+}`
+
+func decodeAlertmanagerPayload(t *testing.T, status string) []monitoring.ExternalAlert {
+	var payload monitoring.AlertmanagerWebhook
+	body := strings.ReplaceAll(alertmanagerPayload, "%s", status)
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	alerts, err := payload.ExternalAlerts("prod")
+	if err != nil {
+		t.Fatalf("ExternalAlerts() error = %v", err)
+	}
+	return alerts
+}
+
+func TestService_IngestAlertmanagerAlerts(t *testing.T) {
+	service, recorder := newNotReadyService(t)
+
+	firing := decodeAlertmanagerPayload(t, "firing")
+	service.IngestAlerts(monitoring.SourceAlertmanager, firing)
+	service.IngestAlerts(monitoring.SourceAlertmanager, firing)
+
+	id := monitoring.Fingerprint("prod", "KubeDeploymentReplicasMismatch", map[string]string{"namespace": "web", "deployment": "api"})
+	alert, err := service.GetAlert(id)
+	if err != nil {
+		t.Fatalf("GetAlert() error = %v", err)
+	}
+	if alert.Level != "critical" || alert.Namespace != "web" || alert.Source != monitoring.SourceAlertmanager {
+		t.Errorf("unexpected alert: %+v", alert)
+	}
+	if alert.CreatedAt.Format("2006-01-02") != "2024-01-01" {
+		t.Errorf("expected alert to start at startsAt, got %v", alert.CreatedAt)
+	}
+	// 重复推送按指纹去重，只通知一次
+	if recorder.count() != 1 {
+		t.Fatalf("expected 1 notification, got %d", recorder.count())
+	}
+
+	service.IngestAlerts(monitoring.SourceAlertmanager, decodeAlertmanagerPayload(t, "resolved"))
+	alert, _ = service.GetAlert(id)
+	if alert.Status != monitoring.AlertStatusResolved {
+		t.Errorf("expected alert resolved, got %s", alert.Status)
+	}
+	if recorder.count() != 2 {
+		t.Errorf("expected resolved notification, got %d messages", recorder.count())
+	}
+}
+
+func TestAlertmanagerWebhook_UnsupportedVersion(t *testing.T) {
+	payload := monitoring.AlertmanagerWebhook{Version: "3"}
+	if _, err := payload.ExternalAlerts("prod"); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
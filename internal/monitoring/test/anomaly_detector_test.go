@@ -0,0 +1,139 @@
+package monitoring_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+// generatedHistory 生成每分钟一个采样的指标历史，pods返回各时刻的Pod总数，CPU使用率固定为50%
+func generatedHistory(pods func(t time.Time) int) monitoring.MetricsHistory {
+	return func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+		var history []*metrics.ClusterMetrics
+		for t := from.Truncate(time.Minute).Add(time.Minute); !t.After(to); t = t.Add(time.Minute) {
+			history = append(history, &metrics.ClusterMetrics{
+				ClusterName: clusterName,
+				Timestamp:   t,
+				Pods:        metrics.PodMetricsSummary{Total: pods(t)},
+				Resources:   metrics.ResourceMetrics{CPUCapacity: 1000, CPUUsage: 500},
+			})
+		}
+		return history, nil
+	}
+}
+
+// spikeAfter Pod总数在最近4分钟内为spike，之前为base
+func spikeAfter(spike int, base func(t time.Time) int) func(t time.Time) int {
+	return func(t time.Time) int {
+		if time.Since(t) < 4*time.Minute {
+			return spike
+		}
+		return base(t)
+	}
+}
+
+func anomalyBySeries(findings []monitoring.Finding) map[string]monitoring.Finding {
+	bySeries := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		bySeries[finding.Labels["series"]] = finding
+	}
+	return bySeries
+}
+
+func TestAnomalyDetector_Rolling(t *testing.T) {
+	now := time.Now()
+	// 基线中每5分钟1次Warning事件，最近4分钟内一个事件发生了30次
+	var records []storage.EventRecord
+	for i := 1; i < 24; i++ {
+		at := now.Add(-time.Duration(i) * 5 * time.Minute)
+		records = append(records, storage.EventRecord{UID: fmt.Sprintf("e%d", i), Count: 1, FirstSeen: at, LastSeen: at})
+	}
+	records = append(records, storage.EventRecord{UID: "burst", Count: 30, FirstSeen: now.Add(-3 * time.Minute), LastSeen: now.Add(-time.Minute)})
+	events := func(clusterName string, from, to time.Time) ([]storage.EventRecord, error) {
+		var result []storage.EventRecord
+		for _, record := range records {
+			if !record.LastSeen.Before(from) && !record.LastSeen.After(to) {
+				result = append(result, record)
+			}
+		}
+		return result, nil
+	}
+
+	history := generatedHistory(spikeAfter(40, func(t time.Time) int { return 10 + t.Minute()%3 }))
+	detector, err := monitoring.NewAnomalyDetector(config.AnomalyDetectorConfig{Window: 2 * time.Hour}, history, events)
+	if err != nil {
+		t.Fatalf("NewAnomalyDetector() error = %v", err)
+	}
+
+	findings, err := detector.Detect(context.Background(), "prod", nil)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	bySeries := anomalyBySeries(findings)
+	if len(bySeries) != 2 {
+		t.Fatalf("expected pod count and event rate anomalies, got %+v", findings)
+	}
+
+	pods := bySeries["pods.total"]
+	if pods.Type != "Anomaly" || pods.Annotations["method"] != "rolling" {
+		t.Errorf("unexpected pod anomaly: %+v", pods)
+	}
+	observed, _ := strconv.ParseFloat(pods.Annotations["observed"], 64)
+	expectedMax, _ := strconv.ParseFloat(pods.Annotations["expected_max"], 64)
+	if observed <= expectedMax || pods.Annotations["expected_min"] == "" {
+		t.Errorf("expected observed value above the expected range: %v", pods.Annotations)
+	}
+	if events := bySeries["events.warning_rate"]; events.Annotations["observed"] != "30" {
+		t.Errorf("unexpected event rate anomaly: %+v", events)
+	}
+}
+
+func TestAnomalyDetector_Seasonal(t *testing.T) {
+	// 之前各周同一时刻的Pod总数分别为10、12、14
+	weekly := func(t time.Time) int {
+		return 10 + 2*int(time.Since(t)/(7*24*time.Hour)-1)
+	}
+	cfg := config.AnomalyDetectorConfig{
+		Method: "seasonal",
+		Weeks:  3,
+		Series: []config.AnomalySeriesConfig{{Field: "pods.total"}, {Field: "cpu.usage_percent"}},
+	}
+
+	detector, err := monitoring.NewAnomalyDetector(cfg, generatedHistory(spikeAfter(40, weekly)), nil)
+	if err != nil {
+		t.Fatalf("NewAnomalyDetector() error = %v", err)
+	}
+	findings, err := detector.Detect(context.Background(), "prod", nil)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Labels["series"] != "pods.total" || findings[0].Annotations["method"] != "seasonal" || findings[0].Annotations["mean"] != "12" {
+		t.Fatalf("expected seasonal pod count anomaly, got %+v", findings)
+	}
+
+	// 当前值在之前各周的范围内时不告警
+	detector, err = monitoring.NewAnomalyDetector(cfg, generatedHistory(spikeAfter(13, weekly)), nil)
+	if err != nil {
+		t.Fatalf("NewAnomalyDetector() error = %v", err)
+	}
+	if findings, _ := detector.Detect(context.Background(), "prod", nil); len(findings) != 0 {
+		t.Errorf("expected no anomaly within the seasonal range, got %+v", findings)
+	}
+}
+
+func TestAnomalyDetector_InvalidConfig(t *testing.T) {
+	history := generatedHistory(func(time.Time) int { return 0 })
+	if _, err := monitoring.NewAnomalyDetector(config.AnomalyDetectorConfig{Series: []config.AnomalySeriesConfig{{Field: "unknown"}}}, history, nil); err == nil {
+		t.Error("expected error for unknown series field")
+	}
+	if _, err := monitoring.NewAnomalyDetector(config.AnomalyDetectorConfig{Method: "median"}, history, nil); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
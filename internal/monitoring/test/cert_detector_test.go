@@ -0,0 +1,155 @@
+package monitoring_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// tlsSecret 创建包含自签名证书的TLS Secret，证书在notAfter过期
+func tlsSecret(t *testing.T, name string, notAfter time.Time) *corev1.Secret {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name + ".example.com"},
+		DNSNames:     []string{name + ".example.com"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "web"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+func TestCertificateDetector(t *testing.T) {
+	now := time.Now()
+	invalid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "web"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a certificate")},
+	}
+	client := fake.NewSimpleClientset(
+		tlsSecret(t, "expired", now.Add(-time.Hour)),
+		tlsSecret(t, "soon", now.Add(3*24*time.Hour+time.Hour)),
+		tlsSecret(t, "later", now.Add(20*24*time.Hour+time.Hour)),
+		tlsSecret(t, "fine", now.Add(200*24*time.Hour)),
+		invalid,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "web"}, Type: corev1.SecretTypeOpaque},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"},
+			Spec:       networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{{SecretName: "soon"}}},
+		},
+	)
+
+	detector := monitoring.NewCertificateDetector(config.CertificateDetectorConfig{})
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	bySecret := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		bySecret[finding.Labels["secret"]] = finding
+	}
+	expected := map[string]struct{ alertType, level string }{
+		"expired": {"CertificateExpired", "critical"},
+		"soon":    {"CertificateExpiring", "critical"},
+		"later":   {"CertificateExpiring", "warning"},
+		"broken":  {"CertificateInvalid", "warning"},
+	}
+	if len(bySecret) != len(expected) {
+		t.Errorf("expected %d findings, got %+v", len(expected), findings)
+	}
+	for secret, want := range expected {
+		finding := bySecret[secret]
+		if finding.Type != want.alertType || finding.Level != want.level {
+			t.Errorf("secret %s: expected %s/%s, got %s/%s", secret, want.alertType, want.level, finding.Type, finding.Level)
+		}
+	}
+	if soon := bySecret["soon"]; soon.Annotations["ingresses"] != "shop" || soon.Annotations["days_remaining"] != "3" {
+		t.Errorf("unexpected annotations: %v", soon.Annotations)
+	}
+
+	// 列表按过期时间排序，只包含30天内过期的证书
+	certificates := detector.Certificates(30 * 24 * time.Hour)
+	var names []string
+	for _, cert := range certificates {
+		names = append(names, cert.Secret)
+	}
+	if len(names) != 3 || names[0] != "expired" || names[1] != "soon" || names[2] != "later" {
+		t.Errorf("unexpected expiring certificates: %v", names)
+	}
+	if all := detector.Certificates(0); len(all) != 5 {
+		t.Errorf("expected 5 certificates without a deadline, got %d", len(all))
+	}
+}
+
+func TestCertificateDetector_ScanInterval(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	client := fake.NewSimpleClientset(tlsSecret(t, "soon", now.Add(3*24*time.Hour+time.Hour)))
+	listSecrets := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" && action.GetResource().Resource == "secrets" {
+				count++
+			}
+		}
+		return count
+	}
+
+	detector := monitoring.NewCertificateDetector(config.CertificateDetectorConfig{ScanInterval: 200 * time.Millisecond})
+	if findings, err := detector.Detect(ctx, "prod", client); err != nil || len(findings) != 1 {
+		t.Fatalf("Detect() = %+v, %v, want 1 finding", findings, err)
+	}
+
+	// 扫描间隔内使用缓存的证书，不重新列出Secret
+	if _, err := client.CoreV1().Secrets("web").Create(ctx, tlsSecret(t, "expired", now.Add(-time.Hour)), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create secret: %v", err)
+	}
+	findings, err := detector.Detect(ctx, "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Labels["secret"] != "soon" || listSecrets() != 1 {
+		t.Errorf("expected cached scan with 1 finding and 1 list, got %+v after %d lists", findings, listSecrets())
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	findings, err = detector.Detect(ctx, "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 2 || listSecrets() != 2 {
+		t.Errorf("expected a new scan after the interval, got %+v after %d lists", findings, listSecrets())
+	}
+}
@@ -0,0 +1,142 @@
+package monitoring_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// newControlPlaneServer 模拟etcd检查失败、CoreDNS副本未全部就绪且没有kube-proxy的API Server，
+// podListStatus为列出kube-system中Pod时返回的状态码
+func newControlPlaneServer(t *testing.T, podListStatus int) k8sclient.Interface {
+	t.Helper()
+
+	replicas := int32(2)
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("[+]ping ok\n[-]etcd failed: reason withheld\n[+]informer-sync ok\nreadyz check failed\n"))
+	})
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[+]ping ok\n[+]log ok\nlivez check passed\n"))
+	})
+	mux.HandleFunc("/api/v1/namespaces/kube-system/pods", func(w http.ResponseWriter, r *http.Request) {
+		if podListStatus != http.StatusOK {
+			writeJSON(w, podListStatus, &metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusFailure,
+				Reason:   metav1.StatusReasonInternalError,
+				Message:  "etcdserver: request timed out",
+				Code:     int32(podListStatus),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, &corev1.PodList{TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"}})
+	})
+	mux.HandleFunc("/apis/apps/v1/namespaces/kube-system/deployments/coredns", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, &metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusFailure,
+			Reason:   metav1.StatusReasonNotFound,
+			Code:     http.StatusNotFound,
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := k8sclient.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestControlPlaneDetector(t *testing.T) {
+	client := newControlPlaneServer(t, http.StatusOK)
+	detector := monitoring.NewControlPlaneDetector(config.ControlPlaneDetectorConfig{LatencyThreshold: time.Nanosecond})
+
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	byComponent := make(map[string]monitoring.Finding)
+	latencyHigh := false
+	for _, finding := range findings {
+		switch finding.Type {
+		case "ControlPlaneComponentUnhealthy":
+			byComponent[finding.Labels["component"]] = finding
+		case "APIServerLatencyHigh":
+			latencyHigh = true
+		default:
+			t.Errorf("unexpected finding: %+v", finding)
+		}
+	}
+
+	if len(byComponent) != 2 {
+		t.Fatalf("expected etcd and coredns findings, got %+v", findings)
+	}
+	etcd := byComponent["etcd"]
+	if etcd.Level != "critical" || !strings.HasPrefix(etcd.Message, "etcd check failing: /readyz check etcd failing") {
+		t.Errorf("unexpected etcd finding: %+v", etcd)
+	}
+	if coredns := byComponent["coredns"]; !strings.Contains(coredns.Message, "1/2 ready replicas") {
+		t.Errorf("unexpected coredns finding: %+v", coredns)
+	}
+	if !latencyHigh {
+		t.Error("expected APIServerLatencyHigh finding with a 1ns threshold")
+	}
+}
+
+func TestControlPlaneDetector_ListFailure(t *testing.T) {
+	// etcd故障时列出kube-system中的Pod也会失败，etcd检查失败的告警仍然要产生
+	client := newControlPlaneServer(t, http.StatusInternalServerError)
+	detector := monitoring.NewControlPlaneDetector(config.ControlPlaneDetectorConfig{})
+
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	byComponent := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		byComponent[finding.Labels["component"]] = finding
+	}
+	if etcd, ok := byComponent["etcd"]; !ok || !strings.Contains(etcd.Message, "/readyz check etcd failing") {
+		t.Errorf("expected etcd finding from /readyz, got %+v", findings)
+	}
+	if apiserver := byComponent["apiserver"]; !strings.Contains(apiserver.Message, "failed to list etcd pods") {
+		t.Errorf("expected list failure recorded for apiserver, got %+v", apiserver)
+	}
+	if _, ok := byComponent["coredns"]; !ok {
+		t.Errorf("expected coredns finding after the list failure, got %+v", findings)
+	}
+}
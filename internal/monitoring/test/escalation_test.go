@@ -0,0 +1,139 @@
+package monitoring_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+func TestService_Escalation(t *testing.T) {
+	service, recorder := newNotReadyService(t)
+
+	err := service.SetEscalationPolicies([]config.EscalationPolicyConfig{{
+		Name:     "oncall",
+		Severity: "warning",
+		Steps: []config.EscalationStepConfig{
+			{After: time.Millisecond, DingTalkMobiles: []string{"13800000000"}},
+			{After: 2 * time.Millisecond, MentionAll: true},
+			{After: time.Hour, MentionAll: true},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("SetEscalationPolicies() error = %v", err)
+	}
+
+	service.RunOnce()
+	if recorder.count() != 1 {
+		t.Fatalf("expected 1 notification, got %d", recorder.count())
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	service.RunOnce()
+	if recorder.count() != 2 {
+		t.Fatalf("expected first escalation, got %d messages", recorder.count())
+	}
+	if last := recorder.messages[1]; !strings.Contains(last, `"atMobiles":["13800000000"]`) || !strings.Contains(last, "@13800000000") {
+		t.Errorf("expected escalation to mention on-call, got %s", last)
+	}
+
+	service.RunOnce()
+	if recorder.count() != 3 || !strings.Contains(recorder.messages[2], `"isAtAll":true`) {
+		t.Fatalf("expected second escalation to mention all, got %v", recorder.messages)
+	}
+
+	// 确认后不再升级
+	alert := service.GetAlerts()[0]
+	if alert.EscalationLevel != 2 {
+		t.Errorf("expected escalation level 2, got %d", alert.EscalationLevel)
+	}
+	if err := service.AckAlert(alert.ID, "alice"); err != nil {
+		t.Fatalf("AckAlert() error = %v", err)
+	}
+	service.RunOnce()
+	if recorder.count() != 3 {
+		t.Errorf("expected no escalation after ack, got %d messages", recorder.count())
+	}
+}
+
+func TestService_EscalationReceivers(t *testing.T) {
+	service, defaultRecorder := newNotReadyService(t)
+	managers, managersWebhook := newMessageRecorder(t)
+
+	err := service.SetRoutes(&config.RouteConfig{Receiver: "default"}, []config.ReceiverConfig{
+		{Name: "managers", DingTalk: []config.DingTalkReceiverConfig{{Webhook: managersWebhook}}},
+	})
+	if err != nil {
+		t.Fatalf("SetRoutes() error = %v", err)
+	}
+	if err := service.SetEscalationPolicies([]config.EscalationPolicyConfig{{
+		Name:     "oncall",
+		Severity: "warning",
+		Steps:    []config.EscalationStepConfig{{After: time.Hour, Receivers: []string{"missing"}}},
+	}}); err == nil {
+		t.Fatal("expected error for unknown escalation receiver")
+	}
+	err = service.SetEscalationPolicies([]config.EscalationPolicyConfig{{
+		Name:     "oncall",
+		Severity: "warning",
+		Steps:    []config.EscalationStepConfig{{After: time.Millisecond, Receivers: []string{"managers"}}},
+	}})
+	if err != nil {
+		t.Fatalf("SetEscalationPolicies() error = %v", err)
+	}
+	if err := service.SetRoutes(nil, nil); err == nil {
+		t.Error("expected error when removing a receiver referenced by an escalation step")
+	}
+
+	// 首次通知只发送到路由匹配的接收方，升级时同时发送到步骤配置的接收方
+	service.RunOnce()
+	if defaultRecorder.count() != 1 || managers.count() != 0 {
+		t.Fatalf("expected first notification only on the routed receiver, got default=%d managers=%d", defaultRecorder.count(), managers.count())
+	}
+	time.Sleep(5 * time.Millisecond)
+	service.RunOnce()
+	if defaultRecorder.count() != 2 || managers.count() != 1 || !strings.Contains(managers.messages[0], "Alert Escalated") {
+		t.Errorf("expected escalation on both receivers, got default=%d managers=%v", defaultRecorder.count(), managers.messages)
+	}
+}
+
+func TestService_AlertActions(t *testing.T) {
+	service, _ := newNotReadyService(t)
+	service.RunOnce()
+
+	id := service.GetAlerts()[0].ID
+	if err := service.AckAlert(id, "alice"); err != nil {
+		t.Fatalf("AckAlert() error = %v", err)
+	}
+	if err := service.AssignAlert(id, "alice", "bob"); err != nil {
+		t.Fatalf("AssignAlert() error = %v", err)
+	}
+	if err := service.CommentAlert(id, "bob", "draining node"); err != nil {
+		t.Fatalf("CommentAlert() error = %v", err)
+	}
+	if err := service.ResolveAlert(id, "bob"); err != nil {
+		t.Fatalf("ResolveAlert() error = %v", err)
+	}
+	if err := service.AckAlert("missing", "alice"); err == nil {
+		t.Error("expected error for unknown alert")
+	}
+
+	alert, err := service.GetAlert(id)
+	if err != nil {
+		t.Fatalf("GetAlert() error = %v", err)
+	}
+	if alert.Status != monitoring.AlertStatusResolved || alert.AckedBy != "alice" || alert.Assignee != "bob" {
+		t.Errorf("unexpected alert state: %+v", alert)
+	}
+
+	var actions []string
+	for _, entry := range alert.Timeline {
+		actions = append(actions, entry.Action+":"+entry.Actor)
+	}
+	expected := "fired:system,notified:system,acknowledged:alice,assigned:alice,commented:bob,resolved:bob"
+	if got := strings.Join(actions, ","); got != expected {
+		t.Errorf("expected timeline %s, got %s", expected, got)
+	}
+}
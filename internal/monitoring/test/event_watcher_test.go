@@ -0,0 +1,143 @@
+package monitoring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// podEvent 创建涉及指定Pod的事件
+func podEvent(name, pod, eventType, reason string, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "web", UID: types.UID(name)},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "web", Name: pod},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        reason + " on " + pod,
+		Count:          count,
+		LastTimestamp:  metav1.Now(),
+	}
+}
+
+func TestEventWatcher(t *testing.T) {
+	ctx := context.Background()
+	existing := podEvent("old", "api-0", corev1.EventTypeWarning, "BackOff", 4)
+	client := fake.NewSimpleClientset(existing)
+
+	var archived []monitoring.ClusterEvent
+	archive := func(events []monitoring.ClusterEvent) error {
+		archived = append(archived, events...)
+		return nil
+	}
+	watcher, err := monitoring.NewEventWatcher(config.EventDetectorConfig{
+		SpikeThreshold: 5,
+		Filters:        []config.EventFilterConfig{{Reason: "DNSConfigForming"}},
+	}, archive)
+	if err != nil {
+		t.Fatalf("NewEventWatcher() error = %v", err)
+	}
+	defer watcher.Stop()
+
+	// 启动前已存在的事件不计入
+	findings, err := watcher.Detect(ctx, "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings before new events, got %+v", findings)
+	}
+
+	stream, unsubscribe := watcher.Subscribe()
+	defer unsubscribe()
+
+	for _, ev := range []*corev1.Event{
+		podEvent("a", "api-1", corev1.EventTypeWarning, "BackOff", 1),
+		podEvent("b", "api-1", corev1.EventTypeWarning, "BackOff", 1),
+		podEvent("c", "api-2", corev1.EventTypeWarning, "BackOff", 1),
+		podEvent("d", "api-2", corev1.EventTypeWarning, "DNSConfigForming", 1),
+		podEvent("e", "api-2", corev1.EventTypeNormal, "Pulled", 1),
+	} {
+		if _, err := client.CoreV1().Events("web").Create(ctx, ev, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create event: %v", err)
+		}
+	}
+	// 已有事件的计数增加时只计入新增的次数
+	existing.Count = 6
+	if _, err := client.CoreV1().Events("web").Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+
+	var received []monitoring.ClusterEvent
+	timeout := time.After(5 * time.Second)
+	for len(received) < 4 {
+		select {
+		case event := <-stream:
+			received = append(received, event)
+		case <-timeout:
+			t.Fatalf("timed out waiting for events, received %+v", received)
+		}
+	}
+	for _, event := range received {
+		if event.Reason != "BackOff" || event.Cluster != "prod" {
+			t.Errorf("unexpected streamed event: %+v", event)
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, aggregate := range watcher.Aggregates("prod") {
+		counts[aggregate.Name+"/"+aggregate.Reason] = aggregate.Count
+	}
+	if len(counts) != 3 || counts["api-0/BackOff"] != 2 || counts["api-1/BackOff"] != 2 || counts["api-2/BackOff"] != 1 {
+		t.Errorf("unexpected aggregates: %v", counts)
+	}
+
+	findings, err = watcher.Detect(ctx, "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Type != "EventRateSpike" || findings[0].Labels["reason"] != "BackOff" {
+		t.Fatalf("expected BackOff spike, got %+v", findings)
+	}
+	if findings[0].Annotations["count"] != "5" {
+		t.Errorf("expected 5 events in window, got %s", findings[0].Annotations["count"])
+	}
+
+	// 所有观察到的事件都会归档，包括启动前已存在的、Normal和被过滤的事件
+	reasons := make(map[string]int)
+	for _, event := range archived {
+		reasons[event.Reason]++
+	}
+	if len(archived) != 7 || reasons["BackOff"] != 5 || reasons["DNSConfigForming"] != 1 || reasons["Pulled"] != 1 {
+		t.Errorf("unexpected archived events: %v", reasons)
+	}
+
+	// 停止（如配置更新替换监听器）时关闭订阅通道，订阅方据此重新订阅
+	watcher.Stop()
+	for {
+		select {
+		case _, ok := <-stream:
+			if !ok {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected subscriber channel to be closed after Stop")
+		}
+	}
+}
+
+func TestEventWatcherInvalidFilter(t *testing.T) {
+	if _, err := monitoring.NewEventWatcher(config.EventDetectorConfig{Filters: []config.EventFilterConfig{{}}}, nil); err == nil {
+		t.Error("expected error for filter without conditions")
+	}
+	if _, err := monitoring.NewEventWatcher(config.EventDetectorConfig{Filters: []config.EventFilterConfig{{Message: "("}}}, nil); err == nil {
+		t.Error("expected error for invalid message pattern")
+	}
+}
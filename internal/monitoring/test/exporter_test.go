@@ -0,0 +1,135 @@
+package monitoring_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// parseMetrics 解析Prometheus文本格式，返回以 name{labels} 为键的样本值和各指标的类型。
+// 每个指标的样本必须紧跟在其HELP和TYPE之后
+func parseMetrics(t *testing.T, data []byte) (map[string]float64, map[string]string) {
+	t.Helper()
+	samples := make(map[string]float64)
+	types := make(map[string]string)
+	current := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if fields := strings.Fields(line); strings.HasPrefix(line, "# TYPE ") {
+			if len(fields) != 4 {
+				t.Fatalf("invalid TYPE line: %q", line)
+			}
+			if _, ok := types[fields[2]]; ok {
+				t.Fatalf("metric %s is declared twice", fields[2])
+			}
+			current = fields[2]
+			types[current] = fields[3]
+			continue
+		}
+
+		separator := strings.LastIndexByte(line, ' ')
+		if separator < 0 {
+			t.Fatalf("invalid sample line: %q", line)
+		}
+		key := line[:separator]
+		if name, _, _ := strings.Cut(key, "{"); name != current {
+			t.Fatalf("sample %q does not follow the TYPE of its metric", line)
+		}
+		value, err := strconv.ParseFloat(line[separator+1:], 64)
+		if err != nil {
+			t.Fatalf("invalid sample value in %q: %v", line, err)
+		}
+		samples[key] = value
+	}
+	return samples, types
+}
+
+func TestService_WriteMetrics(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "web"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	client := fake.NewSimpleClientset(node, pod)
+
+	// 第一轮列出Pod失败，指标采集失败
+	failPods := true
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failPods {
+			return true, nil, errors.New("etcdserver: request timed out")
+		}
+		return false, nil, nil
+	})
+
+	manager := kubernetes.NewManagerWithClients(
+		[]config.ClusterConfig{{Name: "prod"}},
+		map[string]k8sclient.Interface{"prod": client},
+	)
+	service := monitoring.NewService(manager)
+	service.RunOnce()
+	failPods = false
+	service.RunOnce()
+
+	var out bytes.Buffer
+	if err := service.WriteMetrics(&out); err != nil {
+		t.Fatalf("WriteMetrics() error = %v", err)
+	}
+	samples, types := parseMetrics(t, out.Bytes())
+
+	want := map[string]float64{
+		`klaw_collections_total{cluster="prod"}`:                                               1,
+		`klaw_collection_failures_total{cluster="prod"}`:                                       1,
+		`klaw_cluster_nodes{cluster="prod",status="ready"}`:                                    1,
+		`klaw_node_ready{cluster="prod",node="node-1"}`:                                        1,
+		`klaw_cluster_pods{cluster="prod",phase="Running"}`:                                    1,
+		`klaw_namespace_pods{cluster="prod",namespace="web"}`:                                  1,
+		`klaw_workload_pods_ready{cluster="prod",namespace="web",kind="Pod",workload="api-0"}`: 0,
+	}
+	for key, value := range want {
+		got, ok := samples[key]
+		if !ok {
+			t.Errorf("missing sample %s", key)
+			continue
+		}
+		if got != value {
+			t.Errorf("%s = %v, want %v", key, got, value)
+		}
+	}
+	if _, ok := samples[`klaw_alerts_active{cluster="prod",type="MetricsCollectionFailed",level="warning"}`]; ok {
+		t.Error("expected collection failure alert to be resolved after a successful collection")
+	}
+
+	for name, typ := range map[string]string{
+		"klaw_collections_total":         "counter",
+		"klaw_collection_failures_total": "counter",
+		"klaw_cluster_nodes":             "gauge",
+	} {
+		if types[name] != typ {
+			t.Errorf("type of %s = %q, want %q", name, types[name], typ)
+		}
+	}
+}
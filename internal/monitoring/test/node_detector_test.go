@@ -0,0 +1,154 @@
+package monitoring_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// podOnNode 创建运行在指定节点上的Pod
+func podOnNode(name, node string, qos corev1.PodQOSClass) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "web"},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, QOSClass: qos},
+	}
+}
+
+func TestNodeHealthDetectorPressureAndLease(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "node.kubernetes.io/memory-pressure", Effect: corev1.TaintEffectNoSchedule},
+		}},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue, Message: "kubelet has insufficient memory available"},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse},
+		}},
+	}
+	mirror := podOnNode("etcd-node-1", "node-1", corev1.PodQOSBestEffort)
+	mirror.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+	stale := metav1.NewMicroTime(time.Now().Add(-5 * time.Minute))
+
+	client := fake.NewSimpleClientset(
+		node,
+		podOnNode("burstable", "node-1", corev1.PodQOSBurstable),
+		podOnNode("best-effort", "node-1", corev1.PodQOSBestEffort),
+		podOnNode("guaranteed", "node-1", corev1.PodQOSGuaranteed),
+		podOnNode("elsewhere", "node-2", corev1.PodQOSBestEffort),
+		mirror,
+		&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1", Namespace: "kube-node-lease"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &stale},
+		},
+	)
+
+	detector := monitoring.NewNodeHealthDetector(config.NodeDetectorConfig{})
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	byType := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = finding
+	}
+	if len(byType) != 2 {
+		t.Fatalf("expected memory pressure and stale heartbeat findings, got %+v", findings)
+	}
+
+	pressure, ok := byType["NodeMemoryPressure"]
+	if !ok {
+		t.Fatalf("expected NodeMemoryPressure finding, got %+v", findings)
+	}
+	if pressure.Labels["node"] != "node-1" {
+		t.Errorf("unexpected labels: %v", pressure.Labels)
+	}
+	if pressure.Annotations["taints"] != "node.kubernetes.io/memory-pressure:NoSchedule" {
+		t.Errorf("unexpected taints annotation: %q", pressure.Annotations["taints"])
+	}
+	// 按QoS驱逐顺序排列，Guaranteed、静态Pod和其他节点上的Pod不在其中
+	if pressure.Annotations["evicted_pods"] != "web/best-effort, web/burstable" {
+		t.Errorf("unexpected evicted pods: %q", pressure.Annotations["evicted_pods"])
+	}
+
+	heartbeat, ok := byType["NodeHeartbeatStale"]
+	if !ok {
+		t.Fatalf("expected NodeHeartbeatStale finding, got %+v", findings)
+	}
+	if heartbeat.Level != "critical" {
+		t.Errorf("expected critical level, got %s", heartbeat.Level)
+	}
+	// 节点失联时所有未容忍not-ready/unreachable污点的Pod都会被驱逐
+	if !strings.Contains(heartbeat.Annotations["evicted_pods"], "web/guaranteed") {
+		t.Errorf("expected guaranteed pod to be evicted on node failure, got %q", heartbeat.Annotations["evicted_pods"])
+	}
+}
+
+func TestNodeHealthDetectorFlapping(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		}},
+	}
+	client := fake.NewSimpleClientset(node)
+	detector := monitoring.NewNodeHealthDetector(config.NodeDetectorConfig{FlapThreshold: 2})
+
+	detect := func(ready corev1.ConditionStatus) []monitoring.Finding {
+		node.Status.Conditions[0].Status = ready
+		if _, err := client.CoreV1().Nodes().UpdateStatus(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("failed to update node: %v", err)
+		}
+		findings, err := detector.Detect(context.Background(), "prod", client)
+		if err != nil {
+			t.Fatalf("Detect() error = %v", err)
+		}
+		return findings
+	}
+
+	if findings := detect(corev1.ConditionTrue); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+	if findings := detect(corev1.ConditionFalse); len(findings) != 0 {
+		t.Fatalf("expected no findings after one transition, got %+v", findings)
+	}
+	findings := detect(corev1.ConditionTrue)
+	if len(findings) != 1 || findings[0].Type != "NodeFlapping" {
+		t.Fatalf("expected NodeFlapping finding, got %+v", findings)
+	}
+}
+
+func TestNodeHealthDetectorNetworkUnavailable(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionTrue, Message: "route not created"},
+		}},
+	}
+	client := fake.NewSimpleClientset(node, podOnNode("guaranteed", "node-1", corev1.PodQOSGuaranteed))
+
+	detector := monitoring.NewNodeHealthDetector(config.NodeDetectorConfig{})
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Type != "NodeNetworkUnavailable" {
+		t.Fatalf("expected NodeNetworkUnavailable finding, got %+v", findings)
+	}
+	// NetworkUnavailable不会触发驱逐
+	if evicted, ok := findings[0].Annotations["evicted_pods"]; ok || strings.Contains(findings[0].Message, "evicted") {
+		t.Errorf("expected no eviction impact for network unavailable, got %q in %q", evicted, findings[0].Message)
+	}
+}
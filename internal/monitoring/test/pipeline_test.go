@@ -0,0 +1,121 @@
+package monitoring_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+func TestService_NodeNotReadyLifecycle(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Message: "kubelet stopped posting node status"},
+		}},
+	}
+	client := fake.NewSimpleClientset(node)
+	manager := kubernetes.NewManagerWithClients(
+		[]config.ClusterConfig{{Name: "prod"}},
+		map[string]k8sclient.Interface{"prod": client},
+	)
+	service := monitoring.NewService(manager)
+
+	// 连续两轮检测只产生一条告警
+	service.RunOnce()
+	service.RunOnce()
+
+	alerts := service.GetAlerts()
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d: %+v", len(alerts), alerts)
+	}
+	alert := alerts[0]
+	if alert.Type != "NodeNotReady" || alert.Status != monitoring.AlertStatusFiring || alert.Labels["node"] != "node-1" {
+		t.Errorf("unexpected alert: %+v", alert)
+	}
+
+	node.Status.Conditions[0].Status = corev1.ConditionTrue
+	if _, err := client.CoreV1().Nodes().UpdateStatus(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+	service.RunOnce()
+
+	alerts = service.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Status != monitoring.AlertStatusResolved || alerts[0].ID != alert.ID {
+		t.Errorf("expected alert %s to be resolved, got %+v", alert.ID, alerts)
+	}
+}
+
+func TestService_ClusterUnreachable(t *testing.T) {
+	manager := kubernetes.NewManagerWithClients([]config.ClusterConfig{{Name: "missing"}}, nil)
+	service := monitoring.NewService(manager)
+
+	service.RunOnce()
+
+	alerts := service.GetAlerts()
+	if len(alerts) != 1 || alerts[0].Type != "ClusterUnreachable" || alerts[0].Level != "critical" {
+		t.Errorf("expected a single ClusterUnreachable alert, got %+v", alerts)
+	}
+}
+
+func TestService_NotificationLifecycle(t *testing.T) {
+	recorder, webhook := newMessageRecorder(t)
+	node := notReadyNode("node-1")
+	client := fake.NewSimpleClientset(node)
+	manager := kubernetes.NewManagerWithClients(
+		[]config.ClusterConfig{{Name: "prod"}},
+		map[string]k8sclient.Interface{"prod": client},
+	)
+	dingtalkClient, err := dingtalk.NewClient(config.DingTalkConfig{Webhook: webhook})
+	if err != nil {
+		t.Fatalf("dingtalk.NewClient() error = %v", err)
+	}
+	service := monitoring.NewService(manager)
+	service.SetDingTalkClient(dingtalkClient)
+	service.SetRepeatInterval(300 * time.Millisecond)
+
+	lastMessage := func() string {
+		recorder.mutex.Lock()
+		defer recorder.mutex.Unlock()
+		return recorder.messages[len(recorder.messages)-1]
+	}
+
+	// 首次触发立即通知，重复间隔内持续firing不再通知
+	service.RunOnce()
+	service.RunOnce()
+	if recorder.count() != 1 {
+		t.Fatalf("expected 1 notification within repeat interval, got %d", recorder.count())
+	}
+	alertID := service.GetAlerts()[0].ID
+
+	// 超过重复间隔后再次通知同一条告警
+	time.Sleep(350 * time.Millisecond)
+	service.RunOnce()
+	if recorder.count() != 2 || !strings.Contains(lastMessage(), alertID) {
+		t.Fatalf("expected repeated notification for %s, got %d notifications", alertID, recorder.count())
+	}
+
+	// 问题消失后自动解决并发送一次解决通知
+	node.Status.Conditions[0].Status = corev1.ConditionTrue
+	if _, err := client.CoreV1().Nodes().UpdateStatus(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+	service.RunOnce()
+	service.RunOnce()
+	if recorder.count() != 3 {
+		t.Fatalf("expected a single resolved notification, got %d notifications", recorder.count())
+	}
+	if message := lastMessage(); !strings.Contains(message, "[Kubernetes Alert Resolved]") || !strings.Contains(message, alertID) {
+		t.Errorf("expected resolved notification for %s, got %s", alertID, message)
+	}
+}
@@ -0,0 +1,120 @@
+package monitoring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// podOwnedBy 创建属于指定ReplicaSet的Pod
+func podOwnedBy(name, replicaSet string, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "web",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: replicaSet, Controller: &controller},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+	}
+}
+
+func TestPodFailureDetector(t *testing.T) {
+	controller := true
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name:            "api-7d9f",
+		Namespace:       "web",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "api", Controller: &controller}},
+	}}
+	crashLooping := corev1.ContainerStatus{
+		Name:         "api",
+		RestartCount: 3,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+			Reason: "CrashLoopBackOff", Message: "back-off 40s restarting failed container",
+		}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1, Reason: "Error", FinishedAt: metav1.Now(),
+		}},
+	}
+	oomKilled := corev1.ContainerStatus{
+		Name:         "sidecar",
+		RestartCount: 1,
+		State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 137, Reason: "OOMKilled", FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute)),
+		}},
+	}
+	imagePull := corev1.ContainerStatus{
+		Name:  "worker",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}
+
+	client := fake.NewSimpleClientset(
+		replicaSet,
+		podOwnedBy("api-7d9f-a", "api-7d9f", crashLooping, oomKilled),
+		podOwnedBy("api-7d9f-b", "api-7d9f", crashLooping),
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "web"},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{imagePull}},
+		},
+	)
+
+	detector := monitoring.NewPodFailureDetector(config.PodDetectorConfig{RestartThreshold: 3})
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	byType := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = finding
+	}
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+
+	crash := byType["PodCrashLooping"]
+	if crash.Level != "critical" || crash.Labels["workload"] != "api" || crash.Labels["kind"] != "Deployment" || crash.Labels["container"] != "api" {
+		t.Errorf("unexpected crash loop finding: %+v", crash)
+	}
+	if crash.Annotations["exit_code"] != "1" || crash.Annotations["pods"] != "api-7d9f-a,api-7d9f-b" || crash.Annotations["logs"] == "" {
+		t.Errorf("expected exit code, pods and logs, got %+v", crash.Annotations)
+	}
+	if oom := byType["PodOOMKilled"]; oom.Annotations["exit_code"] != "137" || oom.Labels["container"] != "sidecar" {
+		t.Errorf("unexpected OOM finding: %+v", oom)
+	}
+	if pull := byType["PodImagePullFailed"]; pull.Labels["kind"] != "Pod" || pull.Annotations["logs"] != "" {
+		t.Errorf("unexpected image pull finding: %+v", pull)
+	}
+
+	// 重启次数在窗口内增加达到阈值时产生重启突增告警
+	pod, _ := client.CoreV1().Pods("web").Get(context.Background(), "api-7d9f-b", metav1.GetOptions{})
+	pod.Status.ContainerStatuses[0].RestartCount += 3
+	if _, err := client.CoreV1().Pods("web").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	findings, err = detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	found := false
+	for _, finding := range findings {
+		if finding.Type == "WorkloadRestartSpike" && finding.Labels["workload"] == "api" && finding.Annotations["restarts"] == "3" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected restart spike for api, got %+v", findings)
+	}
+}
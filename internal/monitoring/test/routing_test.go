@@ -0,0 +1,57 @@
+package monitoring_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kudig-io/klaw/internal/config"
+)
+
+func TestService_Routing(t *testing.T) {
+	service, defaultRecorder := newNotReadyServiceWithNodes(t, "node-1", "node-2")
+	infra, infraWebhook := newMessageRecorder(t)
+	oncall, oncallWebhook := newMessageRecorder(t)
+
+	err := service.SetRoutes(&config.RouteConfig{
+		Receiver: "default",
+		GroupBy:  []string{"cluster"},
+		Routes: []config.RouteConfig{
+			{Receiver: "infra", Match: map[string]string{"alertname": "NodeNotReady"}, Continue: true},
+			{Receiver: "oncall", MatchRE: map[string]string{"severity": "warn.*"}, GroupBy: []string{"node"}},
+		},
+	}, []config.ReceiverConfig{
+		{Name: "infra", DingTalk: []config.DingTalkReceiverConfig{{Webhook: infraWebhook}}},
+		{Name: "oncall", DingTalk: []config.DingTalkReceiverConfig{{Webhook: oncallWebhook}}},
+	})
+	if err != nil {
+		t.Fatalf("SetRoutes() error = %v", err)
+	}
+
+	service.RunOnce()
+
+	// infra按集群分组，两个节点的告警合并为一条消息
+	if infra.count() != 1 || !strings.Contains(infra.messages[0], "2 firing, 0 resolved") {
+		t.Errorf("expected one grouped message for infra, got %v", infra.messages)
+	}
+	// continue后继续匹配oncall，按节点分组
+	if oncall.count() != 2 {
+		t.Errorf("expected one message per node for oncall, got %d", oncall.count())
+	}
+	if defaultRecorder.count() != 0 {
+		t.Errorf("expected no messages for default receiver, got %d", defaultRecorder.count())
+	}
+}
+
+func TestService_RoutingValidation(t *testing.T) {
+	service, _ := newNotReadyService(t)
+
+	if err := service.SetRoutes(&config.RouteConfig{Receiver: "missing"}, nil); err == nil {
+		t.Error("expected error for unknown receiver")
+	}
+	if err := service.SetRoutes(&config.RouteConfig{
+		Receiver: "default",
+		MatchRE:  map[string]string{"severity": "("},
+	}, nil); err == nil {
+		t.Error("expected error for invalid match_re")
+	}
+}
@@ -0,0 +1,162 @@
+package monitoring_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+// messageRecorder 模拟钉钉机器人webhook，记录收到的消息
+type messageRecorder struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (r *messageRecorder) count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.messages)
+}
+
+// newMessageRecorder 启动模拟的钉钉机器人，返回消息记录器和webhook地址
+func newMessageRecorder(t *testing.T) (*messageRecorder, string) {
+	recorder := &messageRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		recorder.mutex.Lock()
+		recorder.messages = append(recorder.messages, string(body))
+		recorder.mutex.Unlock()
+		io.WriteString(w, `{"errcode":0,"errmsg":"ok"}`)
+	}))
+	t.Cleanup(server.Close)
+	return recorder, server.URL + "/robot/send?access_token=test"
+}
+
+// notReadyNode 创建状态为Unknown的节点
+func notReadyNode(name string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+		}},
+	}
+}
+
+// newNotReadyService 创建包含一个NotReady节点的监控服务，并将通知发送到消息记录器
+func newNotReadyService(t *testing.T) (*monitoring.Service, *messageRecorder) {
+	return newNotReadyServiceWithNodes(t, "node-1")
+}
+
+// newNotReadyServiceWithNodes 创建包含指定NotReady节点的监控服务，并将通知发送到消息记录器
+func newNotReadyServiceWithNodes(t *testing.T, nodes ...string) (*monitoring.Service, *messageRecorder) {
+	recorder, webhook := newMessageRecorder(t)
+
+	var objects []runtime.Object
+	for _, name := range nodes {
+		objects = append(objects, notReadyNode(name))
+	}
+	manager := kubernetes.NewManagerWithClients(
+		[]config.ClusterConfig{{Name: "prod"}},
+		map[string]k8sclient.Interface{"prod": fake.NewSimpleClientset(objects...)},
+	)
+
+	client, err := dingtalk.NewClient(config.DingTalkConfig{Webhook: webhook})
+	if err != nil {
+		t.Fatalf("dingtalk.NewClient() error = %v", err)
+	}
+
+	service := monitoring.NewService(manager)
+	service.SetDingTalkClient(client)
+	return service, recorder
+}
+
+func TestService_Silence(t *testing.T) {
+	service, recorder := newNotReadyService(t)
+
+	silence, err := service.CreateSilence(monitoring.Silence{
+		Matcher:   monitoring.Matcher{Cluster: "prod", Node: "node-1"},
+		EndsAt:    time.Now().Add(time.Hour),
+		CreatedBy: "alice",
+		Comment:   "kernel upgrade",
+	})
+	if err != nil {
+		t.Fatalf("CreateSilence() error = %v", err)
+	}
+
+	service.RunOnce()
+
+	alerts := service.GetAlerts()
+	if len(alerts) != 1 || alerts[0].SilencedBy != "silence:"+silence.ID {
+		t.Fatalf("expected alert silenced by %s, got %+v", silence.ID, alerts)
+	}
+	if recorder.count() != 0 {
+		t.Fatalf("expected no notifications while silenced, got %d", recorder.count())
+	}
+
+	// 静默失效后立即补发通知
+	if err := service.ExpireSilence(silence.ID); err != nil {
+		t.Fatalf("ExpireSilence() error = %v", err)
+	}
+	service.RunOnce()
+
+	if recorder.count() != 1 {
+		t.Errorf("expected 1 notification after silence expired, got %d", recorder.count())
+	}
+}
+
+func TestService_CreateSilenceValidation(t *testing.T) {
+	service, _ := newNotReadyService(t)
+
+	if _, err := service.CreateSilence(monitoring.Silence{EndsAt: time.Now().Add(time.Hour), CreatedBy: "alice"}); err == nil {
+		t.Error("expected error for silence without matchers")
+	}
+	if _, err := service.CreateSilence(monitoring.Silence{
+		Matcher:   monitoring.Matcher{Cluster: "prod"},
+		EndsAt:    time.Now().Add(-time.Hour),
+		CreatedBy: "alice",
+	}); err == nil {
+		t.Error("expected error for silence that already ended")
+	}
+}
+
+func TestService_MaintenanceWindow(t *testing.T) {
+	service, recorder := newNotReadyService(t)
+
+	err := service.SetMaintenanceWindows([]config.MaintenanceWindowConfig{{
+		Name:     "always",
+		Start:    "00:00",
+		Duration: 24 * time.Hour,
+		Match:    config.AlertMatcherConfig{Rule: "NodeNotReady"},
+	}})
+	if err != nil {
+		t.Fatalf("SetMaintenanceWindows() error = %v", err)
+	}
+
+	service.RunOnce()
+
+	alerts := service.GetAlerts()
+	if len(alerts) != 1 || alerts[0].SilencedBy != "maintenance:always" {
+		t.Errorf("expected alert recorded during maintenance window, got %+v", alerts)
+	}
+	if recorder.count() != 0 {
+		t.Errorf("expected no notifications during maintenance window, got %d", recorder.count())
+	}
+
+	if err := service.SetMaintenanceWindows([]config.MaintenanceWindowConfig{{Name: "bad", Start: "25:00", Duration: time.Hour}}); err == nil {
+		t.Error("expected error for invalid start time")
+	}
+}
@@ -0,0 +1,155 @@
+package monitoring_test
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+// workloadHistory 生成web命名空间中api Deployment的就绪副本数历史，超过24小时的范围每小时一个采样，否则每分钟一个
+func workloadHistory(ready func(t time.Time) int) monitoring.MetricsHistory {
+	return func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+		step := time.Minute
+		if to.Sub(from) > 24*time.Hour {
+			step = time.Hour
+		}
+		var history []*metrics.ClusterMetrics
+		for t := from.Truncate(step).Add(step); !t.After(to); t = t.Add(step) {
+			history = append(history, &metrics.ClusterMetrics{
+				ClusterName: clusterName,
+				Timestamp:   t,
+				Namespaces: []metrics.NamespaceMetrics{{
+					Name: "web",
+					Workloads: []metrics.WorkloadMetrics{{
+						Kind: "Deployment", Name: "api", Namespace: "web",
+						Pods: metrics.PodCounts{Total: 3, Ready: ready(t)},
+					}},
+				}},
+			})
+		}
+		return history, nil
+	}
+}
+
+func TestSLODetector_BurnRate(t *testing.T) {
+	cfgs := []config.SLOConfig{{Name: "api-ready", Cluster: "prod", Namespace: "web", Workload: "api", MinReady: 2, Objective: 99.9}}
+
+	// 从两个整点之前开始只有1个就绪副本，30天窗口中每小时的采样有2.5小时不达标
+	now := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	degradedFrom := now.Truncate(time.Hour).Add(-2 * time.Hour)
+	degraded := workloadHistory(func(t time.Time) int {
+		if !t.Before(degradedFrom) {
+			return 1
+		}
+		return 3
+	})
+	detector, err := monitoring.NewSLODetector(cfgs, degraded)
+	if err != nil {
+		t.Fatalf("NewSLODetector() error = %v", err)
+	}
+	detector.SetClock(func() time.Time { return now })
+
+	findings, err := detector.Detect(context.Background(), "prod", nil)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Type != "SLOBurnRate" || findings[0].Level != "critical" || findings[0].Labels["slo"] != "api-ready" {
+		t.Fatalf("expected critical burn rate finding, got %+v", findings)
+	}
+	if findings[0].Annotations["window"] != "1h0m0s" {
+		t.Errorf("expected the fastest window to fire, got %v", findings[0].Annotations)
+	}
+
+	statuses := detector.Status()
+	if len(statuses) != 1 || statuses[0].ErrorBudgetRemaining >= 0 || statuses[0].Compliance >= 99.9 {
+		t.Fatalf("expected exhausted error budget, got %+v", statuses)
+	}
+	// 30天窗口的第一个小时采样在窗口开始后半小时，采样共覆盖719.5小时
+	wantCompliance := (1 - 2.5/719.5) * 100
+	if math.Abs(statuses[0].Compliance-wantCompliance) > 0.001 {
+		t.Errorf("compliance = %.4f, want %.4f", statuses[0].Compliance, wantCompliance)
+	}
+	wantBudget := (1 - (2.5/719.5)/0.001) * 100
+	if math.Abs(statuses[0].ErrorBudgetRemaining-wantBudget) > 0.1 {
+		t.Errorf("error budget remaining = %.2f, want %.2f", statuses[0].ErrorBudgetRemaining, wantBudget)
+	}
+
+	// 其他集群的SLO不参与检测
+	if findings, _ := detector.Detect(context.Background(), "staging", nil); len(findings) != 0 {
+		t.Errorf("expected no findings for other clusters, got %+v", findings)
+	}
+
+	healthy, err := monitoring.NewSLODetector(cfgs, workloadHistory(func(time.Time) int { return 3 }))
+	if err != nil {
+		t.Fatalf("NewSLODetector() error = %v", err)
+	}
+	healthy.SetClock(func() time.Time { return now })
+	if findings, _ := healthy.Detect(context.Background(), "prod", nil); len(findings) != 0 {
+		t.Errorf("expected no findings for healthy workload, got %+v", findings)
+	}
+	if statuses := healthy.Status(); statuses[0].ErrorBudgetRemaining != 100 || statuses[0].Compliance != 100 {
+		t.Errorf("expected full error budget, got %+v", statuses[0])
+	}
+}
+
+func TestSLODetector_InvalidConfig(t *testing.T) {
+	history := workloadHistory(func(time.Time) int { return 3 })
+	if _, err := monitoring.NewSLODetector([]config.SLOConfig{{Name: "a", Cluster: "prod", Namespace: "web", Workload: "api", Objective: 100}}, history); err == nil {
+		t.Error("expected error for objective of 100%")
+	}
+	if _, err := monitoring.NewSLODetector([]config.SLOConfig{{Name: "a", Cluster: "prod", Objective: 99}}, history); err == nil {
+		t.Error("expected error for missing workload")
+	}
+}
+
+func TestSLODetector_Rollups(t *testing.T) {
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "klaw.db"), config.RetentionConfig{
+		Raw:        time.Hour,
+		FiveMinute: 2 * time.Hour,
+		OneHour:    7 * 24 * time.Hour,
+		Audit:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	defer store.Close()
+
+	// 每小时的前20分钟没有就绪副本，其余时间3个副本全部就绪。按小时平均后就绪副本数为2，
+	// 但min_ready为2时每小时有三分之一的时间不达标
+	now := time.Now().Truncate(time.Hour)
+	history := workloadHistory(func(t time.Time) int {
+		if t.Minute() < 20 {
+			return 0
+		}
+		return 3
+	})
+	samples, _ := history("prod", now.Add(-6*time.Hour).Add(-time.Minute), now.Add(-time.Minute))
+	for _, sample := range samples {
+		if err := store.AppendSample(sample); err != nil {
+			t.Fatalf("AppendSample() error = %v", err)
+		}
+	}
+	if err := store.Compact(now); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	cfgs := []config.SLOConfig{{Name: "api-ready", Cluster: "prod", Namespace: "web", Workload: "api", MinReady: 2, Objective: 50, Window: 24 * time.Hour}}
+	detector, err := monitoring.NewSLODetector(cfgs, store.QuerySamples)
+	if err != nil {
+		t.Fatalf("NewSLODetector() error = %v", err)
+	}
+	statuses := detector.Status()
+	if len(statuses) != 1 || statuses[0].Error != "" || statuses[0].Samples == 0 {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if compliance := statuses[0].Compliance; math.Abs(compliance-200.0/3) > 0.5 {
+		t.Errorf("expected compliance of two thirds from rolled-up samples, got %.2f", compliance)
+	}
+}
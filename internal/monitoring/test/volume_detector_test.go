@@ -0,0 +1,240 @@
+package monitoring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
+)
+
+const gib = int64(1) << 30
+
+// pvc 创建指定状态和StorageClass的PVC，创建时间为一小时前
+func pvc(name string, phase corev1.PersistentVolumeClaimPhase, class *string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "db",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		Spec:   corev1.PersistentVolumeClaimSpec{StorageClassName: class},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+// volumeHistory 生成每10分钟一个采样的卷使用量历史，used为各采样的已用GiB
+func volumeHistory(now time.Time, used ...float64) monitoring.MetricsHistory {
+	var history []*metrics.ClusterMetrics
+	for i, u := range used {
+		history = append(history, &metrics.ClusterMetrics{
+			ClusterName: "prod",
+			Timestamp:   now.Add(time.Duration(i-len(used)+1) * 10 * time.Minute),
+			Volumes: []metrics.VolumeMetrics{{
+				Namespace: "db", PVC: "data-mysql-0", Node: "node-1",
+				CapacityBytes: 10 * gib, UsedBytes: int64(u * float64(gib)),
+			}},
+		})
+	}
+	return func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+		return history, nil
+	}
+}
+
+func TestVolumeDetectorBinding(t *testing.T) {
+	fast, local, missing, empty := "fast", "local", "missing", ""
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	client := fake.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}},
+		&storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: "local"},
+			VolumeBindingMode: &waitForConsumer,
+		},
+		pvc("lost", corev1.ClaimLost, &fast),
+		pvc("no-class", corev1.ClaimPending, &missing),
+		pvc("no-default", corev1.ClaimPending, nil),
+		pvc("static", corev1.ClaimPending, &empty),
+		pvc("bound", corev1.ClaimBound, &fast),
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "provisioning", Namespace: "db"},
+			InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "provisioning"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "ProvisioningFailed",
+			Message:        "quota exceeded",
+		},
+		pvc("provisioning", corev1.ClaimPending, &fast),
+		pvc("unused", corev1.ClaimPending, &local),
+	)
+
+	detector := monitoring.NewVolumeDetector(config.VolumeDetectorConfig{}, volumeHistory(time.Now(), 1))
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	reasons := make(map[string]string)
+	for _, finding := range findings {
+		name := finding.Labels["persistentvolumeclaim"]
+		switch finding.Type {
+		case "PVCLost":
+			reasons[name] = "Lost"
+		case "PVCPending":
+			reasons[name] = finding.Annotations["reason"]
+		default:
+			t.Errorf("unexpected finding: %+v", finding)
+		}
+	}
+
+	expected := map[string]string{
+		"lost":         "Lost",
+		"no-class":     "StorageClassNotFound",
+		"no-default":   "NoDefaultStorageClass",
+		"static":       "NoMatchingVolume",
+		"provisioning": "ProvisioningFailed",
+	}
+	if len(reasons) != len(expected) {
+		t.Errorf("expected %d findings, got %v", len(expected), reasons)
+	}
+	for name, reason := range expected {
+		if reasons[name] != reason {
+			t.Errorf("PVC %s: expected %s, got %q", name, reason, reasons[name])
+		}
+	}
+}
+
+func TestVolumeDetectorUsage(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleClientset()
+
+	// 每10分钟增长0.1GiB，当前8.5GiB，预计2.5小时后写满
+	detector := monitoring.NewVolumeDetector(config.VolumeDetectorConfig{}, volumeHistory(now, 8, 8.1, 8.2, 8.3, 8.4, 8.5))
+	findings, err := detector.Detect(context.Background(), "prod", client)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	byType := make(map[string]monitoring.Finding)
+	for _, finding := range findings {
+		byType[finding.Type] = finding
+	}
+	high, ok := byType["PVCUsageHigh"]
+	if !ok || high.Level != "warning" {
+		t.Fatalf("expected warning PVCUsageHigh finding, got %+v", findings)
+	}
+	if high.Labels["persistentvolumeclaim"] != "data-mysql-0" || high.Annotations["used_percent"] != "85.0" {
+		t.Errorf("unexpected usage finding: %+v", high)
+	}
+
+	filling, ok := byType["PVCFillingUp"]
+	if !ok {
+		t.Fatalf("expected PVCFillingUp finding, got %+v", findings)
+	}
+	fullAt, err := time.Parse(time.RFC3339, filling.Annotations["predicted_full_at"])
+	if err != nil {
+		t.Fatalf("invalid predicted_full_at: %v", err)
+	}
+	if eta := fullAt.Sub(now); eta < 2*time.Hour+20*time.Minute || eta > 2*time.Hour+40*time.Minute {
+		t.Errorf("expected volume to be full in about 2.5h, got %s", eta)
+	}
+
+	// 使用量没有增长时不预测
+	detector = monitoring.NewVolumeDetector(config.VolumeDetectorConfig{}, volumeHistory(now, 5, 5, 5, 5, 5, 5))
+	if findings, err := detector.Detect(context.Background(), "prod", client); err != nil || len(findings) != 0 {
+		t.Errorf("expected no findings for a stable volume, got %+v (err %v)", findings, err)
+	}
+
+	// 没有最新的卷使用量时沿用上一次的使用量告警，PVC绑定状态的告警照常返回
+	fresh := true
+	filled := volumeHistory(now, 9.5)
+	toggled := func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+		if fresh {
+			return filled(clusterName, from, to)
+		}
+		return nil, nil
+	}
+	pending := fake.NewSimpleClientset(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "lost", Namespace: "db"},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimLost},
+	})
+	detector = monitoring.NewVolumeDetector(config.VolumeDetectorConfig{}, toggled)
+	if findings, err := detector.Detect(context.Background(), "prod", pending); err != nil || len(findings) != 2 {
+		t.Fatalf("expected lost and usage findings, got %+v (err %v)", findings, err)
+	}
+	fresh = false
+	findings, err = detector.Detect(context.Background(), "prod", pending)
+	if err != nil {
+		t.Fatalf("Detect() without recent stats error = %v", err)
+	}
+	types := make(map[string]bool)
+	for _, finding := range findings {
+		types[finding.Type] = true
+	}
+	if len(findings) != 2 || !types["PVCLost"] || !types["PVCUsageHigh"] {
+		t.Errorf("expected binding findings and the previous usage finding, got %+v", findings)
+	}
+}
+
+func TestVolumeDetectorUsage_NodeStatsFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	redis := metrics.VolumeMetrics{Namespace: "db", PVC: "data-redis-0", Node: "node-2", CapacityBytes: 10 * gib, UsedBytes: 8 * gib}
+	samples := []*metrics.ClusterMetrics{
+		{Volumes: []metrics.VolumeMetrics{
+			{Namespace: "db", PVC: "data-mysql-0", Node: "node-1", CapacityBytes: 10 * gib, UsedBytes: 9 * gib},
+			redis,
+		}},
+		// node-1的卷统计读取失败，其上的卷不在采样中
+		{Volumes: []metrics.VolumeMetrics{redis}, VolumeStatsFailedNodes: []string{"node-1"}},
+		{Volumes: []metrics.VolumeMetrics{
+			{Namespace: "db", PVC: "data-mysql-0", Node: "node-1", CapacityBytes: 10 * gib, UsedBytes: 5 * gib},
+			redis,
+		}},
+	}
+	current := 0
+	history := func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+		sample := *samples[current]
+		sample.ClusterName = clusterName
+		sample.Timestamp = to
+		return []*metrics.ClusterMetrics{&sample}, nil
+	}
+	detector := monitoring.NewVolumeDetector(config.VolumeDetectorConfig{}, history)
+
+	levels := func() map[string]string {
+		t.Helper()
+		findings, err := detector.Detect(context.Background(), "prod", client)
+		if err != nil {
+			t.Fatalf("Detect() error = %v", err)
+		}
+		result := make(map[string]string)
+		for _, finding := range findings {
+			if finding.Type == "PVCUsageHigh" {
+				result[finding.Labels["persistentvolumeclaim"]] = finding.Level
+			}
+		}
+		return result
+	}
+
+	if got := levels(); got["data-mysql-0"] != "critical" || got["data-redis-0"] != "warning" {
+		t.Fatalf("expected usage findings for both volumes, got %v", got)
+	}
+
+	// 读取失败的节点上的卷沿用上一次的使用量告警，连续失败时继续沿用
+	current = 1
+	for i := 0; i < 2; i++ {
+		if got := levels(); got["data-mysql-0"] != "critical" || got["data-redis-0"] != "warning" {
+			t.Fatalf("expected usage finding on the failed node to be kept, got %v", got)
+		}
+	}
+
+	// 节点恢复后按最新的使用量计算
+	current = 2
+	if got := levels(); len(got) != 1 || got["data-redis-0"] != "warning" {
+		t.Errorf("expected usage finding to resolve once the node reports again, got %v", got)
+	}
+}
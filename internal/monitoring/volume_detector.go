@@ -0,0 +1,397 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// PVC检测器的默认参数
+const (
+	defaultPVCPendingTimeout     = 5 * time.Minute
+	defaultVolumeWarningPercent  = 80
+	defaultVolumeCriticalPercent = 90
+	defaultPredictionWindow      = 6 * time.Hour
+	defaultPredictionHorizon     = 24 * time.Hour
+	// volumeStatsMaxAge 最新指标采样超过该时长时认为卷使用量不可用
+	volumeStatsMaxAge = 5 * time.Minute
+	// minPredictionSamples 线性预测至少需要的采样数
+	minPredictionSamples = 5
+	// minPredictionSpan 线性预测至少需要的历史跨度
+	minPredictionSpan = 15 * time.Minute
+)
+
+// 默认StorageClass注解
+const (
+	defaultClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+	// selectedNodeAnnotation WaitForFirstConsumer的PVC被Pod使用并完成调度后由调度器设置
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+// MetricsHistory 查询集群在时间范围内按时间排序的指标采样，卷检测要求最新的采样包含本轮采集的卷使用量
+type MetricsHistory func(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error)
+
+// VolumeDetector PVC检测器，检查PVC的绑定状态和StorageClass配置，
+// 并根据kubelet上报的卷使用量检查使用率和按历史线性预测写满时间
+type VolumeDetector struct {
+	pendingTimeout    time.Duration
+	warningPercent    float64
+	criticalPercent   float64
+	predictionWindow  time.Duration
+	predictionHorizon time.Duration
+	history           MetricsHistory
+
+	// lastUsage 各集群最近一次成功计算的使用量告警，没有最近的卷统计时沿用，避免已有的使用量告警被误判为解决
+	mutex     sync.Mutex
+	lastUsage map[string][]Finding
+}
+
+// NewVolumeDetector 创建PVC检测器，未配置的参数使用默认值
+func NewVolumeDetector(cfg config.VolumeDetectorConfig, history MetricsHistory) *VolumeDetector {
+	d := &VolumeDetector{
+		pendingTimeout:    cfg.PendingTimeout,
+		warningPercent:    cfg.WarningPercent,
+		criticalPercent:   cfg.CriticalPercent,
+		predictionWindow:  cfg.PredictionWindow,
+		predictionHorizon: cfg.PredictionHorizon,
+		history:           history,
+		lastUsage:         make(map[string][]Finding),
+	}
+	if d.pendingTimeout <= 0 {
+		d.pendingTimeout = defaultPVCPendingTimeout
+	}
+	if d.warningPercent <= 0 {
+		d.warningPercent = defaultVolumeWarningPercent
+	}
+	if d.criticalPercent <= 0 {
+		d.criticalPercent = defaultVolumeCriticalPercent
+	}
+	if d.predictionWindow <= 0 {
+		d.predictionWindow = defaultPredictionWindow
+	}
+	if d.predictionHorizon <= 0 {
+		d.predictionHorizon = defaultPredictionHorizon
+	}
+	return d
+}
+
+// Name 检测器名称
+func (d *VolumeDetector) Name() string {
+	return "volume"
+}
+
+// Detect 检查PVC绑定状态、StorageClass和卷使用量
+func (d *VolumeDetector) Detect(ctx context.Context, clusterName string, client k8sclient.Interface) ([]Finding, error) {
+	pvcs, err := client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %v", err)
+	}
+	classes, err := client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %v", err)
+	}
+
+	now := time.Now()
+	findings := d.storageClassFindings(classes.Items)
+	findings = append(findings, d.bindingFindings(ctx, client, pvcs.Items, classes.Items, now)...)
+
+	// 没有最近的卷统计（如首次采集之前或采集失败）时只跳过使用量检查并沿用上一次的结果，PVC绑定状态的告警照常产生
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	usage, err := d.usageFindings(clusterName, now, d.lastUsage[clusterName])
+	if err != nil {
+		fmt.Printf("Skipping volume usage check for cluster %s: %v\n", clusterName, err)
+		usage = d.lastUsage[clusterName]
+	} else {
+		d.lastUsage[clusterName] = usage
+	}
+	return append(findings, usage...), nil
+}
+
+// storageClassFindings 检查是否有多个默认StorageClass，此时未指定StorageClass的PVC会创建失败
+func (d *VolumeDetector) storageClassFindings(classes []storagev1.StorageClass) []Finding {
+	var defaults []string
+	for i := range classes {
+		if isDefaultClass(&classes[i]) {
+			defaults = append(defaults, classes[i].Name)
+		}
+	}
+	if len(defaults) <= 1 {
+		return nil
+	}
+
+	sort.Strings(defaults)
+	return []Finding{{
+		Type:        "StorageClassMultipleDefaults",
+		Level:       "warning",
+		Message:     fmt.Sprintf("Multiple default storage classes: %s", strings.Join(defaults, ", ")),
+		Annotations: map[string]string{"storage_classes": strings.Join(defaults, ",")},
+	}}
+}
+
+// bindingFindings 检查Lost的PVC以及超时仍为Pending的PVC。WaitForFirstConsumer的PVC在被Pod使用前处于Pending是正常的
+func (d *VolumeDetector) bindingFindings(ctx context.Context, client k8sclient.Interface, pvcs []corev1.PersistentVolumeClaim, classes []storagev1.StorageClass, now time.Time) []Finding {
+	byName := make(map[string]*storagev1.StorageClass, len(classes))
+	var defaultClass *storagev1.StorageClass
+	for i := range classes {
+		byName[classes[i].Name] = &classes[i]
+		if isDefaultClass(&classes[i]) {
+			defaultClass = &classes[i]
+		}
+	}
+
+	var findings []Finding
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		labels := map[string]string{"namespace": pvc.Namespace, "persistentvolumeclaim": pvc.Name}
+
+		switch pvc.Status.Phase {
+		case corev1.ClaimLost:
+			findings = append(findings, Finding{
+				Type:      "PVCLost",
+				Namespace: pvc.Namespace,
+				Level:     "critical",
+				Message:   fmt.Sprintf("PVC %s/%s lost its volume %s", pvc.Namespace, pvc.Name, pvc.Spec.VolumeName),
+				Labels:    labels,
+			})
+
+		case corev1.ClaimPending:
+			if now.Sub(pvc.CreationTimestamp.Time) < d.pendingTimeout {
+				continue
+			}
+
+			className := ""
+			class := defaultClass
+			if pvc.Spec.StorageClassName != nil {
+				className = *pvc.Spec.StorageClassName
+				class = byName[className]
+			} else if defaultClass != nil {
+				className = defaultClass.Name
+			}
+
+			var reason, detail string
+			switch {
+			case pvc.Spec.StorageClassName != nil && className == "":
+				// 显式指定空StorageClass，只能绑定手动创建的PV
+				reason, detail = "NoMatchingVolume", "no matching persistent volume without a storage class"
+			case class == nil && className != "":
+				reason, detail = "StorageClassNotFound", fmt.Sprintf("storage class %s does not exist", className)
+			case class == nil:
+				reason, detail = "NoDefaultStorageClass", "no storage class specified and the cluster has no default storage class"
+			case class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer && pvc.Annotations[selectedNodeAnnotation] == "":
+				continue
+			default:
+				reason, detail = "ProvisioningFailed", "waiting for volume to be provisioned"
+				if event := latestWarningEvent(ctx, client, pvc); event != nil {
+					reason, detail = event.Reason, event.Message
+				}
+			}
+
+			annotations := map[string]string{"reason": reason}
+			if className != "" {
+				annotations["storage_class"] = className
+			}
+			findings = append(findings, Finding{
+				Type:      "PVCPending",
+				Namespace: pvc.Namespace,
+				Level:     "warning",
+				Message: fmt.Sprintf("PVC %s/%s has been pending for %s: %s",
+					pvc.Namespace, pvc.Name, now.Sub(pvc.CreationTimestamp.Time).Round(time.Second), detail),
+				Labels:      labels,
+				Annotations: annotations,
+			})
+		}
+	}
+	return findings
+}
+
+// latestWarningEvent 返回PVC最近的Warning事件，如ProvisioningFailed
+func latestWarningEvent(ctx context.Context, client k8sclient.Interface, pvc *corev1.PersistentVolumeClaim) *corev1.Event {
+	events, err := client.CoreV1().Events(pvc.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=PersistentVolumeClaim,involvedObject.name=" + pvc.Name,
+	})
+	if err != nil {
+		return nil
+	}
+
+	var latest *corev1.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.Type != corev1.EventTypeWarning || event.InvolvedObject.Kind != "PersistentVolumeClaim" || event.InvolvedObject.Name != pvc.Name {
+			continue
+		}
+		if latest == nil || event.LastTimestamp.After(latest.LastTimestamp.Time) {
+			latest = event
+		}
+	}
+	return latest
+}
+
+// isDefaultClass 判断StorageClass是否为默认StorageClass
+func isDefaultClass(class *storagev1.StorageClass) bool {
+	return class.Annotations[defaultClassAnnotation] == "true" || class.Annotations[betaDefaultClassAnnotation] == "true"
+}
+
+// usageFindings 根据最新采样检查卷使用率，并用预测窗口内的历史线性预测写满时间。
+// 最新采样中读取卷统计失败的节点上的卷沿用previous中的使用量告警，避免告警在解决和触发之间反复
+func (d *VolumeDetector) usageFindings(clusterName string, now time.Time, previous []Finding) ([]Finding, error) {
+	history, err := d.history(clusterName, now.Add(-d.predictionWindow), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query volume history: %v", err)
+	}
+	if len(history) == 0 || now.Sub(history[len(history)-1].Timestamp) > volumeStatsMaxAge {
+		return nil, fmt.Errorf("no recent volume stats for cluster %s", clusterName)
+	}
+
+	latest := history[len(history)-1]
+	var findings []Finding
+	for _, volume := range latest.Volumes {
+		volume := volume
+		labels := map[string]string{"namespace": volume.Namespace, "persistentvolumeclaim": volume.PVC}
+		annotations := map[string]string{
+			"node":           volume.Node,
+			"used_percent":   fmt.Sprintf("%.1f", volume.UsedPercent()),
+			"capacity":       formatBytes(volume.CapacityBytes),
+			"inodes_percent": fmt.Sprintf("%.1f", volume.InodesUsedPercent()),
+		}
+
+		if level := d.usageLevel(&volume); level != "" {
+			message := fmt.Sprintf("Volume of PVC %s/%s is %.1f%% full (%s of %s) on node %s",
+				volume.Namespace, volume.PVC, volume.UsedPercent(), formatBytes(volume.UsedBytes), formatBytes(volume.CapacityBytes), volume.Node)
+			if volume.InodesUsedPercent() >= d.warningPercent {
+				message += fmt.Sprintf(", inodes %.1f%% used", volume.InodesUsedPercent())
+			}
+			findings = append(findings, Finding{
+				Type:        "PVCUsageHigh",
+				Namespace:   volume.Namespace,
+				Level:       level,
+				Message:     message,
+				Labels:      labels,
+				Annotations: annotations,
+			})
+		}
+
+		if fullAt, rate, ok := predictFull(history, &volume); ok && fullAt.Sub(now) <= d.predictionHorizon {
+			predicted := copyLabels(annotations)
+			predicted["predicted_full_at"] = fullAt.Format(time.RFC3339)
+			predicted["growth_per_hour"] = formatBytes(int64(rate * 3600))
+			findings = append(findings, Finding{
+				Type:      "PVCFillingUp",
+				Namespace: volume.Namespace,
+				Level:     "warning",
+				Message: fmt.Sprintf("Volume of PVC %s/%s is growing %s/h and is predicted to be full in %s (at %s)",
+					volume.Namespace, volume.PVC, formatBytes(int64(rate*3600)), fullAt.Sub(now).Round(time.Minute), fullAt.Format("2006-01-02 15:04")),
+				Labels:      labels,
+				Annotations: predicted,
+			})
+		}
+	}
+
+	failed := make(map[string]bool, len(latest.VolumeStatsFailedNodes))
+	for _, node := range latest.VolumeStatsFailedNodes {
+		failed[node] = true
+	}
+	for _, finding := range previous {
+		if failed[finding.Annotations["node"]] && latest.Volume(finding.Labels["namespace"], finding.Labels["persistentvolumeclaim"]) == nil {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// usageLevel 按空间和inode使用率中较高的一个返回告警级别，未达到阈值时返回空
+func (d *VolumeDetector) usageLevel(volume *metrics.VolumeMetrics) string {
+	used := volume.UsedPercent()
+	if inodes := volume.InodesUsedPercent(); inodes > used {
+		used = inodes
+	}
+	switch {
+	case used >= d.criticalPercent:
+		return "critical"
+	case used >= d.warningPercent:
+		return "warning"
+	}
+	return ""
+}
+
+// predictFull 对卷的已用空间做最小二乘线性拟合，返回预计写满的时间和每秒增长字节数。
+// 只使用容量与当前一致的采样，避免扩容前的数据影响预测；使用量没有增长时返回false
+func predictFull(history []*metrics.ClusterMetrics, volume *metrics.VolumeMetrics) (time.Time, float64, bool) {
+	var times []time.Time
+	var used []float64
+	for _, sample := range history {
+		v := sample.Volume(volume.Namespace, volume.PVC)
+		if v == nil {
+			continue
+		}
+		if v.CapacityBytes != volume.CapacityBytes {
+			times, used = nil, nil
+			continue
+		}
+		times = append(times, sample.Timestamp)
+		used = append(used, float64(v.UsedBytes))
+	}
+	if len(times) < minPredictionSamples || times[len(times)-1].Sub(times[0]) < minPredictionSpan {
+		return time.Time{}, 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(times))
+	for i, t := range times {
+		x := t.Sub(times[0]).Seconds()
+		sumX += x
+		sumY += used[i]
+		sumXY += x * used[i]
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return time.Time{}, 0, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	if slope <= 0 {
+		return time.Time{}, 0, false
+	}
+
+	last := times[len(times)-1]
+	remaining := float64(volume.CapacityBytes - volume.UsedBytes)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return last.Add(time.Duration(remaining / slope * float64(time.Second))), slope, true
+}
+
+// copyLabels 复制标签或注解
+func copyLabels(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		result[k] = v
+	}
+	return result
+}
+
+// formatBytes 按二进制单位格式化字节数
+func formatBytes(value int64) string {
+	const unit = 1024
+	if value < unit {
+		return fmt.Sprintf("%dB", value)
+	}
+	div, exp := int64(unit), 0
+	for n := value / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(value)/float64(div), "KMGTPE"[exp])
+}
@@ -2,30 +2,47 @@ package ops
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/kudig-io/klaw/internal/chart"
+	"github.com/kudig-io/klaw/internal/config"
 	"github.com/kudig-io/klaw/internal/kubernetes"
-	"github.com/kudig-io/klaw/internal/metrics"
-	"github.com/kudig-io/klaw/internal/monitoring"
 	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
 	"github.com/kudig-io/klaw/internal/messaging/feishu"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+// 事件查询命令的默认参数
+const (
+	defaultEventSearchRange = 24 * time.Hour
+	maxEventSearchResults   = 20
+	// chartRange 图表命令覆盖的时间范围
+	chartRange = time.Hour
 )
 
+// defaultActor 未提供发送者时记录的操作者
+const defaultActor = "chatops"
+
 // Handler 运维命令处理器
 type Handler struct {
-	k8sManager      *kubernetes.Manager
+	k8sManager        *kubernetes.Manager
 	monitoringService *monitoring.Service
-	dingtalkClient   *dingtalk.Client
-	feishuClient     *feishu.Client
-	resources        *kubernetes.Resources
+	dingtalkClient    *dingtalk.Client
+	feishuClient      *feishu.Client
+	resources         *kubernetes.Resources
+	chartsConfig      config.ChatChartsConfig
 }
 
 // NewHandler 创建运维命令处理器
 func NewHandler(k8sManager *kubernetes.Manager, monitoringService *monitoring.Service) *Handler {
 	return &Handler{
-		k8sManager:      k8sManager,
+		k8sManager:        k8sManager,
 		monitoringService: monitoringService,
-		resources:        kubernetes.NewResources(k8sManager),
+		resources:         kubernetes.NewResources(k8sManager),
 	}
 }
 
@@ -39,22 +56,38 @@ func (h *Handler) SetFeishuClient(client *feishu.Client) {
 	h.feishuClient = client
 }
 
-// HandleCommand 处理运维命令
-func (h *Handler) HandleCommand(command string) (string, error) {
+// SetChartsConfig 设置图表命令的回复方式
+func (h *Handler) SetChartsConfig(cfg config.ChatChartsConfig) {
+	h.chartsConfig = cfg
+}
+
+// HandleCommand 处理运维命令。sender为发送命令的用户标识（如钉钉或飞书的用户ID），
+// 删除Pod、告警和静默操作以此作为审计和告警时间线中的操作者
+func (h *Handler) HandleCommand(sender, command string) (string, error) {
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
 		return "", fmt.Errorf("empty command")
 	}
+	actor := sender
+	if actor == "" {
+		actor = defaultActor
+	}
 
 	switch parts[0] {
 	case "cluster":
 		return h.handleClusterCommand(parts[1:])
 	case "pod":
-		return h.handlePodCommand(parts[1:])
+		return h.handlePodCommand(parts[1:], actor)
 	case "node":
 		return h.handleNodeCommand(parts[1:])
 	case "monitor":
-		return h.handleMonitorCommand(parts[1:])
+		return h.handleMonitorCommand(parts[1:], actor)
+	case "cert":
+		return h.handleCertCommand(parts[1:])
+	case "events":
+		return h.handleEventsCommand(parts[1:])
+	case "slo":
+		return h.handleSLOCommand(parts[1:])
 	case "help":
 		return h.showHelp(), nil
 	default:
@@ -90,7 +123,7 @@ func (h *Handler) handleClusterCommand(parts []string) (string, error) {
 }
 
 // handlePodCommand 处理Pod命令
-func (h *Handler) handlePodCommand(parts []string) (string, error) {
+func (h *Handler) handlePodCommand(parts []string, actor string) (string, error) {
 	if len(parts) == 0 {
 		return "", fmt.Errorf("pod command requires subcommand")
 	}
@@ -115,7 +148,7 @@ func (h *Handler) handlePodCommand(parts []string) (string, error) {
 		if len(parts) < 4 {
 			return "", fmt.Errorf("pod delete command requires cluster name, namespace and pod name")
 		}
-		return h.deletePod(parts[1], parts[2], parts[3])
+		return h.deletePod(parts[1], parts[2], parts[3], actor)
 	default:
 		return "", fmt.Errorf("unknown pod subcommand: %s", parts[0])
 	}
@@ -149,7 +182,7 @@ func (h *Handler) handleNodeCommand(parts []string) (string, error) {
 }
 
 // handleMonitorCommand 处理监控命令
-func (h *Handler) handleMonitorCommand(parts []string) (string, error) {
+func (h *Handler) handleMonitorCommand(parts []string, actor string) (string, error) {
 	if len(parts) == 0 {
 		return "", fmt.Errorf("monitor command requires subcommand")
 	}
@@ -170,6 +203,10 @@ func (h *Handler) handleMonitorCommand(parts []string) (string, error) {
 			return "", fmt.Errorf("monitor chart command requires cluster name")
 		}
 		return h.sendMonitorChart(parts[1])
+	case "silence":
+		return h.handleSilenceCommand(parts[1:], actor)
+	case "ack", "assign", "resolve", "comment", "timeline":
+		return h.handleAlertCommand(parts[0], parts[1:], actor)
 	default:
 		return "", fmt.Errorf("unknown monitor subcommand: %s", parts[0])
 	}
@@ -214,34 +251,60 @@ func (h *Handler) getClusterMetrics(clusterName string) (string, error) {
 	return result, nil
 }
 
-// sendClusterChart 发送集群图表
+// sendClusterChart 发送最近一小时的集群监控图表。图片被禁用、没有配置消息平台或发送失败时，以文本迷你图作为回复
 func (h *Handler) sendClusterChart(clusterName string) (string, error) {
 	if h.monitoringService == nil {
 		return "", fmt.Errorf("monitoring service not initialized")
 	}
 
-	history := h.monitoringService.GetMetricsHistory(clusterName)
+	to := time.Now()
+	from := to.Add(-chartRange)
+	history, err := h.monitoringService.GetMetricsHistoryRange(clusterName, from, to, chart.DefaultStep(from, to))
+	if err != nil {
+		return "", err
+	}
 	if len(history) == 0 {
 		return "", fmt.Errorf("no metrics history available for cluster %s", clusterName)
 	}
 
-	latestMetrics := history[len(history)-1]
+	prefix := ""
+	if !h.chartsConfig.DisableImages && (h.dingtalkClient != nil || h.feishuClient != nil) {
+		err := h.sendChartImage(clusterName, history, from, to)
+		if err == nil {
+			return fmt.Sprintf("Sent monitoring chart for cluster %s", clusterName), nil
+		}
+		prefix = fmt.Sprintf("Failed to send chart image (%v), showing text chart instead:\n", err)
+	}
 
-	// 发送图表到钉钉
+	generator := chart.NewGenerator(0, 0)
+	generator.SetFormat(chart.FormatText)
+	generator.SetTextOptions(chart.TextStyle(h.chartsConfig.TextStyle), h.chartsConfig.TextWidth)
+	text, err := generator.GenerateClusterMetricsChart(clusterName, history, from, to)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate text chart: %v", err)
+	}
+	return prefix + string(text), nil
+}
+
+// sendChartImage 生成集群监控图表的PNG图片并发送到钉钉和飞书
+func (h *Handler) sendChartImage(clusterName string, history []*metrics.ClusterMetrics, from, to time.Time) error {
+	chartData, err := chart.NewGenerator(800, 600).GenerateClusterMetricsChart(clusterName, history, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to generate chart: %v", err)
+	}
+
+	title := fmt.Sprintf("集群监控 - %s", clusterName)
 	if h.dingtalkClient != nil {
-		if err := h.dingtalkClient.SendMessage(fmt.Sprintf("正在生成集群 %s 的监控图表...", clusterName)); err != nil {
-			return "", err
+		if err := h.dingtalkClient.SendChart(chartData, title); err != nil {
+			return fmt.Errorf("dingtalk: %v", err)
 		}
 	}
-
-	// 发送图表到飞书
 	if h.feishuClient != nil {
-		if err := h.feishuClient.SendMessage(fmt.Sprintf("正在生成集群 %s 的监控图表...", clusterName)); err != nil {
-			return "", err
+		if err := h.feishuClient.SendChart(chartData, title); err != nil {
+			return fmt.Errorf("feishu: %v", err)
 		}
 	}
-
-	return fmt.Sprintf("Sent monitoring chart for cluster %s", clusterName), nil
+	return nil
 }
 
 // listPods 列出Pod
@@ -286,12 +349,16 @@ func (h *Handler) getPodLogs(clusterName, namespace, podName string) (string, er
 }
 
 // deletePod 删除Pod
-func (h *Handler) deletePod(clusterName, namespace, podName string) (string, error) {
+func (h *Handler) deletePod(clusterName, namespace, podName, actor string) (string, error) {
 	err := h.resources.DeletePod(clusterName, namespace, podName)
 	if err != nil {
 		return "", err
 	}
 
+	if h.monitoringService != nil {
+		h.monitoringService.RecordAudit(actor, "pod.delete", fmt.Sprintf("%s/%s/%s", clusterName, namespace, podName), "")
+	}
+
 	return fmt.Sprintf("Deleted pod %s in namespace %s", podName, namespace), nil
 }
 
@@ -368,7 +435,14 @@ func (h *Handler) getMonitorAlerts(clusterName string) (string, error) {
 	result := fmt.Sprintf("Alerts for cluster %s:\n", clusterName)
 	for _, alert := range alerts {
 		if alert.Cluster == clusterName {
-			result += fmt.Sprintf("- [%s] %s: %s\n", alert.Level, alert.Type, alert.Message)
+			result += fmt.Sprintf("- [%s] %s (%s, %s): %s", alert.Level, alert.Type, alert.ID, alert.Status, alert.Message)
+			if alert.Acknowledged() {
+				result += fmt.Sprintf(" [acked by %s]", alert.AckedBy)
+			}
+			if alert.Assignee != "" {
+				result += fmt.Sprintf(" [assigned to %s]", alert.Assignee)
+			}
+			result += "\n"
 		}
 	}
 
@@ -387,7 +461,7 @@ func (h *Handler) showHelp() string {
 Cluster commands:
   cluster status <cluster-name>    - Get cluster status
   cluster metrics <cluster-name>    - Get cluster metrics
-  cluster chart <cluster-name>       - Send monitoring chart
+  cluster chart <cluster-name>       - Send monitoring chart (text sparkline if images are disabled or fail)
 
 Pod commands:
   pod list <cluster-name> <namespace>         - List pods
@@ -404,8 +478,377 @@ Monitor commands:
   monitor status <cluster-name> - Get monitoring status
   monitor alerts <cluster-name> - Get monitoring alerts
   monitor chart <cluster-name>    - Send monitoring chart
+  monitor silence <duration> <key=value>... [-- comment] - Silence matching alerts
+      keys: cluster, namespace, node, rule, or any alert label
+      e.g. monitor silence 2h cluster=prod node=node-1 -- kernel upgrade
+  monitor silence list          - List silences
+  monitor silence expire <id>   - Expire a silence
+  monitor ack <alert-id>        - Acknowledge an alert, stopping repeats and escalation
+  monitor assign <alert-id> <assignee> - Assign an alert
+  monitor resolve <alert-id>    - Resolve an alert manually
+  monitor comment <alert-id> <text> - Comment on an alert
+  monitor timeline <alert-id>   - Show the alert timeline
+
+Certificate commands:
+  cert expiring <days>          - List TLS certificates expiring within the given days across all clusters
+
+Event commands:
+  events search <cluster-name> [key=value]... - Search archived events, newest first
+      keys: namespace, kind, object, reason, type, since (default 24h)
+      e.g. events search prod namespace=web reason=BackOff since=6h
+
+SLO commands:
+  slo status [slo-name]         - Show SLO compliance, remaining error budget and burn rates
 
 Help:
   help - Show this help message
 `
 }
+
+// handleCertCommand 处理证书命令
+func (h *Handler) handleCertCommand(parts []string) (string, error) {
+	if h.monitoringService == nil {
+		return "", fmt.Errorf("monitoring service not available")
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("cert command requires subcommand")
+	}
+
+	switch parts[0] {
+	case "expiring":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("cert expiring command requires days")
+		}
+		days, err := strconv.Atoi(parts[1])
+		if err != nil || days <= 0 {
+			return "", fmt.Errorf("invalid days: %s", parts[1])
+		}
+		return h.listExpiringCertificates(days), nil
+	default:
+		return "", fmt.Errorf("unknown cert subcommand: %s", parts[0])
+	}
+}
+
+// listExpiringCertificates 列出所有集群中在指定天数内过期的证书
+func (h *Handler) listExpiringCertificates(days int) string {
+	certificates := h.monitoringService.GetCertificates(time.Duration(days) * 24 * time.Hour)
+	if len(certificates) == 0 {
+		return fmt.Sprintf("No certificates expiring within %d days", days)
+	}
+
+	now := time.Now()
+	result := fmt.Sprintf("Certificates expiring within %d days:\n", days)
+	for _, cert := range certificates {
+		name := cert.CommonName
+		if name == "" && len(cert.DNSNames) > 0 {
+			name = cert.DNSNames[0]
+		}
+
+		status := fmt.Sprintf("expires in %d days", cert.DaysRemaining(now))
+		if !now.Before(cert.NotAfter) {
+			status = "EXPIRED"
+		}
+		result += fmt.Sprintf("- [%s] %s/%s %s: %s (%s)", cert.Cluster, cert.Namespace, cert.Secret, name, status, cert.NotAfter.Format("2006-01-02"))
+		if len(cert.Ingresses) > 0 {
+			result += " ingress: " + strings.Join(cert.Ingresses, ",")
+		}
+		result += "\n"
+	}
+	return result
+}
+
+// handleEventsCommand 处理事件命令
+func (h *Handler) handleEventsCommand(parts []string) (string, error) {
+	if h.monitoringService == nil {
+		return "", fmt.Errorf("monitoring service not available")
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("events command requires subcommand")
+	}
+
+	switch parts[0] {
+	case "search":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("events search command requires cluster name")
+		}
+		query, err := parseEventQuery(parts[1], parts[2:])
+		if err != nil {
+			return "", err
+		}
+		return h.searchEvents(query)
+	default:
+		return "", fmt.Errorf("unknown events subcommand: %s", parts[0])
+	}
+}
+
+// parseEventQuery 解析 <cluster> [key=value]... 格式的事件查询参数
+func parseEventQuery(clusterName string, parts []string) (storage.EventQuery, error) {
+	now := time.Now()
+	query := storage.EventQuery{Cluster: clusterName, From: now.Add(-defaultEventSearchRange), To: now, Limit: maxEventSearchResults}
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" || value == "" {
+			return storage.EventQuery{}, fmt.Errorf("invalid event filter %q, expected key=value", part)
+		}
+		switch key {
+		case "namespace":
+			query.Namespace = value
+		case "kind":
+			query.Kind = value
+		case "object":
+			query.Name = value
+		case "reason":
+			query.Reason = value
+		case "type":
+			query.Type = value
+		case "since":
+			since, err := time.ParseDuration(value)
+			if err != nil || since <= 0 {
+				return storage.EventQuery{}, fmt.Errorf("invalid since duration %q", value)
+			}
+			query.From = now.Add(-since)
+		default:
+			return storage.EventQuery{}, fmt.Errorf("unknown event filter %q", key)
+		}
+	}
+	return query, nil
+}
+
+// searchEvents 查询归档的事件并格式化为文本
+func (h *Handler) searchEvents(query storage.EventQuery) (string, error) {
+	records, err := h.monitoringService.SearchEvents(query)
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return fmt.Sprintf("No events found in cluster %s since %s", query.Cluster, query.From.Format("2006-01-02 15:04:05")), nil
+	}
+
+	result := fmt.Sprintf("Events in cluster %s (newest first, at most %d):\n", query.Cluster, query.Limit)
+	for _, record := range records {
+		object := record.Kind + " " + record.Name
+		if record.Namespace != "" {
+			object = record.Kind + " " + record.Namespace + "/" + record.Name
+		}
+		result += fmt.Sprintf("- %s [%s] %s %s (x%d): %s\n", record.LastSeen.Format("2006-01-02 15:04:05"),
+			record.Type, record.Reason, object, record.Count, record.Message)
+	}
+	return result, nil
+}
+
+// handleSLOCommand 处理SLO命令
+func (h *Handler) handleSLOCommand(parts []string) (string, error) {
+	if h.monitoringService == nil {
+		return "", fmt.Errorf("monitoring service not available")
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("slo command requires subcommand")
+	}
+
+	switch parts[0] {
+	case "status":
+		name := ""
+		if len(parts) > 1 {
+			name = parts[1]
+		}
+		return h.sloStatus(name)
+	default:
+		return "", fmt.Errorf("unknown slo subcommand: %s", parts[0])
+	}
+}
+
+// sloStatus 格式化SLO的达成率、剩余错误预算和燃烧率，name为空时列出所有SLO
+func (h *Handler) sloStatus(name string) (string, error) {
+	var statuses []monitoring.SLOStatus
+	for _, status := range h.monitoringService.GetSLOStatus() {
+		if name == "" || status.Name == name {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		if name != "" {
+			return "", fmt.Errorf("slo not found: %s", name)
+		}
+		return "No SLOs configured", nil
+	}
+
+	result := "SLO status:\n"
+	for _, status := range statuses {
+		result += fmt.Sprintf("- %s [%s] %s %s/%s at least %d ready, objective %.2f%% over %s\n",
+			status.Name, status.Cluster, status.Kind, status.Namespace, status.Workload, status.MinReady, status.Objective, status.Window)
+		if status.Error != "" {
+			result += fmt.Sprintf("  error: %s\n", status.Error)
+			continue
+		}
+		if status.Samples == 0 {
+			result += "  no samples yet\n"
+			continue
+		}
+		result += fmt.Sprintf("  compliance: %.3f%%, error budget remaining: %.1f%% (%d samples)\n",
+			status.Compliance, status.ErrorBudgetRemaining, status.Samples)
+		for _, burn := range status.BurnRates {
+			marker := ""
+			if burn.Firing {
+				marker = " FIRING"
+			}
+			result += fmt.Sprintf("  burn rate %s/%s: %.2fx/%.2fx (threshold %.1fx, %s)%s\n",
+				burn.LongWindow, burn.ShortWindow, burn.LongRate, burn.ShortRate, burn.Threshold, burn.Level, marker)
+		}
+	}
+	return result, nil
+}
+
+// handleAlertCommand 处理告警的确认、指派、解决、备注和时间线命令
+func (h *Handler) handleAlertCommand(action string, parts []string, actor string) (string, error) {
+	if h.monitoringService == nil {
+		return "", fmt.Errorf("monitoring service not available")
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("monitor %s command requires alert id", action)
+	}
+
+	id := parts[0]
+	var err error
+	switch action {
+	case "ack":
+		err = h.monitoringService.AckAlert(id, actor)
+	case "assign":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("monitor assign command requires alert id and assignee")
+		}
+		err = h.monitoringService.AssignAlert(id, actor, parts[1])
+	case "resolve":
+		err = h.monitoringService.ResolveAlert(id, actor)
+	case "comment":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("monitor comment command requires alert id and text")
+		}
+		err = h.monitoringService.CommentAlert(id, actor, strings.Join(parts[1:], " "))
+	case "timeline":
+		return h.alertTimeline(id)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	h.monitoringService.RecordAudit(actor, "alert."+action, id, strings.Join(parts[1:], " "))
+	return h.alertTimeline(id)
+}
+
+// alertTimeline 格式化告警时间线
+func (h *Handler) alertTimeline(id string) (string, error) {
+	alert, err := h.monitoringService.GetAlert(id)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Alert %s (%s) in cluster %s: %s\n", alert.ID, alert.Type, alert.Cluster, alert.Status)
+	for _, entry := range alert.Timeline {
+		result += fmt.Sprintf("- %s %s by %s", entry.Time.Format("2006-01-02 15:04:05"), entry.Action, entry.Actor)
+		if entry.Detail != "" {
+			result += ": " + entry.Detail
+		}
+		result += "\n"
+	}
+	return result, nil
+}
+
+// handleSilenceCommand 处理静默命令
+func (h *Handler) handleSilenceCommand(parts []string, actor string) (string, error) {
+	if h.monitoringService == nil {
+		return "", fmt.Errorf("monitoring service not available")
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("monitor silence command requires duration and matchers")
+	}
+
+	switch parts[0] {
+	case "list":
+		return h.listSilences(), nil
+	case "expire":
+		if len(parts) < 2 {
+			return "", fmt.Errorf("monitor silence expire command requires silence id")
+		}
+		if err := h.monitoringService.ExpireSilence(parts[1]); err != nil {
+			return "", err
+		}
+		h.monitoringService.RecordAudit(actor, "silence.expire", parts[1], "")
+		return fmt.Sprintf("Silence %s expired", parts[1]), nil
+	}
+
+	silence, err := parseSilence(parts, actor)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := h.monitoringService.CreateSilence(silence)
+	if err != nil {
+		return "", err
+	}
+	h.monitoringService.RecordAudit(created.CreatedBy, "silence.create", created.ID, created.Matcher.String())
+
+	return fmt.Sprintf("Created silence %s for %s until %s", created.ID, created.Matcher.String(),
+		created.EndsAt.Format("2006-01-02 15:04:05")), nil
+}
+
+// parseSilence 解析 <duration> <key=value>... [-- comment] 格式的静默参数，actor为静默的创建者
+func parseSilence(parts []string, actor string) (monitoring.Silence, error) {
+	duration, err := time.ParseDuration(parts[0])
+	if err != nil {
+		return monitoring.Silence{}, fmt.Errorf("invalid silence duration %q: %v", parts[0], err)
+	}
+
+	now := time.Now()
+	silence := monitoring.Silence{StartsAt: now, EndsAt: now.Add(duration), CreatedBy: actor}
+	for i, part := range parts[1:] {
+		if part == "--" {
+			silence.Comment = strings.Join(parts[i+2:], " ")
+			break
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" || value == "" {
+			return monitoring.Silence{}, fmt.Errorf("invalid silence matcher %q, expected key=value", part)
+		}
+		switch key {
+		case "cluster":
+			silence.Cluster = value
+		case "namespace":
+			silence.Namespace = value
+		case "node":
+			silence.Node = value
+		case "rule":
+			silence.Rule = value
+		default:
+			if silence.Labels == nil {
+				silence.Labels = make(map[string]string)
+			}
+			silence.Labels[key] = value
+		}
+	}
+
+	return silence, nil
+}
+
+// listSilences 列出生效中和未来生效的静默规则
+func (h *Handler) listSilences() string {
+	now := time.Now()
+	result := "Silences:\n"
+	count := 0
+	for _, silence := range h.monitoringService.GetSilences() {
+		if !silence.EndsAt.After(now) {
+			continue
+		}
+		count++
+		result += fmt.Sprintf("- %s: %s until %s by %s", silence.ID, silence.Matcher.String(),
+			silence.EndsAt.Format("2006-01-02 15:04:05"), silence.CreatedBy)
+		if silence.Comment != "" {
+			result += fmt.Sprintf(" (%s)", silence.Comment)
+		}
+		result += "\n"
+	}
+	if count == 0 {
+		return "No active silences"
+	}
+	return result
+}
@@ -1,9 +1,21 @@
 package ops_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/kubernetes"
+	"github.com/kudig-io/klaw/internal/messaging/dingtalk"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/monitoring"
 	"github.com/kudig-io/klaw/internal/ops"
+	"github.com/kudig-io/klaw/internal/storage"
 )
 
 func TestHandler_HandleCommand(t *testing.T) {
@@ -39,11 +51,16 @@ func TestHandler_HandleCommand(t *testing.T) {
 			command: "pod list test default",
 			wantErr: true,
 		},
+		{
+			name:    "events search without monitoring service",
+			command: "events search test reason=BackOff",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := handler.HandleCommand(tt.command)
+			result, err := handler.HandleCommand("", tt.command)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HandleCommand() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -56,7 +73,10 @@ func TestHandler_HandleCommand(t *testing.T) {
 
 func TestHandler_ShowHelp(t *testing.T) {
 	handler := ops.NewHandler(nil, nil)
-	help := handler.HandleCommand("help")
+	help, err := handler.HandleCommand("", "help")
+	if err != nil {
+		t.Fatalf("HandleCommand(help) error = %v", err)
+	}
 
 	if help == "" {
 		t.Error("ShowHelp() returned empty help message")
@@ -67,3 +87,113 @@ func TestHandler_ShowHelp(t *testing.T) {
 		t.Errorf("ShowHelp() = %v, want %v", help, expectedHelp)
 	}
 }
+
+// newChartHandler 创建带有最近一小时采样的处理器，钉钉接口由测试服务器模拟，机器人接口返回robotErrCode
+func newChartHandler(t *testing.T, robotErrCode int) *ops.Handler {
+	t.Helper()
+
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "klaw.db"), config.RetentionConfig{
+		Raw: 24 * time.Hour, FiveMinute: 24 * time.Hour, OneHour: 24 * time.Hour, Audit: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	now := time.Now()
+	for i := 30; i > 0; i-- {
+		err := store.AppendSample(&metrics.ClusterMetrics{
+			ClusterName: "prod",
+			Timestamp:   now.Add(-time.Duration(i) * time.Minute),
+			Resources:   metrics.ResourceMetrics{CPUCapacity: 100, CPUUsage: int64(i), MemoryCapacity: 100, MemoryUsage: 50},
+		})
+		if err != nil {
+			t.Fatalf("AppendSample() error = %v", err)
+		}
+	}
+
+	manager := kubernetes.NewManagerWithClients([]config.ClusterConfig{{Name: "prod"}}, nil)
+	service := monitoring.NewService(manager)
+	if err := service.SetStore(store); err != nil {
+		t.Fatalf("SetStore() error = %v", err)
+	}
+
+	// 钉钉在签名错误等情况下返回HTTP 200，错误信息在响应体的errcode中
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 0, "access_token": "token", "expires_in": 7200})
+		case "/media/upload":
+			json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 0, "media_id": "@lADPchart"})
+		case "/robot/send":
+			json.NewEncoder(w).Encode(map[string]interface{}{"errcode": robotErrCode, "errmsg": "sign not match"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := dingtalk.NewClient(config.DingTalkConfig{
+		AppKey:    "key",
+		AppSecret: "secret",
+		Webhook:   server.URL + "/robot/send?access_token=robot",
+		Secret:    "SEC",
+		APIBase:   server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	handler := ops.NewHandler(manager, service)
+	handler.SetDingTalkClient(client)
+	return handler
+}
+
+func TestHandler_ClusterChartFallback(t *testing.T) {
+	result, err := newChartHandler(t, 0).HandleCommand("alice", "cluster chart prod")
+	if err != nil {
+		t.Fatalf("HandleCommand() error = %v", err)
+	}
+	if result != "Sent monitoring chart for cluster prod" {
+		t.Errorf("expected chart image to be sent, got %q", result)
+	}
+
+	result, err = newChartHandler(t, 310000).HandleCommand("alice", "cluster chart prod")
+	if err != nil {
+		t.Fatalf("HandleCommand() error = %v", err)
+	}
+	if !strings.HasPrefix(result, "Failed to send chart image") || !strings.Contains(result, "errcode: 310000") {
+		t.Errorf("expected errcode in the fallback reason, got %q", result)
+	}
+	if !strings.Contains(result, "Cluster - prod") || !strings.ContainsAny(result, "▁▂▃▄▅▆▇█") {
+		t.Errorf("expected text chart fallback, got %q", result)
+	}
+}
+
+func TestHandler_RecordsSender(t *testing.T) {
+	manager := kubernetes.NewManagerWithClients([]config.ClusterConfig{{Name: "prod"}}, nil)
+	service := monitoring.NewService(manager)
+	service.RunOnce()
+	alerts := service.GetAlerts()
+	if len(alerts) != 1 {
+		t.Fatalf("expected a ClusterUnreachable alert, got %+v", alerts)
+	}
+	handler := ops.NewHandler(manager, service)
+
+	if _, err := handler.HandleCommand("dingtalk:alice", "monitor ack "+alerts[0].ID); err != nil {
+		t.Fatalf("HandleCommand(ack) error = %v", err)
+	}
+	timeline, err := handler.HandleCommand("", "monitor comment "+alerts[0].ID+" looking into it")
+	if err != nil {
+		t.Fatalf("HandleCommand(comment) error = %v", err)
+	}
+	if !strings.Contains(timeline, "acknowledged by dingtalk:alice") || !strings.Contains(timeline, "commented by chatops") {
+		t.Errorf("expected sender in the alert timeline, got %s", timeline)
+	}
+
+	if _, err := handler.HandleCommand("feishu:ou_bob", "monitor silence 1h cluster=prod"); err != nil {
+		t.Fatalf("HandleCommand(silence) error = %v", err)
+	}
+	if silences := service.GetSilences(); len(silences) != 1 || silences[0].CreatedBy != "feishu:ou_bob" {
+		t.Errorf("expected silence created by the sender, got %+v", silences)
+	}
+}
@@ -0,0 +1,273 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// Client Prometheus HTTP API客户端
+type Client struct {
+	baseURL      string
+	clusterLabel string
+	httpClient   *http.Client
+}
+
+// NewClient 创建Prometheus客户端
+func NewClient(cfg config.PrometheusConfig) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("prometheus url is required")
+	}
+
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid prometheus url: %v", err)
+	}
+
+	return &Client{
+		baseURL:      strings.TrimRight(cfg.URL, "/"),
+		clusterLabel: cfg.ClusterLabel,
+		httpClient:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Point 时间序列上的一个采样点
+type Point struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// SampleStream range query返回的一条时间序列
+type SampleStream struct {
+	Metric map[string]string
+	Points []Point
+}
+
+// apiResponse Prometheus HTTP API响应
+type apiResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange 执行range query
+func (c *Client) QueryRange(query string, start, end time.Time, step time.Duration) ([]SampleStream, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatTime(start))
+	params.Set("end", formatTime(end))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	resp, err := c.httpClient.PostForm(c.baseURL+"/api/v1/query_range", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode prometheus response, status code: %d: %v", resp.StatusCode, err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s: %s", response.ErrorType, response.Error)
+	}
+
+	if response.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("unexpected prometheus result type: %s", response.Data.ResultType)
+	}
+
+	streams := make([]SampleStream, 0, len(response.Data.Result))
+	for _, result := range response.Data.Result {
+		stream := SampleStream{
+			Metric: result.Metric,
+			Points: make([]Point, 0, len(result.Values)),
+		}
+		for _, value := range result.Values {
+			point, err := parsePoint(value)
+			if err != nil {
+				return nil, err
+			}
+			stream.Points = append(stream.Points, point)
+		}
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
+
+// historyQuery 构建集群历史指标使用的查询及其结果的写入方式
+type historyQuery struct {
+	query string
+	apply func(m *metrics.ClusterMetrics, labels map[string]string, value float64)
+}
+
+// historyQueries 基于kube-state-metrics和cAdvisor指标的集群历史查询
+func (c *Client) historyQueries(clusterName string) []historyQuery {
+	sel := c.selector(clusterName)
+
+	return []historyQuery{
+		{
+			query: fmt.Sprintf(`count(kube_node_info%s)`, sel()),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) { m.Nodes.Total = int(v) },
+		},
+		{
+			query: fmt.Sprintf(`sum(kube_node_status_condition%s)`, sel(`condition="Ready"`, `status="true"`)),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) { m.Nodes.Ready = int(v) },
+		},
+		{
+			query: fmt.Sprintf(`sum by (phase) (kube_pod_status_phase%s)`, sel()),
+			apply: func(m *metrics.ClusterMetrics, labels map[string]string, v float64) {
+				switch labels["phase"] {
+				case "Running":
+					m.Pods.Running = int(v)
+				case "Pending":
+					m.Pods.Pending = int(v)
+				case "Failed":
+					m.Pods.Failed = int(v)
+				case "Succeeded":
+					m.Pods.Succeeded = int(v)
+				}
+				m.Pods.Total += int(v)
+			},
+		},
+		{
+			query: fmt.Sprintf(`sum(kube_pod_container_status_restarts_total%s)`, sel()),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) { m.Pods.Restarts = int32(v) },
+		},
+		{
+			query: fmt.Sprintf(`sum(kube_node_status_capacity%s)`, sel(`resource="cpu"`)),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) {
+				m.Resources.CPUCapacity = int64(v * 1000)
+			},
+		},
+		{
+			query: fmt.Sprintf(`sum(kube_node_status_capacity%s)`, sel(`resource="memory"`)),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) { m.Resources.MemoryCapacity = int64(v) },
+		},
+		{
+			query: fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total%s[5m]))`, sel(`container!=""`)),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) {
+				m.Resources.CPUUsage = int64(v * 1000)
+			},
+		},
+		{
+			query: fmt.Sprintf(`sum(container_memory_working_set_bytes%s)`, sel(`container!=""`)),
+			apply: func(m *metrics.ClusterMetrics, _ map[string]string, v float64) { m.Resources.MemoryUsage = int64(v) },
+		},
+		{
+			query: fmt.Sprintf(`100 * sum by (node) (rate(container_cpu_usage_seconds_total%s[5m])) / sum by (node) (kube_node_status_allocatable%s)`,
+				sel(`container!=""`), sel(`resource="cpu"`)),
+			apply: func(m *metrics.ClusterMetrics, labels map[string]string, v float64) {
+				nodeDetail(m, labels["node"]).CPUUsagePercent = v
+			},
+		},
+		{
+			query: fmt.Sprintf(`100 * sum by (node) (container_memory_working_set_bytes%s) / sum by (node) (kube_node_status_allocatable%s)`,
+				sel(`container!=""`), sel(`resource="memory"`)),
+			apply: func(m *metrics.ClusterMetrics, labels map[string]string, v float64) {
+				nodeDetail(m, labels["node"]).MemoryUsagePercent = v
+			},
+		},
+	}
+}
+
+// QueryClusterHistory 通过range query重建集群在指定时间范围内的指标历史
+func (c *Client) QueryClusterHistory(clusterName string, start, end time.Time, step time.Duration) ([]*metrics.ClusterMetrics, error) {
+	samples := make(map[int64]*metrics.ClusterMetrics)
+
+	for _, q := range c.historyQueries(clusterName) {
+		streams, err := c.QueryRange(q.query, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stream := range streams {
+			for _, point := range stream.Points {
+				key := point.Timestamp.UnixMilli()
+				m, ok := samples[key]
+				if !ok {
+					m = &metrics.ClusterMetrics{ClusterName: clusterName, Timestamp: point.Timestamp}
+					samples[key] = m
+				}
+				q.apply(m, stream.Metric, point.Value)
+			}
+		}
+	}
+
+	history := make([]*metrics.ClusterMetrics, 0, len(samples))
+	for _, m := range samples {
+		m.Nodes.NotReady = m.Nodes.Total - m.Nodes.Ready
+		history = append(history, m)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+
+	return history, nil
+}
+
+// selector 返回构建标签选择器的函数，配置了集群标签时自动追加集群匹配条件
+func (c *Client) selector(clusterName string) func(matchers ...string) string {
+	return func(matchers ...string) string {
+		if c.clusterLabel != "" {
+			matchers = append(matchers, fmt.Sprintf(`%s=%q`, c.clusterLabel, clusterName))
+		}
+		if len(matchers) == 0 {
+			return ""
+		}
+		return "{" + strings.Join(matchers, ",") + "}"
+	}
+}
+
+// nodeDetail 获取或创建节点详情
+func nodeDetail(m *metrics.ClusterMetrics, name string) *metrics.NodeDetail {
+	for i := range m.Nodes.Details {
+		if m.Nodes.Details[i].Name == name {
+			return &m.Nodes.Details[i]
+		}
+	}
+	m.Nodes.Details = append(m.Nodes.Details, metrics.NodeDetail{Name: name})
+	return &m.Nodes.Details[len(m.Nodes.Details)-1]
+}
+
+// parsePoint 解析 [<unix时间>, "<值>"] 格式的采样点
+func parsePoint(value [2]interface{}) (Point, error) {
+	timestamp, ok := value[0].(float64)
+	if !ok {
+		return Point{}, fmt.Errorf("invalid prometheus timestamp: %v", value[0])
+	}
+
+	raw, ok := value[1].(string)
+	if !ok {
+		return Point{}, fmt.Errorf("invalid prometheus sample value: %v", value[1])
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid prometheus sample value: %v", err)
+	}
+
+	return Point{
+		Timestamp: time.UnixMilli(int64(math.Round(timestamp * 1000))),
+		Value:     v,
+	}, nil
+}
+
+// formatTime 格式化为Prometheus API接受的unix时间
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
@@ -0,0 +1,141 @@
+package prometheus_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/prometheus"
+)
+
+// newPrometheusServer 模拟Prometheus的 /api/v1/query_range 接口，按查询中包含的指标名返回固定结果
+func newPrometheusServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+
+		query := r.Form.Get("query")
+		for metric, result := range results {
+			if strings.Contains(query, metric) {
+				fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[%s]}}`, result)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unknown query"}`)
+	}))
+}
+
+func TestClient_QueryRange(t *testing.T) {
+	server := newPrometheusServer(t, map[string]string{
+		"up": `{"metric":{"job":"kubelet"},"values":[[1700000000,"1"],[1700000030.5,"0"]]}`,
+	})
+	defer server.Close()
+
+	client, err := prometheus.NewClient(config.PrometheusConfig{URL: server.URL, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	streams, err := client.QueryRange("up", time.Unix(1700000000, 0), time.Unix(1700000060, 0), 30*time.Second)
+	if err != nil {
+		t.Fatalf("QueryRange() error = %v", err)
+	}
+
+	if len(streams) != 1 || len(streams[0].Points) != 2 {
+		t.Fatalf("unexpected streams: %+v", streams)
+	}
+	if streams[0].Metric["job"] != "kubelet" {
+		t.Errorf("expected job label kubelet, got %q", streams[0].Metric["job"])
+	}
+	if got := streams[0].Points[1].Timestamp.UnixMilli(); got != 1700000030500 {
+		t.Errorf("expected timestamp 1700000030500, got %d", got)
+	}
+
+	if _, err := client.QueryRange("unknown_metric", time.Unix(0, 0), time.Unix(60, 0), time.Minute); err == nil {
+		t.Error("expected error for failed query")
+	}
+}
+
+func TestClient_QueryClusterHistory(t *testing.T) {
+	server := newPrometheusServer(t, map[string]string{
+		"kube_node_info":             `{"metric":{},"values":[[1700000000,"3"],[1700000060,"3"]]}`,
+		"kube_node_status_condition": `{"metric":{},"values":[[1700000000,"3"],[1700000060,"2"]]}`,
+		"kube_pod_status_phase": `{"metric":{"phase":"Running"},"values":[[1700000000,"10"],[1700000060,"9"]]},` +
+			`{"metric":{"phase":"Failed"},"values":[[1700000000,"0"],[1700000060,"1"]]}`,
+		"kube_pod_container_status_restarts_total": `{"metric":{},"values":[[1700000000,"4"],[1700000060,"7"]]}`,
+		"kube_node_status_capacity":                `{"metric":{},"values":[[1700000000,"8"],[1700000060,"8"]]}`,
+		"container_cpu_usage_seconds_total":        `{"metric":{"node":"node-1"},"values":[[1700000000,"42.5"]]}`,
+		"container_memory_working_set_bytes":       `{"metric":{"node":"node-1"},"values":[[1700000000,"1024"]]}`,
+	})
+	defer server.Close()
+
+	client, err := prometheus.NewClient(config.PrometheusConfig{URL: server.URL, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	history, err := client.QueryClusterHistory("prod", time.Unix(1700000000, 0), time.Unix(1700000060, 0), time.Minute)
+	if err != nil {
+		t.Fatalf("QueryClusterHistory() error = %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(history))
+	}
+
+	latest := history[1]
+	if latest.ClusterName != "prod" || latest.Timestamp.Unix() != 1700000060 {
+		t.Errorf("unexpected sample identity: %s at %v", latest.ClusterName, latest.Timestamp)
+	}
+	if latest.Nodes.Total != 3 || latest.Nodes.Ready != 2 || latest.Nodes.NotReady != 1 {
+		t.Errorf("unexpected node counts: %+v", latest.Nodes)
+	}
+	if latest.Pods.Running != 9 || latest.Pods.Failed != 1 || latest.Pods.Total != 10 {
+		t.Errorf("unexpected pod counts: %+v", latest.Pods)
+	}
+	if latest.Pods.Restarts != 7 {
+		t.Errorf("expected 7 restarts, got %d", latest.Pods.Restarts)
+	}
+	if history[0].Resources.CPUCapacity != 8000 {
+		t.Errorf("expected 8000m CPU capacity, got %d", history[0].Resources.CPUCapacity)
+	}
+	if len(history[0].Nodes.Details) != 1 || history[0].Nodes.Details[0].CPUUsagePercent != 42.5 {
+		t.Errorf("unexpected node details: %+v", history[0].Nodes.Details)
+	}
+}
+
+func TestClient_ClusterLabelSelector(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		queries = append(queries, r.Form.Get("query"))
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[]}}`)
+	}))
+	defer server.Close()
+
+	client, err := prometheus.NewClient(config.PrometheusConfig{URL: server.URL, ClusterLabel: "cluster", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.QueryClusterHistory("prod", time.Unix(0, 0), time.Unix(60, 0), time.Minute); err != nil {
+		t.Fatalf("QueryClusterHistory() error = %v", err)
+	}
+
+	for _, query := range queries {
+		if !strings.Contains(query, `cluster="prod"`) {
+			t.Errorf("query is missing cluster selector: %s", query)
+		}
+	}
+}
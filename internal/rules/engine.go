@@ -0,0 +1,262 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// 规则实例状态
+const (
+	StatePending = "pending"
+	StateFiring  = "firing"
+)
+
+// Instance 规则在某个对象上的一次命中，条件持续满足For时长后由pending转为firing
+type Instance struct {
+	Rule        string            `json:"rule"`
+	Cluster     string            `json:"cluster"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Node        string            `json:"node,omitempty"`
+	Kind        string            `json:"kind,omitempty"`
+	Workload    string            `json:"workload,omitempty"`
+	Severity    string            `json:"severity"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	State       string            `json:"state"`
+	ActiveSince time.Time         `json:"active_since"`
+	Value       float64           `json:"value"`
+	Message     string            `json:"message"`
+}
+
+// RuleState 规则及其当前命中的实例
+type RuleState struct {
+	Name      string            `json:"name"`
+	Scope     string            `json:"scope"`
+	Expr      string            `json:"expr"`
+	For       string            `json:"for"`
+	Severity  string            `json:"severity"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Instances []Instance        `json:"instances"`
+}
+
+// Engine 告警规则引擎，记录每条规则各实例的pending/firing状态
+type Engine struct {
+	mutex  sync.RWMutex
+	rules  []*Rule
+	active map[string]*Instance
+}
+
+// NewEngine 创建告警规则引擎
+func NewEngine(rules []*Rule) *Engine {
+	return &Engine{
+		rules:  rules,
+		active: make(map[string]*Instance),
+	}
+}
+
+// SetRules 替换规则，已删除规则的实例状态一并清除
+func (e *Engine) SetRules(rules []*Rule) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		names[rule.Name] = true
+	}
+	for key, inst := range e.active {
+		if !names[inst.Rule] {
+			delete(e.active, key)
+		}
+	}
+	e.rules = rules
+}
+
+// Evaluate 基于集群最新指标计算所有规则，返回该集群当前处于firing状态的实例
+func (e *Engine) Evaluate(clusterName string, m *metrics.ClusterMetrics, now time.Time) []Instance {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	seen := make(map[string]bool)
+	var firing []Instance
+
+	for _, rule := range e.rules {
+		for _, target := range targets(rule, clusterName, m) {
+			matched, value, err := rule.Expr.Eval(target.lookup)
+			if err != nil {
+				fmt.Printf("Failed to evaluate rule %s: %v\n", rule.Name, err)
+				continue
+			}
+			if !matched {
+				continue
+			}
+
+			key := rule.Name + "|" + clusterName + "|" + target.key()
+			seen[key] = true
+
+			inst, ok := e.active[key]
+			if !ok {
+				inst = &Instance{
+					Rule:        rule.Name,
+					Cluster:     clusterName,
+					Namespace:   target.data.Namespace,
+					Node:        target.data.Node,
+					Kind:        target.data.Kind,
+					Workload:    target.data.Workload,
+					Severity:    rule.Severity,
+					Labels:      target.labels(rule),
+					State:       StatePending,
+					ActiveSince: now,
+				}
+				e.active[key] = inst
+			}
+
+			target.data.Value = value
+			target.data.Labels = inst.Labels
+			inst.Value = value
+			inst.Message = rule.render(target.data)
+			if now.Sub(inst.ActiveSince) >= rule.For {
+				inst.State = StateFiring
+			}
+			if inst.State == StateFiring {
+				firing = append(firing, *inst)
+			}
+		}
+	}
+
+	// 条件不再满足的实例恢复为未命中
+	for key, inst := range e.active {
+		if inst.Cluster == clusterName && !seen[key] {
+			delete(e.active, key)
+		}
+	}
+
+	return firing
+}
+
+// States 返回所有规则及其当前命中的实例
+func (e *Engine) States() []RuleState {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	states := make([]RuleState, 0, len(e.rules))
+	index := make(map[string]int)
+	for _, rule := range e.rules {
+		index[rule.Name] = len(states)
+		states = append(states, RuleState{
+			Name:      rule.Name,
+			Scope:     rule.Scope,
+			Expr:      rule.Expr.String(),
+			For:       rule.For.String(),
+			Severity:  rule.Severity,
+			Labels:    rule.Labels,
+			Instances: []Instance{},
+		})
+	}
+
+	for _, inst := range e.active {
+		if i, ok := index[inst.Rule]; ok {
+			states[i].Instances = append(states[i].Instances, *inst)
+		}
+	}
+	for i := range states {
+		sort.Slice(states[i].Instances, func(a, b int) bool {
+			return states[i].Instances[a].ActiveSince.Before(states[i].Instances[b].ActiveSince)
+		})
+	}
+
+	return states
+}
+
+// target 规则计算的对象
+type target struct {
+	data   TemplateData
+	lookup func(field string) (float64, bool)
+}
+
+// key 对象在集群内的唯一标识
+func (t target) key() string {
+	switch {
+	case t.data.Workload != "":
+		return t.data.Namespace + "/" + t.data.Kind + "/" + t.data.Workload
+	case t.data.Node != "":
+		return "node/" + t.data.Node
+	case t.data.Namespace != "":
+		return t.data.Namespace
+	}
+	return ""
+}
+
+// labels 规则标签加上对象自身的标签
+func (t target) labels(rule *Rule) map[string]string {
+	labels := make(map[string]string, len(rule.Labels)+3)
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	if t.data.Namespace != "" {
+		labels["namespace"] = t.data.Namespace
+	}
+	if t.data.Node != "" {
+		labels["node"] = t.data.Node
+	}
+	if t.data.Workload != "" {
+		labels["kind"] = t.data.Kind
+		labels["workload"] = t.data.Workload
+	}
+	return labels
+}
+
+// targets 按规则的作用范围展开计算对象
+func targets(rule *Rule, clusterName string, m *metrics.ClusterMetrics) []target {
+	var result []target
+
+	switch rule.Scope {
+	case ScopeCluster:
+		result = append(result, target{
+			data:   TemplateData{Cluster: clusterName},
+			lookup: m.Field,
+		})
+	case ScopeNode:
+		for i := range m.Nodes.Details {
+			node := &m.Nodes.Details[i]
+			result = append(result, target{
+				data:   TemplateData{Cluster: clusterName, Node: node.Name},
+				lookup: node.Field,
+			})
+		}
+	case ScopeNamespace:
+		for i := range m.Namespaces {
+			ns := &m.Namespaces[i]
+			if !rule.matchNamespace(ns.Name) {
+				continue
+			}
+			result = append(result, target{
+				data:   TemplateData{Cluster: clusterName, Namespace: ns.Name},
+				lookup: ns.Field,
+			})
+		}
+	case ScopeWorkload:
+		for i := range m.Namespaces {
+			ns := &m.Namespaces[i]
+			if !rule.matchNamespace(ns.Name) {
+				continue
+			}
+			for j := range ns.Workloads {
+				workload := &ns.Workloads[j]
+				result = append(result, target{
+					data: TemplateData{
+						Cluster:   clusterName,
+						Namespace: ns.Name,
+						Kind:      workload.Kind,
+						Workload:  workload.Name,
+					},
+					lookup: workload.Field,
+				})
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,430 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr 编译后的规则表达式
+// 表达式支持数值、字段名、算术运算（+ - * /）、比较运算（> >= < <= == !=）、
+// 逻辑运算（and or not，也可写作 && || !）和括号，比较和逻辑运算的结果为1或0
+type Expr struct {
+	source string
+	root   node
+	// value 第一个比较运算的左侧，作为告警的当前值
+	value node
+}
+
+// ParseExpr 解析规则表达式
+func ParseExpr(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q in expression %q", p.tokens[p.pos].text, source)
+	}
+
+	expr := &Expr{source: source, root: root, value: p.firstComparisonLeft}
+	if expr.value == nil {
+		expr.value = root
+	}
+	return expr, nil
+}
+
+// String 返回表达式原文
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Fields 返回表达式中引用的字段名
+func (e *Expr) Fields() []string {
+	var fields []string
+	seen := make(map[string]bool)
+	walk(e.root, func(n node) {
+		if f, ok := n.(fieldNode); ok && !seen[string(f)] {
+			seen[string(f)] = true
+			fields = append(fields, string(f))
+		}
+	})
+	return fields
+}
+
+// Eval 计算表达式，返回条件是否成立以及告警的当前值
+func (e *Expr) Eval(lookup func(field string) (float64, bool)) (bool, float64, error) {
+	result, err := e.root.eval(lookup)
+	if err != nil {
+		return false, 0, err
+	}
+	value, err := e.value.eval(lookup)
+	if err != nil {
+		return false, 0, err
+	}
+	return result != 0, value, nil
+}
+
+// node 表达式语法树节点
+type node interface {
+	eval(lookup func(field string) (float64, bool)) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(func(string) (float64, bool)) (float64, error) {
+	return float64(n), nil
+}
+
+type fieldNode string
+
+func (n fieldNode) eval(lookup func(string) (float64, bool)) (float64, error) {
+	v, ok := lookup(string(n))
+	if !ok {
+		return 0, fmt.Errorf("unknown field: %s", string(n))
+	}
+	return v, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(lookup func(string) (float64, bool)) (float64, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	return boolValue(v == 0), nil
+}
+
+type negNode struct {
+	operand node
+}
+
+func (n negNode) eval(lookup func(string) (float64, bool)) (float64, error) {
+	v, err := n.operand.eval(lookup)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(lookup func(string) (float64, bool)) (float64, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	// 逻辑运算短路求值
+	switch n.op {
+	case "and":
+		if l == 0 {
+			return 0, nil
+		}
+	case "or":
+		if l != 0 {
+			return 1, nil
+		}
+	}
+
+	r, err := n.right.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "and", "or":
+		return boolValue(r != 0), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, nil
+		}
+		return l / r, nil
+	case ">":
+		return boolValue(l > r), nil
+	case ">=":
+		return boolValue(l >= r), nil
+	case "<":
+		return boolValue(l < r), nil
+	case "<=":
+		return boolValue(l <= r), nil
+	case "==":
+		return boolValue(l == r), nil
+	case "!=":
+		return boolValue(l != r), nil
+	}
+	return 0, fmt.Errorf("unknown operator: %s", n.op)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// walk 遍历语法树
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch v := n.(type) {
+	case notNode:
+		walk(v.operand, fn)
+	case negNode:
+		walk(v.operand, fn)
+	case binaryNode:
+		walk(v.left, fn)
+		walk(v.right, fn)
+	}
+}
+
+// token 词法单元
+type token struct {
+	kind string // number、ident、op
+	text string
+}
+
+// tokenize 将表达式切分为词法单元
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: "number", text: string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			switch strings.ToLower(text) {
+			case "and", "or", "not":
+				tokens = append(tokens, token{kind: "op", text: strings.ToLower(text)})
+			default:
+				tokens = append(tokens, token{kind: "ident", text: text})
+			}
+		default:
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case ">=", "<=", "==", "!=":
+					tokens = append(tokens, token{kind: "op", text: two})
+					i += 2
+					continue
+				case "&&":
+					tokens = append(tokens, token{kind: "op", text: "and"})
+					i += 2
+					continue
+				case "||":
+					tokens = append(tokens, token{kind: "op", text: "or"})
+					i += 2
+					continue
+				}
+			}
+			switch r {
+			case '>', '<', '+', '-', '*', '/', '(', ')':
+				tokens = append(tokens, token{kind: "op", text: string(r)})
+			case '!':
+				tokens = append(tokens, token{kind: "op", text: "not"})
+			default:
+				return nil, fmt.Errorf("unexpected character %q in expression %q", r, source)
+			}
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+// parser 递归下降解析器，优先级从低到高为 or、and、not、比较、加减、乘除、一元负号
+type parser struct {
+	tokens              []token
+	pos                 int
+	firstComparisonLeft node
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if t == nil || t.kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "or", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "and", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.acceptOp("not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp(">", ">=", "<", "<=", "==", "!=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.firstComparisonLeft == nil {
+		p.firstComparisonLeft = left
+	}
+	return binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.acceptOp("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	switch {
+	case t.kind == "number":
+		p.pos++
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %v", t.text, err)
+		}
+		return numberNode(v), nil
+	case t.kind == "ident":
+		p.pos++
+		return fieldNode(t.text), nil
+	case t.kind == "op" && t.text == "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.acceptOp(")"); !ok {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+
+	return nil, fmt.Errorf("unexpected token %q", t.text)
+}
@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// 规则作用范围
+const (
+	ScopeCluster   = "cluster"
+	ScopeNamespace = "namespace"
+	ScopeNode      = "node"
+	ScopeWorkload  = "workload"
+)
+
+// Rule 编译后的告警规则
+type Rule struct {
+	Name       string
+	Scope      string
+	Expr       *Expr
+	For        time.Duration
+	Severity   string
+	Labels     map[string]string
+	Namespaces []string
+	message    *template.Template
+}
+
+// TemplateData 告警消息模板可用的数据
+type TemplateData struct {
+	Cluster   string
+	Namespace string
+	Node      string
+	Kind      string
+	Workload  string
+	Value     float64
+	Labels    map[string]string
+}
+
+// Compile 编译告警规则，校验作用范围、表达式字段和消息模板
+func Compile(cfg config.AlertRuleConfig) (*Rule, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("rule name is required")
+	}
+
+	scope := cfg.Scope
+	if scope == "" {
+		scope = ScopeCluster
+	}
+
+	var fields []string
+	switch scope {
+	case ScopeCluster:
+		fields = metrics.FieldNames()
+	case ScopeNamespace, ScopeWorkload:
+		fields = metrics.NamespaceFieldNames()
+	case ScopeNode:
+		fields = metrics.NodeFieldNames()
+	default:
+		return nil, fmt.Errorf("rule %s: unknown scope: %s", cfg.Name, scope)
+	}
+
+	expr, err := ParseExpr(cfg.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: invalid expression: %v", cfg.Name, err)
+	}
+	for _, field := range expr.Fields() {
+		if !contains(fields, field) {
+			return nil, fmt.Errorf("rule %s: field %s is not available in %s scope", cfg.Name, field, scope)
+		}
+	}
+
+	message := cfg.Message
+	if message == "" {
+		message = fmt.Sprintf("%s: %s (current value {{ .Value }})", cfg.Name, cfg.Expr)
+	}
+	tmpl, err := template.New(cfg.Name).Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: invalid message template: %v", cfg.Name, err)
+	}
+
+	severity := cfg.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+
+	return &Rule{
+		Name:       cfg.Name,
+		Scope:      scope,
+		Expr:       expr,
+		For:        cfg.For,
+		Severity:   severity,
+		Labels:     cfg.Labels,
+		Namespaces: cfg.Namespaces,
+		message:    tmpl,
+	}, nil
+}
+
+// CompileAll 编译一组告警规则，规则名不能重复
+func CompileAll(cfgs []config.AlertRuleConfig) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(cfgs))
+	seen := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		rule, err := Compile(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// DefaultRules 未配置告警规则时使用的默认规则，节点就绪由监控服务的节点检测器逐个节点检查
+func DefaultRules() []config.AlertRuleConfig {
+	return []config.AlertRuleConfig{
+		{
+			Name:     "PodsFailed",
+			Scope:    ScopeNamespace,
+			Expr:     "pods.failed > 0",
+			Severity: "critical",
+			Message:  "{{ .Value }} pods have failed in namespace {{ .Namespace }}",
+		},
+		{
+			Name:     "PodsPending",
+			Scope:    ScopeCluster,
+			Expr:     "pods.pending > 10",
+			Severity: "warning",
+			Message:  "{{ .Value }} pods are pending",
+		},
+	}
+}
+
+// render 渲染告警消息
+func (r *Rule) render(data TemplateData) string {
+	var buf bytes.Buffer
+	if err := r.message.Execute(&buf, data); err != nil {
+		return fmt.Sprintf("%s (failed to render message: %v)", r.Name, err)
+	}
+	return buf.String()
+}
+
+// matchNamespace 判断规则是否对命名空间生效
+func (r *Rule) matchNamespace(namespace string) bool {
+	return len(r.Namespaces) == 0 || contains(r.Namespaces, namespace)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Defaults 返回编译后的默认规则
+func Defaults() []*Rule {
+	rules, err := CompileAll(DefaultRules())
+	if err != nil {
+		panic(fmt.Sprintf("invalid default rules: %v", err))
+	}
+	return rules
+}
@@ -0,0 +1,132 @@
+package rules_test
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/rules"
+)
+
+func TestParseExpr(t *testing.T) {
+	fields := map[string]float64{"pods.failed": 2, "pods.total": 10, "nodes.ready": 3}
+	lookup := func(field string) (float64, bool) {
+		v, ok := fields[field]
+		return v, ok
+	}
+
+	tests := []struct {
+		expr    string
+		matched bool
+		value   float64
+	}{
+		{"pods.failed > 0", true, 2},
+		{"pods.failed / pods.total * 100 >= 25", false, 20},
+		{"pods.failed > 5 or nodes.ready < 4", true, 2},
+		{"not (pods.failed > 0) && pods.total > 0", false, 2},
+		{"-pods.failed + 2 == 0", true, 0},
+	}
+
+	for _, tt := range tests {
+		expr, err := rules.ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q) error = %v", tt.expr, err)
+		}
+		matched, value, err := expr.Eval(lookup)
+		if err != nil {
+			t.Fatalf("Eval(%q) error = %v", tt.expr, err)
+		}
+		if matched != tt.matched || value != tt.value {
+			t.Errorf("Eval(%q) = %v, %v; want %v, %v", tt.expr, matched, value, tt.matched, tt.value)
+		}
+	}
+
+	for _, invalid := range []string{"pods.failed >", "(pods.failed > 0", "pods.failed $ 1"} {
+		if _, err := rules.ParseExpr(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestCompile_UnknownField(t *testing.T) {
+	_, err := rules.Compile(config.AlertRuleConfig{Name: "Bad", Scope: "node", Expr: "pods.failed > 0"})
+	if err == nil {
+		t.Error("expected error for field outside of rule scope")
+	}
+}
+
+func TestEngine_ForDuration(t *testing.T) {
+	compiled, err := rules.CompileAll([]config.AlertRuleConfig{
+		{
+			Name:     "WorkloadNotReady",
+			Scope:    "workload",
+			Expr:     "pods.not_ready > 0",
+			For:      time.Minute,
+			Severity: "critical",
+			Labels:   map[string]string{"team": "web"},
+			Message:  "{{ .Kind }} {{ .Namespace }}/{{ .Workload }} has {{ .Value }} pods not ready",
+		},
+		{
+			Name:    "NodeNotReady",
+			Scope:   "node",
+			Expr:    "ready == 0",
+			Message: "Node {{ .Node }} is not ready",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CompileAll() error = %v", err)
+	}
+	engine := rules.NewEngine(compiled)
+
+	m := &metrics.ClusterMetrics{
+		Nodes: metrics.NodeMetricsSummary{Details: []metrics.NodeDetail{
+			{Name: "node-1", Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		}},
+		Namespaces: []metrics.NamespaceMetrics{{
+			Name: "default",
+			Workloads: []metrics.WorkloadMetrics{
+				{Kind: "Deployment", Name: "web", Namespace: "default", Pods: metrics.PodCounts{Total: 3, Ready: 1}},
+				{Kind: "Deployment", Name: "api", Namespace: "default", Pods: metrics.PodCounts{Total: 2, Ready: 2}},
+			},
+		}},
+	}
+
+	start := time.Now()
+	if firing := engine.Evaluate("prod", m, start); len(firing) != 0 {
+		t.Fatalf("expected no firing instances before for duration, got %d", len(firing))
+	}
+
+	firing := engine.Evaluate("prod", m, start.Add(time.Minute))
+	if len(firing) != 1 {
+		t.Fatalf("expected 1 firing instance, got %d", len(firing))
+	}
+	inst := firing[0]
+	if inst.Message != "Deployment default/web has 2 pods not ready" {
+		t.Errorf("unexpected message: %s", inst.Message)
+	}
+	if inst.Labels["team"] != "web" || inst.Labels["workload"] != "web" {
+		t.Errorf("unexpected labels: %v", inst.Labels)
+	}
+
+	// 条件恢复后实例被清除
+	m.Namespaces[0].Workloads[0].Pods.Ready = 3
+	engine.Evaluate("prod", m, start.Add(2*time.Minute))
+	for _, state := range engine.States() {
+		if len(state.Instances) != 0 {
+			t.Errorf("expected no active instances for %s, got %d", state.Name, len(state.Instances))
+		}
+	}
+}
+
+func TestExampleRulesCompile(t *testing.T) {
+	cfgs, err := config.LoadRules("../../../configs/rules.yaml.example")
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if _, err := rules.CompileAll(cfgs); err != nil {
+		t.Errorf("CompileAll() error = %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+var (
+	// 指标采样按精度分为三级，每级下按集群名建立子bucket
+	bucketRaw        = []byte("samples_raw")
+	bucketFiveMinute = []byte("samples_5m")
+	bucketOneHour    = []byte("samples_1h")
+	bucketAudit      = []byte("audit")
+	bucketRecords    = []byte("records")
+)
+
+// BoltStore 基于bbolt的嵌入式文件存储
+type BoltStore struct {
+	db        *bolt.DB
+	retention config.RetentionConfig
+}
+
+// NewBoltStore 创建bbolt存储，文件不存在时自动创建
+func NewBoltStore(path string, retention config.RetentionConfig) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketRaw, bucketFiveMinute, bucketOneHour, bucketAudit, bucketRecords, bucketEvents} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize storage: %v", err)
+	}
+
+	return &BoltStore{db: db, retention: retention}, nil
+}
+
+// AppendSample 写入一个原始指标采样
+func (s *BoltStore) AppendSample(m *metrics.ClusterMetrics) error {
+	data, err := json.Marshal(compactSample(m))
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(bucketRaw).CreateBucketIfNotExists([]byte(m.ClusterName))
+		if err != nil {
+			return err
+		}
+		return bucket.Put(timeKey(m.Timestamp), data)
+	})
+}
+
+// sampleTiers 从粗到细排列的采样层级，window为每个采样覆盖的时长（汇总窗口），原始采样为0
+var sampleTiers = []struct {
+	bucket []byte
+	window time.Duration
+}{
+	{bucketOneHour, time.Hour},
+	{bucketFiveMinute, 5 * time.Minute},
+	{bucketRaw, 0},
+}
+
+// QuerySamples 查询时间范围内的指标采样
+// 起始时间仍在原始数据保留期内时返回原始采样，否则从5分钟或1小时汇总开始读取；
+// 尚未汇总的最近时段依次由更细的层级补齐，各层级覆盖的时段不重叠
+func (s *BoltStore) QuerySamples(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+	first := 0
+	switch age := time.Since(from); {
+	case age <= s.retention.Raw:
+		first = 2
+	case age <= s.retention.FiveMinute:
+		first = 1
+	}
+
+	var samples []*metrics.ClusterMetrics
+	err := s.db.View(func(tx *bolt.Tx) error {
+		next := from
+		for _, tier := range sampleTiers[first:] {
+			bucket := tx.Bucket(tier.bucket).Bucket([]byte(clusterName))
+			if bucket == nil {
+				continue
+			}
+
+			tierSamples, err := readSamples(bucket, next, to)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, tierSamples...)
+			if n := len(tierSamples); n > 0 {
+				next = tierSamples[n-1].Timestamp.Add(tier.window)
+			}
+		}
+		return nil
+	})
+
+	return samples, err
+}
+
+// Put 以JSON格式保存记录
+func (s *BoltStore) Put(bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		records, err := tx.Bucket(bucketRecords).CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return records.Put([]byte(key), data)
+	})
+}
+
+// Delete 删除记录
+func (s *BoltStore) Delete(bucket, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketRecords).Bucket([]byte(bucket))
+		if records == nil {
+			return nil
+		}
+		return records.Delete([]byte(key))
+	})
+}
+
+// List 遍历bucket中的所有记录
+func (s *BoltStore) List(bucket string, fn func(key string, data []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketRecords).Bucket([]byte(bucket))
+		if records == nil {
+			return nil
+		}
+		return records.ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
+
+// AppendAudit 写入审计记录
+func (s *BoltStore) AppendAudit(entry AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAudit)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		// 同一时刻可能有多条记录，键中追加序号保证唯一
+		key := make([]byte, 16)
+		copy(key, timeKey(entry.Time))
+		binary.BigEndian.PutUint64(key[8:], seq)
+		return bucket.Put(key, data)
+	})
+}
+
+// QueryAudit 查询时间范围内的审计记录
+func (s *BoltStore) QueryAudit(from, to time.Time) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketAudit).Cursor()
+		end := timeKey(to)
+		for k, v := cursor.Seek(timeKey(from)); k != nil && string(k[:8]) <= string(end); k, v = cursor.Next() {
+			var entry AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to unmarshal audit entry: %v", err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// Compact 将原始采样汇总为5分钟采样、5分钟采样汇总为1小时采样，并清理超过保留时长的数据
+func (s *BoltStore) Compact(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(bucketRaw)
+
+		var clusters []string
+		if err := raw.ForEachBucket(func(k []byte) error {
+			clusters = append(clusters, string(k))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, clusterName := range clusters {
+			rawBucket := raw.Bucket([]byte(clusterName))
+			fiveMinute, err := tx.Bucket(bucketFiveMinute).CreateBucketIfNotExists([]byte(clusterName))
+			if err != nil {
+				return err
+			}
+			oneHour, err := tx.Bucket(bucketOneHour).CreateBucketIfNotExists([]byte(clusterName))
+			if err != nil {
+				return err
+			}
+
+			if err := rollupTier(rawBucket, fiveMinute, 5*time.Minute, now); err != nil {
+				return err
+			}
+			if err := rollupTier(fiveMinute, oneHour, time.Hour, now); err != nil {
+				return err
+			}
+
+			if err := pruneBefore(rawBucket, now.Add(-s.retention.Raw)); err != nil {
+				return err
+			}
+			if err := pruneBefore(fiveMinute, now.Add(-s.retention.FiveMinute)); err != nil {
+				return err
+			}
+			if err := pruneBefore(oneHour, now.Add(-s.retention.OneHour)); err != nil {
+				return err
+			}
+		}
+
+		if s.retention.Events > 0 {
+			if err := pruneEvents(tx, now.Add(-s.retention.Events)); err != nil {
+				return err
+			}
+		}
+		return pruneBefore(tx.Bucket(bucketAudit), now.Add(-s.retention.Audit))
+	})
+}
+
+// Close 关闭存储
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// rollupTier 将src中已结束且尚未汇总的时间窗口汇总写入dst
+func rollupTier(src, dst *bolt.Bucket, window time.Duration, now time.Time) error {
+	var start time.Time
+	if k, _ := dst.Cursor().Last(); k != nil {
+		start = keyTime(k).Add(window)
+	} else if k, _ := src.Cursor().First(); k != nil {
+		start = keyTime(k).Truncate(window)
+	} else {
+		return nil
+	}
+
+	for ; !start.Add(window).After(now); start = start.Add(window) {
+		samples, err := readSamples(src, start, start.Add(window-time.Nanosecond))
+		if err != nil {
+			return err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		data, err := json.Marshal(rollup(samples, start, window))
+		if err != nil {
+			return fmt.Errorf("failed to marshal rollup: %v", err)
+		}
+		if err := dst.Put(timeKey(start), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSamples 读取 [from, to] 范围内的采样
+func readSamples(bucket *bolt.Bucket, from, to time.Time) ([]*metrics.ClusterMetrics, error) {
+	var samples []*metrics.ClusterMetrics
+
+	cursor := bucket.Cursor()
+	end := timeKey(to)
+	for k, v := cursor.Seek(timeKey(from)); k != nil && string(k) <= string(end); k, v = cursor.Next() {
+		var sample metrics.ClusterMetrics
+		if err := json.Unmarshal(v, &sample); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sample: %v", err)
+		}
+		samples = append(samples, &sample)
+	}
+
+	return samples, nil
+}
+
+// pruneBefore 删除早于指定时间的数据
+func pruneBefore(bucket *bolt.Bucket, before time.Time) error {
+	cursor := bucket.Cursor()
+	limit := timeKey(before)
+	for k, _ := cursor.First(); k != nil && string(k[:8]) < string(limit); k, _ = cursor.First() {
+		if err := cursor.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeKey 将时间编码为按时间顺序排列的键，早于1970年的时间按0处理
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	if t.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	return key
+}
+
+// keyTime 解码timeKey生成的键
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketEvents 集群事件归档，每个集群一个子bucket
+var bucketEvents = []byte("events")
+
+// EventRecord 归档的集群事件，每次观察到事件计数变化时记录一条
+type EventRecord struct {
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Source    string    `json:"source,omitempty"`
+	Count     int32     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	UID       string    `json:"uid"`
+}
+
+// EventQuery 事件查询条件，字段为空时不按该字段过滤
+type EventQuery struct {
+	Cluster   string
+	Namespace string
+	Kind      string
+	Name      string
+	Reason    string
+	Type      string
+	From      time.Time
+	To        time.Time
+	// Limit 返回的最大条数，为0时不限制
+	Limit int
+	// AllChanges 为true时返回事件每次计数变化的记录（如用于计算事件速率），默认每个事件只返回时间范围内最新的一条
+	AllChanges bool
+}
+
+// matches 判断事件是否满足查询条件（不含时间范围）
+func (q *EventQuery) matches(record *EventRecord) bool {
+	return (q.Namespace == "" || q.Namespace == record.Namespace) &&
+		(q.Kind == "" || q.Kind == record.Kind) &&
+		(q.Name == "" || q.Name == record.Name) &&
+		(q.Reason == "" || q.Reason == record.Reason) &&
+		(q.Type == "" || q.Type == record.Type)
+}
+
+// AppendEvents 写入事件记录。键由最近发生时间和事件UID组成，重复写入同一次观察不会产生重复记录
+func (s *BoltStore) AppendEvents(records []EventRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to marshal event: %v", err)
+			}
+
+			bucket, err := tx.Bucket(bucketEvents).CreateBucketIfNotExists([]byte(record.Cluster))
+			if err != nil {
+				return err
+			}
+			key := append(timeKey(record.LastSeen), []byte(record.UID)...)
+			if err := bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// QueryEvents 查询集群在时间范围内的事件，按最近发生时间倒序
+func (s *BoltStore) QueryEvents(query EventQuery) ([]EventRecord, error) {
+	var records []EventRecord
+	seen := make(map[string]bool)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketEvents).Bucket([]byte(query.Cluster))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		start := string(timeKey(query.From))
+		// 从结束时间之后的第一个键向前遍历
+		k, v := cursor.Seek(timeKey(query.To.Add(time.Nanosecond)))
+		if k == nil {
+			k, v = cursor.Last()
+		} else {
+			k, v = cursor.Prev()
+		}
+		for ; k != nil && string(k[:8]) >= start; k, v = cursor.Prev() {
+			var record EventRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("failed to unmarshal event: %v", err)
+			}
+			if !query.matches(&record) {
+				continue
+			}
+			// 倒序遍历，同一事件先遇到的是最新的记录
+			if !query.AllChanges {
+				if seen[record.UID] {
+					continue
+				}
+				seen[record.UID] = true
+			}
+			records = append(records, record)
+			if query.Limit > 0 && len(records) >= query.Limit {
+				break
+			}
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// pruneEvents 删除所有集群中早于指定时间的事件
+func pruneEvents(tx *bolt.Tx, before time.Time) error {
+	events := tx.Bucket(bucketEvents)
+	return events.ForEachBucket(func(k []byte) error {
+		return pruneBefore(events.Bucket(k), before)
+	})
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"math"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// compactSample 去掉持久化时不需要的Pod明细和节点状况，只保留汇总数据
+func compactSample(m *metrics.ClusterMetrics) *metrics.ClusterMetrics {
+	sample := *m
+	sample.Pods.Details = nil
+
+	sample.Nodes.Details = make([]metrics.NodeDetail, len(m.Nodes.Details))
+	for i, node := range m.Nodes.Details {
+		node.Conditions = nil
+		sample.Nodes.Details[i] = node
+	}
+
+	return &sample
+}
+
+// average 累加器，用于计算整数字段的平均值
+type average struct {
+	sum   float64
+	count int
+}
+
+func (a *average) add(v float64) {
+	a.sum += v
+	a.count++
+}
+
+func (a *average) value() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.sum / float64(a.count)
+}
+
+func (a *average) int() int {
+	return int(math.Round(a.value()))
+}
+
+func (a *average) int64() int64 {
+	return int64(math.Round(a.value()))
+}
+
+// podCountsAverage Pod数量统计的平均值累加器。readyFractions不为nil时同时统计就绪副本数的分布，
+// 就绪副本数取平均并取整后会掩盖部分时间没有副本就绪的情况，SLO需要按分布计算
+type podCountsAverage struct {
+	total, ready, running, pending, failed, succeeded average
+	restarts                                          int32
+	readyFractions                                    []float64
+}
+
+// newWorkloadCountsAverage 创建统计就绪副本数分布的累加器
+func newWorkloadCountsAverage() *podCountsAverage {
+	return &podCountsAverage{readyFractions: []float64{}}
+}
+
+// addReady 累加一个采样的就绪副本数分布，汇总采样按其分布累加，原始采样在Ready处累加1
+func (p *podCountsAverage) addReady(c metrics.PodCounts) {
+	fractions := c.ReadyFractions
+	if len(fractions) == 0 {
+		fractions = make([]float64, c.Ready+1)
+		fractions[c.Ready] = 1
+	}
+	for len(p.readyFractions) < len(fractions) {
+		p.readyFractions = append(p.readyFractions, 0)
+	}
+	for i, fraction := range fractions {
+		p.readyFractions[i] += fraction
+	}
+}
+
+func (p *podCountsAverage) add(c metrics.PodCounts) {
+	if p.readyFractions != nil {
+		p.addReady(c)
+	}
+	p.total.add(float64(c.Total))
+	p.ready.add(float64(c.Ready))
+	p.running.add(float64(c.Running))
+	p.pending.add(float64(c.Pending))
+	p.failed.add(float64(c.Failed))
+	p.succeeded.add(float64(c.Succeeded))
+	p.restarts = c.Restarts
+}
+
+func (p *podCountsAverage) result() metrics.PodCounts {
+	var readyFractions []float64
+	if len(p.readyFractions) > 0 {
+		readyFractions = make([]float64, len(p.readyFractions))
+		for i, sum := range p.readyFractions {
+			readyFractions[i] = sum / float64(p.ready.count)
+		}
+	}
+	return metrics.PodCounts{
+		ReadyFractions: readyFractions,
+		Total:          p.total.int(),
+		Ready:          p.ready.int(),
+		Running:        p.running.int(),
+		Pending:        p.pending.int(),
+		Failed:         p.failed.int(),
+		Succeeded:      p.succeeded.int(),
+		Restarts:       p.restarts,
+	}
+}
+
+// usageAverage 资源使用量的平均值累加器
+type usageAverage struct {
+	cpuUsage, memoryUsage, cpuRequests, memoryRequests, cpuLimits, memoryLimits average
+}
+
+func (u *usageAverage) add(usage metrics.UsageMetrics) {
+	u.cpuUsage.add(float64(usage.CPUUsage))
+	u.memoryUsage.add(float64(usage.MemoryUsage))
+	u.cpuRequests.add(float64(usage.CPURequests))
+	u.memoryRequests.add(float64(usage.MemoryRequests))
+	u.cpuLimits.add(float64(usage.CPULimits))
+	u.memoryLimits.add(float64(usage.MemoryLimits))
+}
+
+func (u *usageAverage) result() metrics.UsageMetrics {
+	return metrics.UsageMetrics{
+		CPUUsage:       u.cpuUsage.int64(),
+		MemoryUsage:    u.memoryUsage.int64(),
+		CPURequests:    u.cpuRequests.int64(),
+		MemoryRequests: u.memoryRequests.int64(),
+		CPULimits:      u.cpuLimits.int64(),
+		MemoryLimits:   u.memoryLimits.int64(),
+	}
+}
+
+// volumeAverage 卷使用量的平均值累加器，容量取最后一个采样的值（卷可能在窗口内扩容）
+type volumeAverage struct {
+	last                        metrics.VolumeMetrics
+	used, available, inodesUsed average
+}
+
+func (v *volumeAverage) add(volume metrics.VolumeMetrics) {
+	v.last = volume
+	v.used.add(float64(volume.UsedBytes))
+	v.available.add(float64(volume.AvailableBytes))
+	v.inodesUsed.add(float64(volume.InodesUsed))
+}
+
+func (v *volumeAverage) result() metrics.VolumeMetrics {
+	volume := v.last
+	volume.UsedBytes = v.used.int64()
+	volume.AvailableBytes = v.available.int64()
+	volume.InodesUsed = v.inodesUsed.int64()
+	return volume
+}
+
+// rollup 将 [timestamp, timestamp+window) 内的采样汇总为一个采样
+// 数量、使用量、使用率和卷使用量取平均值，重启次数是累计值，取窗口内最后一个采样的值
+func rollup(samples []*metrics.ClusterMetrics, timestamp time.Time, window time.Duration) *metrics.ClusterMetrics {
+	last := samples[len(samples)-1]
+	result := &metrics.ClusterMetrics{
+		ClusterName: last.ClusterName,
+		Timestamp:   timestamp,
+		Resources:   last.Resources,
+		Interval:    window,
+	}
+
+	var nodesTotal, nodesReady, nodesNotReady, nodesUnreachable average
+	var podsTotal, podsRunning, podsPending, podsFailed, podsSucceeded average
+	var cpuCapacity, memoryCapacity, cpuUsage, memoryUsage average
+
+	nodeOrder := []string{}
+	nodeCPU := make(map[string]*average)
+	nodeMemory := make(map[string]*average)
+
+	namespaceOrder := []string{}
+	namespacePods := make(map[string]*podCountsAverage)
+	namespaceUsage := make(map[string]*usageAverage)
+	workloadOrder := make(map[string][]metrics.WorkloadMetrics)
+	workloadPods := make(map[string]*podCountsAverage)
+	workloadUsage := make(map[string]*usageAverage)
+
+	volumeOrder := []string{}
+	volumes := make(map[string]*volumeAverage)
+
+	for _, sample := range samples {
+		nodesTotal.add(float64(sample.Nodes.Total))
+		nodesReady.add(float64(sample.Nodes.Ready))
+		nodesNotReady.add(float64(sample.Nodes.NotReady))
+		nodesUnreachable.add(float64(sample.Nodes.Unreachable))
+
+		podsTotal.add(float64(sample.Pods.Total))
+		podsRunning.add(float64(sample.Pods.Running))
+		podsPending.add(float64(sample.Pods.Pending))
+		podsFailed.add(float64(sample.Pods.Failed))
+		podsSucceeded.add(float64(sample.Pods.Succeeded))
+
+		cpuCapacity.add(float64(sample.Resources.CPUCapacity))
+		memoryCapacity.add(float64(sample.Resources.MemoryCapacity))
+		cpuUsage.add(float64(sample.Resources.CPUUsage))
+		memoryUsage.add(float64(sample.Resources.MemoryUsage))
+
+		for _, node := range sample.Nodes.Details {
+			if _, ok := nodeCPU[node.Name]; !ok {
+				nodeOrder = append(nodeOrder, node.Name)
+				nodeCPU[node.Name] = &average{}
+				nodeMemory[node.Name] = &average{}
+			}
+			nodeCPU[node.Name].add(node.CPUUsagePercent)
+			nodeMemory[node.Name].add(node.MemoryUsagePercent)
+		}
+
+		for _, ns := range sample.Namespaces {
+			if _, ok := namespacePods[ns.Name]; !ok {
+				namespaceOrder = append(namespaceOrder, ns.Name)
+				namespacePods[ns.Name] = &podCountsAverage{}
+				namespaceUsage[ns.Name] = &usageAverage{}
+			}
+			namespacePods[ns.Name].add(ns.Pods)
+			namespaceUsage[ns.Name].add(ns.Usage)
+
+			for _, workload := range ns.Workloads {
+				key := workload.Namespace + "/" + workload.Kind + "/" + workload.Name
+				if _, ok := workloadPods[key]; !ok {
+					workloadOrder[ns.Name] = append(workloadOrder[ns.Name], metrics.WorkloadMetrics{
+						Kind: workload.Kind, Name: workload.Name, Namespace: workload.Namespace,
+					})
+					workloadPods[key] = newWorkloadCountsAverage()
+					workloadUsage[key] = &usageAverage{}
+				}
+				workloadPods[key].add(workload.Pods)
+				workloadUsage[key].add(workload.Usage)
+			}
+		}
+
+		for _, volume := range sample.Volumes {
+			key := volume.Namespace + "/" + volume.PVC
+			if _, ok := volumes[key]; !ok {
+				volumeOrder = append(volumeOrder, key)
+				volumes[key] = &volumeAverage{}
+			}
+			volumes[key].add(volume)
+		}
+	}
+
+	result.Nodes = metrics.NodeMetricsSummary{
+		Total:       nodesTotal.int(),
+		Ready:       nodesReady.int(),
+		NotReady:    nodesNotReady.int(),
+		Unreachable: nodesUnreachable.int(),
+	}
+	for _, name := range nodeOrder {
+		result.Nodes.Details = append(result.Nodes.Details, metrics.NodeDetail{
+			Name:               name,
+			CPUUsagePercent:    nodeCPU[name].value(),
+			MemoryUsagePercent: nodeMemory[name].value(),
+		})
+	}
+
+	result.Pods = metrics.PodMetricsSummary{
+		Total:     podsTotal.int(),
+		Running:   podsRunning.int(),
+		Pending:   podsPending.int(),
+		Failed:    podsFailed.int(),
+		Succeeded: podsSucceeded.int(),
+		Restarts:  last.Pods.Restarts,
+	}
+
+	result.Resources.CPUCapacity = cpuCapacity.int64()
+	result.Resources.MemoryCapacity = memoryCapacity.int64()
+	result.Resources.CPUUsage = cpuUsage.int64()
+	result.Resources.MemoryUsage = memoryUsage.int64()
+
+	for _, name := range namespaceOrder {
+		ns := metrics.NamespaceMetrics{
+			Name:  name,
+			Pods:  namespacePods[name].result(),
+			Usage: namespaceUsage[name].result(),
+		}
+		for _, workload := range workloadOrder[name] {
+			key := workload.Namespace + "/" + workload.Kind + "/" + workload.Name
+			workload.Pods = workloadPods[key].result()
+			workload.Usage = workloadUsage[key].result()
+			ns.Workloads = append(ns.Workloads, workload)
+		}
+		result.Namespaces = append(result.Namespaces, ns)
+	}
+
+	for _, key := range volumeOrder {
+		result.Volumes = append(result.Volumes, volumes[key].result())
+	}
+
+	return result
+}
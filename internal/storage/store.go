@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+)
+
+// Store 持久化存储接口
+type Store interface {
+	// AppendSample 写入一个原始指标采样
+	AppendSample(m *metrics.ClusterMetrics) error
+	// QuerySamples 查询时间范围内的指标采样，按起始时间自动选择原始、5分钟或1小时精度
+	QuerySamples(clusterName string, from, to time.Time) ([]*metrics.ClusterMetrics, error)
+
+	// Put 以JSON格式保存记录
+	Put(bucket, key string, value interface{}) error
+	// Delete 删除记录
+	Delete(bucket, key string) error
+	// List 遍历bucket中的所有记录
+	List(bucket string, fn func(key string, data []byte) error) error
+
+	// AppendAudit 写入审计记录
+	AppendAudit(entry AuditEntry) error
+	// QueryAudit 查询时间范围内的审计记录
+	QueryAudit(from, to time.Time) ([]AuditEntry, error)
+
+	// AppendEvents 归档集群事件
+	AppendEvents(records []EventRecord) error
+	// QueryEvents 按条件查询归档的事件，按时间倒序
+	QueryEvents(query EventQuery) ([]EventRecord, error)
+
+	// Compact 汇总降采样并清理超过保留时长的数据
+	Compact(now time.Time) error
+	// Close 关闭存储
+	Close() error
+}
+
+// AuditEntry 审计记录
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Action string    `json:"action"`
+	Target string    `json:"target"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Open 根据配置打开存储
+func Open(cfg config.StorageConfig) (Store, error) {
+	return NewBoltStore(cfg.Path, cfg.Retention)
+}
@@ -0,0 +1,201 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kudig-io/klaw/internal/config"
+	"github.com/kudig-io/klaw/internal/metrics"
+	"github.com/kudig-io/klaw/internal/storage"
+)
+
+func newTestStore(t *testing.T) *storage.BoltStore {
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "klaw.db"), config.RetentionConfig{
+		Raw:        time.Hour,
+		FiveMinute: 24 * time.Hour,
+		OneHour:    7 * 24 * time.Hour,
+		Audit:      24 * time.Hour,
+		Events:     24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBoltStore_SamplesAndCompact(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now().Truncate(time.Hour)
+	start := now.Add(-2 * time.Hour)
+	for i := 0; i < 24; i++ {
+		sample := &metrics.ClusterMetrics{
+			ClusterName: "prod",
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			Pods:        metrics.PodMetricsSummary{Total: 10 + i%2*2, Details: []metrics.PodDetail{{Name: "web"}}},
+		}
+		if err := store.AppendSample(sample); err != nil {
+			t.Fatalf("AppendSample() error = %v", err)
+		}
+	}
+
+	if err := store.Compact(now); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	// 原始数据已超过1小时保留期被清理，查询退化为5分钟汇总
+	history, err := store.QuerySamples("prod", start, now)
+	if err != nil {
+		t.Fatalf("QuerySamples() error = %v", err)
+	}
+	if len(history) != 5 {
+		t.Fatalf("expected 5 five-minute samples, got %d", len(history))
+	}
+	if !history[0].Timestamp.Equal(start) || history[0].Pods.Total != 11 {
+		t.Errorf("unexpected first rollup: %v total=%d", history[0].Timestamp, history[0].Pods.Total)
+	}
+	if history[0].Pods.Details != nil {
+		t.Error("expected pod details to be dropped from stored samples")
+	}
+}
+
+func TestBoltStore_QuerySamplesTail(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	start := now.Add(-2 * time.Hour).Truncate(time.Hour)
+	var latest time.Time
+	for ts := start; ts.Before(now); ts = ts.Add(time.Minute) {
+		if err := store.AppendSample(&metrics.ClusterMetrics{ClusterName: "prod", Timestamp: ts}); err != nil {
+			t.Fatalf("AppendSample() error = %v", err)
+		}
+		latest = ts
+	}
+
+	// 上次汇总之后的采样只存在于原始数据中
+	compactedAt := start.Add(90 * time.Minute)
+	if err := store.Compact(compactedAt); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	history, err := store.QuerySamples("prod", start, now)
+	if err != nil {
+		t.Fatalf("QuerySamples() error = %v", err)
+	}
+	if len(history) == 0 || !history[0].Timestamp.Equal(start) || history[0].Interval != 5*time.Minute {
+		t.Fatalf("expected query to start with five-minute rollups, got %d samples", len(history))
+	}
+	if last := history[len(history)-1]; !last.Timestamp.Equal(latest) {
+		t.Errorf("expected samples after the last rollup to be filled from raw data, last sample at %v, want %v", last.Timestamp, latest)
+	}
+
+	rollups := 0
+	for i, sample := range history {
+		if i > 0 && !sample.Timestamp.After(history[i-1].Timestamp.Add(history[i-1].Interval-time.Nanosecond)) {
+			t.Fatalf("sample %d at %v overlaps the previous sample", i, sample.Timestamp)
+		}
+		if sample.Interval > 0 {
+			rollups++
+		}
+	}
+	if rollups != 18 || len(history) != 18+int(latest.Sub(compactedAt)/time.Minute)+1 {
+		t.Errorf("expected 18 rollups followed by raw samples since %v, got %d rollups in %d samples", compactedAt, rollups, len(history))
+	}
+}
+
+func TestBoltStore_RecordsAndAudit(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.Put("alerts", "a1", map[string]string{"id": "a1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put("alerts", "a2", map[string]string{"id": "a2"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Delete("alerts", "a1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	var ids []string
+	err := store.List("alerts", func(key string, data []byte) error {
+		var record map[string]string
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		ids = append(ids, record["id"])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "a2" {
+		t.Errorf("unexpected records: %v", ids)
+	}
+
+	now := time.Now()
+	for _, action := range []string{"pod.delete", "pod.delete"} {
+		if err := store.AppendAudit(storage.AuditEntry{Time: now, Actor: "api", Action: action, Target: "prod/default/web"}); err != nil {
+			t.Fatalf("AppendAudit() error = %v", err)
+		}
+	}
+
+	entries, err := store.QueryAudit(now.Add(-time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("QueryAudit() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 audit entries, got %d", len(entries))
+	}
+}
+
+func TestBoltStore_Events(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	records := []storage.EventRecord{
+		{Cluster: "prod", Namespace: "web", Kind: "Pod", Name: "api-1", Type: "Warning", Reason: "BackOff", Count: 1, LastSeen: now.Add(-3 * time.Hour), UID: "a"},
+		{Cluster: "prod", Namespace: "web", Kind: "Pod", Name: "api-1", Type: "Warning", Reason: "BackOff", Count: 2, LastSeen: now.Add(-2 * time.Hour), UID: "a"},
+		{Cluster: "prod", Namespace: "web", Kind: "Pod", Name: "api-2", Type: "Normal", Reason: "Pulled", Count: 1, LastSeen: now.Add(-time.Hour), UID: "b"},
+		{Cluster: "prod", Namespace: "db", Kind: "Pod", Name: "pg-0", Type: "Warning", Reason: "BackOff", Count: 1, LastSeen: now.Add(-time.Minute), UID: "c"},
+		{Cluster: "staging", Namespace: "web", Kind: "Pod", Name: "api-1", Type: "Warning", Reason: "BackOff", Count: 1, LastSeen: now, UID: "d"},
+	}
+	if err := store.AppendEvents(records); err != nil {
+		t.Fatalf("AppendEvents() error = %v", err)
+	}
+	// 重复写入同一次观察不产生重复记录
+	if err := store.AppendEvents(records[:1]); err != nil {
+		t.Fatalf("AppendEvents() error = %v", err)
+	}
+
+	found, err := store.QueryEvents(storage.EventQuery{Cluster: "prod", Reason: "BackOff", From: now.Add(-4 * time.Hour), To: now})
+	if err != nil {
+		t.Fatalf("QueryEvents() error = %v", err)
+	}
+	// 同一事件的多次计数变化只返回最新的一条
+	if len(found) != 2 || found[0].Name != "pg-0" || found[1].Count != 2 {
+		t.Fatalf("unexpected events: %+v", found)
+	}
+
+	found, err = store.QueryEvents(storage.EventQuery{Cluster: "prod", Reason: "BackOff", From: now.Add(-4 * time.Hour), To: now, AllChanges: true})
+	if err != nil {
+		t.Fatalf("QueryEvents() error = %v", err)
+	}
+	if len(found) != 3 || found[0].Name != "pg-0" || found[2].Count != 1 {
+		t.Fatalf("expected every count change, got %+v", found)
+	}
+
+	found, err = store.QueryEvents(storage.EventQuery{Cluster: "prod", Namespace: "web", Name: "api-1", From: now.Add(-150 * time.Minute), To: now, Limit: 10})
+	if err != nil {
+		t.Fatalf("QueryEvents() error = %v", err)
+	}
+	if len(found) != 1 || found[0].Count != 2 {
+		t.Errorf("expected only the event within the time range, got %+v", found)
+	}
+
+	if found, _ := store.QueryEvents(storage.EventQuery{Cluster: "prod", From: now.Add(-4 * time.Hour), To: now, Limit: 2}); len(found) != 2 {
+		t.Errorf("expected limit to apply, got %d events", len(found))
+	}
+}
@@ -5,7 +5,10 @@ import ClusterDashboard from './pages/ClusterDashboard'
 import PodsPage from './pages/PodsPage'
 import NodesPage from './pages/NodesPage'
 import MonitoringPage from './pages/MonitoringPage'
-import { Menu, X, Moon, Sun, Database, Server, Activity, AlertCircle } from 'lucide-react'
+import SilencesPage from './pages/SilencesPage'
+import EventsPage from './pages/EventsPage'
+import SLOPage from './pages/SLOPage'
+import { Menu, X, Moon, Sun, Database, Server, Activity, AlertCircle, BellOff, Radio, Target } from 'lucide-react'
 
 function App() {
   const [isDarkMode, setIsDarkMode] = useState(false)
@@ -21,6 +24,9 @@ function App() {
     { path: '/pods', label: 'Pods', icon: Server },
     { path: '/nodes', label: 'Nodes', icon: Activity },
     { path: '/monitoring', label: 'Monitoring', icon: AlertCircle },
+    { path: '/events', label: 'Events', icon: Radio },
+    { path: '/slos', label: 'SLOs', icon: Target },
+    { path: '/silences', label: 'Silences', icon: BellOff },
   ]
 
   return (
@@ -117,6 +123,9 @@ function App() {
           <Route path="/pods" element={<PodsPage />} />
           <Route path="/nodes" element={<NodesPage />} />
           <Route path="/monitoring" element={<MonitoringPage />} />
+          <Route path="/events" element={<EventsPage />} />
+          <Route path="/slos" element={<SLOPage />} />
+          <Route path="/silences" element={<SilencesPage />} />
         </Routes>
       </main>
 
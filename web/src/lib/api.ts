@@ -85,6 +85,75 @@ export interface Event {
   lastTimestamp: string
 }
 
+export interface ClusterEvent {
+  cluster: string
+  namespace: string
+  kind: string
+  name: string
+  type: string
+  reason: string
+  message: string
+  source?: string
+  count: number
+  first_seen: string
+  last_seen: string
+  uid: string
+}
+
+export interface EventAggregate {
+  cluster: string
+  namespace: string
+  kind: string
+  name: string
+  reason: string
+  message: string
+  count: number
+  first_seen: string
+  last_seen: string
+}
+
+export interface MetricsHistoryParams {
+  from?: string
+  to?: string
+  step?: string
+  fields?: string[]
+}
+
+export interface MetricsHistory {
+  cluster: string
+  from: string
+  to: string
+  step: number
+  timestamps: number[]
+  series: Record<string, Array<number | null>>
+}
+
+export interface SLOBurnRate {
+  long_window: string
+  short_window: string
+  long_rate: number
+  short_rate: number
+  threshold: number
+  level: string
+  firing: boolean
+}
+
+export interface SLOStatus {
+  name: string
+  cluster: string
+  namespace: string
+  kind: string
+  workload: string
+  min_ready: number
+  objective: number
+  window: string
+  compliance: number
+  error_budget_remaining: number
+  samples: number
+  burn_rates: SLOBurnRate[] | null
+  error?: string
+}
+
 export const clusterApi = {
   getClusters: () => api.get<Cluster[]>('/clusters'),
   getCluster: (name: string) => api.get<Cluster>(`/clusters/${name}`),
@@ -115,12 +184,90 @@ export const nodeApi = {
 export const eventApi = {
   getEvents: (cluster: string, namespace?: string) =>
     api.get<Event[]>(namespace ? `/clusters/${cluster}/namespaces/${namespace}/events` : `/clusters/${cluster}/events`),
+  getWarnings: (cluster: string) => api.get<EventAggregate[]>(`/clusters/${cluster}/events/warnings`),
+  // streamUrl 实时Warning事件流地址，cluster为空时接收所有集群的事件
+  streamUrl: (cluster?: string) =>
+    cluster ? `/api/events/stream?cluster=${encodeURIComponent(cluster)}` : '/api/events/stream',
 }
 
 export const monitoringApi = {
   getStatus: (cluster: string) => api.get(`/monitoring/${cluster}/status`),
-  getAlerts: (cluster: string) => api.get(`/monitoring/${cluster}/alerts`),
-  getHistory: (cluster: string) => api.get(`/monitoring/${cluster}/history`),
+  getAlerts: (cluster: string) => api.get<Alert[]>(`/monitoring/${cluster}/alerts`),
+  getHistory: (cluster: string, params: MetricsHistoryParams = {}) =>
+    api.get<MetricsHistory>(`/monitoring/${cluster}/history`, {
+      params: { ...params, fields: params.fields?.join(',') },
+    }),
+}
+
+export interface AlertTimelineEntry {
+  time: string
+  action: string
+  actor: string
+  detail?: string
+}
+
+export interface Alert {
+  id: string
+  cluster: string
+  namespace?: string
+  type: string
+  level: string
+  message: string
+  labels?: Record<string, string>
+  status: string
+  silenced_by?: string
+  created_at: string
+  resolved_at?: string
+  acked_by?: string
+  acked_at?: string
+  assignee?: string
+  escalation_level?: number
+  timeline?: AlertTimelineEntry[]
+}
+
+export const alertApi = {
+  getAlert: (id: string) => api.get<Alert>(`/alerts/${id}`),
+  ackAlert: (id: string, actor: string) => api.post<Alert>(`/alerts/${id}/ack`, { actor }),
+  assignAlert: (id: string, actor: string, assignee: string) =>
+    api.post<Alert>(`/alerts/${id}/assign`, { actor, assignee }),
+  resolveAlert: (id: string, actor: string) => api.post<Alert>(`/alerts/${id}/resolve`, { actor }),
+  commentAlert: (id: string, actor: string, comment: string) =>
+    api.post<Alert>(`/alerts/${id}/comments`, { actor, comment }),
+}
+
+export interface Silence {
+  id: string
+  cluster?: string
+  namespace?: string
+  node?: string
+  rule?: string
+  labels?: Record<string, string>
+  starts_at: string
+  ends_at: string
+  created_by: string
+  comment?: string
+  created_at: string
+}
+
+export interface CreateSilenceRequest {
+  cluster?: string
+  namespace?: string
+  node?: string
+  rule?: string
+  labels?: Record<string, string>
+  duration: string
+  created_by: string
+  comment?: string
+}
+
+export const silenceApi = {
+  listSilences: () => api.get<Silence[]>('/silences'),
+  createSilence: (silence: CreateSilenceRequest) => api.post<Silence>('/silences', silence),
+  expireSilence: (id: string) => api.delete(`/silences/${id}`),
+}
+
+export const sloApi = {
+  getStatus: () => api.get<SLOStatus[]>('/slos'),
 }
 
 export default api
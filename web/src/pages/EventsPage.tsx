@@ -0,0 +1,185 @@
+import React, { useState, useEffect } from 'react'
+import { clusterApi, eventApi, ClusterEvent, EventAggregate } from '../lib/api'
+import { formatDate } from '../lib/utils'
+import { RefreshCw, Loader2, Radio, Layers } from 'lucide-react'
+
+// maxFeedEvents 实时事件列表保留的最大条数
+const maxFeedEvents = 200
+
+const EventsPage: React.FC = () => {
+  const [clusters, setClusters] = useState<any[]>([])
+  const [selectedCluster, setSelectedCluster] = useState<string>('')
+  const [feed, setFeed] = useState<ClusterEvent[]>([])
+  const [connected, setConnected] = useState(false)
+  const [aggregates, setAggregates] = useState<EventAggregate[]>([])
+  const [loading, setLoading] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    fetchClusters()
+  }, [])
+
+  const fetchClusters = async () => {
+    try {
+      const response = await clusterApi.getClusters()
+      setClusters(response.data)
+      if (response.data.length > 0) {
+        setSelectedCluster(response.data[0].name)
+      }
+    } catch (err) {
+      setError('Failed to fetch clusters')
+      console.error('Error fetching clusters:', err)
+    }
+  }
+
+  useEffect(() => {
+    setFeed([])
+    const source = new EventSource(eventApi.streamUrl(selectedCluster))
+    source.onopen = () => setConnected(true)
+    source.onerror = () => setConnected(false)
+    source.onmessage = (message) => {
+      const event: ClusterEvent = JSON.parse(message.data)
+      setFeed((current) => [event, ...current].slice(0, maxFeedEvents))
+    }
+    return () => source.close()
+  }, [selectedCluster])
+
+  useEffect(() => {
+    if (selectedCluster) {
+      fetchAggregates()
+    }
+  }, [selectedCluster])
+
+  const fetchAggregates = async () => {
+    try {
+      setLoading(true)
+      setError(null)
+      const response = await eventApi.getWarnings(selectedCluster)
+      setAggregates(response.data)
+    } catch (err) {
+      setError('Failed to fetch warning events')
+      console.error('Error fetching warning events:', err)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  return (
+    <div>
+      <div className="flex flex-col md:flex-row md:items-center justify-between mb-6 gap-4">
+        <h1 className="text-2xl font-bold">Warning Events</h1>
+        <div className="flex flex-col md:flex-row gap-4">
+          <select
+            value={selectedCluster}
+            onChange={(e) => setSelectedCluster(e.target.value)}
+            className="input"
+          >
+            <option value="">All Clusters</option>
+            {clusters.map((cluster) => (
+              <option key={cluster.name} value={cluster.name}>
+                {cluster.name}
+              </option>
+            ))}
+          </select>
+          <button
+            onClick={fetchAggregates}
+            disabled={!selectedCluster}
+            className="btn btn-secondary flex items-center space-x-2"
+          >
+            <RefreshCw className="h-4 w-4" />
+            <span>Refresh</span>
+          </button>
+        </div>
+      </div>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded mb-4">
+          {error}
+        </div>
+      )}
+
+      <div className="space-y-8">
+        <div className="card p-6">
+          <h2 className="text-lg font-semibold flex items-center space-x-2 mb-4">
+            <Radio className="h-5 w-5 text-primary-600 dark:text-primary-400" />
+            <span>Live Feed</span>
+            <span className={connected ? 'text-sm text-success-600' : 'text-sm text-gray-500 dark:text-gray-400'}>
+              {connected ? '● connected' : '○ disconnected'}
+            </span>
+          </h2>
+          {feed.length > 0 ? (
+            <div className="space-y-2 max-h-96 overflow-y-auto">
+              {feed.map((event, index) => (
+                <div key={`${event.uid}-${event.count}-${index}`} className="bg-gray-50 dark:bg-gray-800 rounded-lg p-3">
+                  <div className="flex items-center justify-between">
+                    <h3 className="font-medium">
+                      {event.reason} · {event.kind} {event.namespace ? `${event.namespace}/` : ''}{event.name}
+                    </h3>
+                    <span className="text-sm text-gray-500 dark:text-gray-400">
+                      {event.cluster} · {formatDate(event.last_seen)}
+                    </span>
+                  </div>
+                  <p className="text-sm text-gray-600 dark:text-gray-400 mt-1">{event.message}</p>
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="text-center py-8 text-gray-500 dark:text-gray-400">
+              Waiting for warning events
+            </div>
+          )}
+        </div>
+
+        {selectedCluster && (
+          <div className="card p-6">
+            <h2 className="text-lg font-semibold flex items-center space-x-2 mb-4">
+              <Layers className="h-5 w-5 text-warning-600 dark:text-warning-400" />
+              <span>Aggregated by Object and Reason</span>
+            </h2>
+            {loading ? (
+              <div className="flex items-center justify-center py-8">
+                <Loader2 className="h-8 w-8 animate-spin text-primary-600" />
+              </div>
+            ) : aggregates.length > 0 ? (
+              <div className="overflow-x-auto">
+                <table className="min-w-full text-sm">
+                  <thead>
+                    <tr className="text-left text-gray-600 dark:text-gray-400">
+                      <th className="py-2 pr-4">Object</th>
+                      <th className="py-2 pr-4">Reason</th>
+                      <th className="py-2 pr-4">Count</th>
+                      <th className="py-2 pr-4">Last Seen</th>
+                      <th className="py-2">Message</th>
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {aggregates.map((aggregate) => (
+                      <tr
+                        key={`${aggregate.namespace}/${aggregate.kind}/${aggregate.name}/${aggregate.reason}`}
+                        className="border-t border-gray-200 dark:border-gray-800"
+                      >
+                        <td className="py-2 pr-4">
+                          {aggregate.kind} {aggregate.namespace ? `${aggregate.namespace}/` : ''}{aggregate.name}
+                        </td>
+                        <td className="py-2 pr-4">{aggregate.reason}</td>
+                        <td className="py-2 pr-4">{aggregate.count}</td>
+                        <td className="py-2 pr-4">{formatDate(aggregate.last_seen)}</td>
+                        <td className="py-2 text-gray-600 dark:text-gray-400">{aggregate.message}</td>
+                      </tr>
+                    ))}
+                  </tbody>
+                </table>
+              </div>
+            ) : (
+              <div className="text-center py-8 text-gray-500 dark:text-gray-400">
+                No recent warning events
+              </div>
+            )}
+          </div>
+        )}
+      </div>
+    </div>
+  )
+}
+
+export default EventsPage
@@ -1,5 +1,5 @@
 import React, { useState, useEffect } from 'react'
-import { clusterApi, monitoringApi } from '../lib/api'
+import { clusterApi, monitoringApi, alertApi, Alert, MetricsHistory } from '../lib/api'
 import { formatDate } from '../lib/utils'
 import { RefreshCw, Loader2, AlertCircle, Activity, Clock, AlertTriangle, AlertOctagon } from 'lucide-react'
 import { XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer, AreaChart, Area } from 'recharts'
@@ -8,7 +8,8 @@ const MonitoringPage: React.FC = () => {
   const [clusters, setClusters] = useState<any[]>([])
   const [selectedCluster, setSelectedCluster] = useState<string>('')
   const [monitoringStatus, setMonitoringStatus] = useState<any>(null)
-  const [alerts, setAlerts] = useState<any[]>([])
+  const [alerts, setAlerts] = useState<Alert[]>([])
+  const [history, setHistory] = useState<MetricsHistory | null>(null)
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState<string | null>(null)
 
@@ -40,13 +41,21 @@ const MonitoringPage: React.FC = () => {
       setLoading(true)
       setError(null)
 
-      const [statusResponse, alertsResponse] = await Promise.all([
+      const now = new Date()
+      const [statusResponse, alertsResponse, historyResponse] = await Promise.all([
         monitoringApi.getStatus(selectedCluster),
-        monitoringApi.getAlerts(selectedCluster)
+        monitoringApi.getAlerts(selectedCluster),
+        monitoringApi.getHistory(selectedCluster, {
+          from: new Date(now.getTime() - 24 * 60 * 60 * 1000).toISOString(),
+          to: now.toISOString(),
+          step: '1h',
+          fields: ['cpu.usage_percent', 'memory.usage_percent', 'pods.total'],
+        }),
       ])
 
       setMonitoringStatus(statusResponse.data)
       setAlerts(alertsResponse.data)
+      setHistory(historyResponse.data)
     } catch (err) {
       setError('Failed to fetch monitoring data')
       console.error('Error fetching monitoring data:', err)
@@ -55,19 +64,26 @@ const MonitoringPage: React.FC = () => {
     }
   }
 
-  const generateMockMetrics = () => {
-    const data = []
-    const now = new Date()
-    for (let i = 23; i >= 0; i--) {
-      const time = new Date(now.getTime() - i * 60 * 60 * 1000)
-      data.push({
-        time: time.toLocaleTimeString('zh-CN', { hour: '2-digit', minute: '2-digit' }),
-        cpu: Math.random() * 80 + 10,
-        memory: Math.random() * 70 + 20,
-        pods: Math.floor(Math.random() * 50) + 10,
-      })
+  const handleAlertAction = async (action: (id: string, actor: string) => Promise<unknown>, id: string) => {
+    try {
+      await action(id, 'web')
+      fetchMonitoringData()
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to update alert')
+      console.error('Error updating alert:', err)
+    }
+  }
+
+  const toChartData = (history: MetricsHistory | null) => {
+    if (!history) {
+      return []
     }
-    return data
+    return history.timestamps.map((timestamp, i) => ({
+      time: new Date(timestamp).toLocaleTimeString('zh-CN', { hour: '2-digit', minute: '2-digit' }),
+      cpu: history.series['cpu.usage_percent']?.[i] ?? null,
+      memory: history.series['memory.usage_percent']?.[i] ?? null,
+      pods: history.series['pods.total']?.[i] ?? null,
+    }))
   }
 
   const getAlertIcon = (level: string) => {
@@ -92,7 +108,7 @@ const MonitoringPage: React.FC = () => {
     }
   }
 
-  const mockMetrics = generateMockMetrics()
+  const chartData = toChartData(history)
 
   return (
     <div>
@@ -143,7 +159,7 @@ const MonitoringPage: React.FC = () => {
               </div>
               <div className="h-64">
                 <ResponsiveContainer width="100%" height="100%">
-                  <AreaChart data={mockMetrics}>
+                  <AreaChart data={chartData}>
                     <defs>
                       <linearGradient id="colorCpu" x1="0" y1="0" x2="0" y2="1">
                         <stop offset="5%" stopColor="#0ea5e9" stopOpacity={0.8}/>
@@ -169,7 +185,7 @@ const MonitoringPage: React.FC = () => {
               </div>
               <div className="h-64">
                 <ResponsiveContainer width="100%" height="100%">
-                  <AreaChart data={mockMetrics}>
+                  <AreaChart data={chartData}>
                     <defs>
                       <linearGradient id="colorMemory" x1="0" y1="0" x2="0" y2="1">
                         <stop offset="5%" stopColor="#22c55e" stopOpacity={0.8}/>
@@ -196,10 +212,10 @@ const MonitoringPage: React.FC = () => {
             </div>
             {alerts.length > 0 ? (
               <div className="space-y-4">
-                {alerts.map((alert: any, index: number) => {
+                {alerts.map((alert) => {
                   const AlertIcon = getAlertIcon(alert.level)
                   return (
-                    <div key={index} className="bg-gray-50 dark:bg-gray-800 rounded-lg p-4 border-l-4 border-warning-500">
+                    <div key={alert.id} className="bg-gray-50 dark:bg-gray-800 rounded-lg p-4 border-l-4 border-warning-500">
                       <div className="flex items-start space-x-3">
                         <AlertIcon className={`h-5 w-5 ${getAlertColor(alert.level)} flex-shrink-0 mt-0.5`} />
                         <div className="flex-1">
@@ -207,12 +223,32 @@ const MonitoringPage: React.FC = () => {
                             <h3 className="font-medium">{alert.message}</h3>
                             <span className="text-sm text-gray-500 dark:text-gray-400 flex items-center space-x-1">
                               <Clock className="h-3 w-3" />
-                              {formatDate(alert.timestamp || new Date().toISOString())}
+                              {formatDate(alert.created_at)}
                             </span>
                           </div>
                           <p className="text-sm text-gray-600 dark:text-gray-400 mt-1">
-                            {alert.type} - {alert.level}
+                            {alert.type} - {alert.level} - {alert.status}
+                            {alert.acked_by && ` - acked by ${alert.acked_by}`}
+                            {alert.assignee && ` - assigned to ${alert.assignee}`}
                           </p>
+                          {alert.status === 'firing' && (
+                            <div className="flex space-x-2 mt-2">
+                              {!alert.acked_by && (
+                                <button
+                                  onClick={() => handleAlertAction(alertApi.ackAlert, alert.id)}
+                                  className="btn btn-secondary text-sm"
+                                >
+                                  Ack
+                                </button>
+                              )}
+                              <button
+                                onClick={() => handleAlertAction(alertApi.resolveAlert, alert.id)}
+                                className="btn btn-secondary text-sm"
+                              >
+                                Resolve
+                              </button>
+                            </div>
+                          )}
                         </div>
                       </div>
                     </div>
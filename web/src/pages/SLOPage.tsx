@@ -0,0 +1,138 @@
+import React, { useState, useEffect } from 'react'
+import { sloApi, SLOStatus } from '../lib/api'
+import { cn } from '../lib/utils'
+import { RefreshCw, Loader2, Target } from 'lucide-react'
+
+const SLOPage: React.FC = () => {
+  const [slos, setSLOs] = useState<SLOStatus[]>([])
+  const [loading, setLoading] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    fetchSLOs()
+  }, [])
+
+  const fetchSLOs = async () => {
+    try {
+      setLoading(true)
+      setError(null)
+      const response = await sloApi.getStatus()
+      setSLOs(response.data)
+    } catch (err) {
+      setError('Failed to fetch SLOs')
+      console.error('Error fetching SLOs:', err)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  // budgetColor 剩余错误预算越少颜色越醒目
+  const budgetColor = (remaining: number) => {
+    if (remaining <= 0) return 'bg-red-500'
+    if (remaining < 25) return 'bg-orange-500'
+    if (remaining < 50) return 'bg-yellow-500'
+    return 'bg-green-500'
+  }
+
+  return (
+    <div>
+      <div className="flex flex-col md:flex-row md:items-center justify-between mb-6 gap-4">
+        <h1 className="text-2xl font-bold">SLOs</h1>
+        <button
+          onClick={fetchSLOs}
+          className="btn btn-secondary flex items-center space-x-2"
+        >
+          <RefreshCw className="h-4 w-4" />
+          <span>Refresh</span>
+        </button>
+      </div>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded mb-4">
+          {error}
+        </div>
+      )}
+
+      {loading ? (
+        <div className="flex items-center justify-center py-8">
+          <Loader2 className="h-8 w-8 animate-spin text-primary-600" />
+        </div>
+      ) : slos.length > 0 ? (
+        <div className="space-y-4">
+          {slos.map((slo) => (
+            <div key={slo.name} className="card p-6">
+              <div className="flex flex-col md:flex-row md:items-start justify-between gap-2 mb-4">
+                <div>
+                  <h2 className="text-lg font-semibold flex items-center space-x-2">
+                    <Target className="h-5 w-5 text-primary-600 dark:text-primary-400" />
+                    <span>{slo.name}</span>
+                  </h2>
+                  <p className="text-sm text-gray-600 dark:text-gray-400 mt-1">
+                    [{slo.cluster}] {slo.kind} {slo.namespace}/{slo.workload} at least {slo.min_ready} ready,
+                    objective {slo.objective}% over {slo.window}
+                  </p>
+                </div>
+                {slo.samples > 0 && (
+                  <div className="text-right">
+                    <div className="text-2xl font-bold">{slo.compliance.toFixed(3)}%</div>
+                    <div className="text-sm text-gray-500 dark:text-gray-400">{slo.samples} samples</div>
+                  </div>
+                )}
+              </div>
+
+              {slo.error ? (
+                <div className="text-sm text-red-600 dark:text-red-400">{slo.error}</div>
+              ) : slo.samples === 0 ? (
+                <div className="text-sm text-gray-500 dark:text-gray-400">No samples yet</div>
+              ) : (
+                <>
+                  <div className="mb-4">
+                    <div className="flex justify-between text-sm mb-1">
+                      <span>Error budget remaining</span>
+                      <span className="font-medium">{slo.error_budget_remaining.toFixed(1)}%</span>
+                    </div>
+                    <div className="h-2 bg-gray-200 dark:bg-gray-700 rounded-full overflow-hidden">
+                      <div
+                        className={cn('h-full rounded-full', budgetColor(slo.error_budget_remaining))}
+                        style={{ width: `${Math.max(0, Math.min(100, slo.error_budget_remaining))}%` }}
+                      />
+                    </div>
+                  </div>
+
+                  <table className="w-full text-sm">
+                    <thead>
+                      <tr className="text-left text-gray-500 dark:text-gray-400">
+                        <th className="py-1">Window</th>
+                        <th className="py-1">Long burn rate</th>
+                        <th className="py-1">Short burn rate</th>
+                        <th className="py-1">Threshold</th>
+                        <th className="py-1">Level</th>
+                      </tr>
+                    </thead>
+                    <tbody>
+                      {(slo.burn_rates || []).map((burn) => (
+                        <tr key={burn.long_window} className={cn(burn.firing && 'text-red-600 dark:text-red-400 font-medium')}>
+                          <td className="py-1">{burn.long_window} / {burn.short_window}</td>
+                          <td className="py-1">{burn.long_rate.toFixed(2)}x</td>
+                          <td className="py-1">{burn.short_rate.toFixed(2)}x</td>
+                          <td className="py-1">{burn.threshold.toFixed(1)}x</td>
+                          <td className="py-1">{burn.firing ? `${burn.level} (firing)` : burn.level}</td>
+                        </tr>
+                      ))}
+                    </tbody>
+                  </table>
+                </>
+              )}
+            </div>
+          ))}
+        </div>
+      ) : (
+        <div className="card p-6 text-center py-8 text-gray-500 dark:text-gray-400">
+          No SLOs configured
+        </div>
+      )}
+    </div>
+  )
+}
+
+export default SLOPage
@@ -0,0 +1,189 @@
+import React, { useState, useEffect } from 'react'
+import { silenceApi, Silence } from '../lib/api'
+import { formatDate } from '../lib/utils'
+import { RefreshCw, Loader2, BellOff, Plus, X } from 'lucide-react'
+
+const emptyForm = {
+  cluster: '',
+  namespace: '',
+  node: '',
+  rule: '',
+  labels: '',
+  duration: '2h',
+  createdBy: '',
+  comment: '',
+}
+
+const SilencesPage: React.FC = () => {
+  const [silences, setSilences] = useState<Silence[]>([])
+  const [form, setForm] = useState(emptyForm)
+  const [loading, setLoading] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    fetchSilences()
+  }, [])
+
+  const fetchSilences = async () => {
+    try {
+      setLoading(true)
+      setError(null)
+      const response = await silenceApi.listSilences()
+      setSilences(response.data)
+    } catch (err) {
+      setError('Failed to fetch silences')
+      console.error('Error fetching silences:', err)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  // parseLabels 解析 key=value,key=value 格式的标签
+  const parseLabels = (value: string) => {
+    const labels: Record<string, string> = {}
+    value.split(',').map((item) => item.trim()).filter(Boolean).forEach((item) => {
+      const [key, ...rest] = item.split('=')
+      labels[key.trim()] = rest.join('=').trim()
+    })
+    return labels
+  }
+
+  const handleCreate = async (e: React.FormEvent) => {
+    e.preventDefault()
+    try {
+      setError(null)
+      await silenceApi.createSilence({
+        cluster: form.cluster || undefined,
+        namespace: form.namespace || undefined,
+        node: form.node || undefined,
+        rule: form.rule || undefined,
+        labels: form.labels ? parseLabels(form.labels) : undefined,
+        duration: form.duration,
+        created_by: form.createdBy,
+        comment: form.comment || undefined,
+      })
+      setForm(emptyForm)
+      fetchSilences()
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to create silence')
+      console.error('Error creating silence:', err)
+    }
+  }
+
+  const handleExpire = async (id: string) => {
+    try {
+      await silenceApi.expireSilence(id)
+      fetchSilences()
+    } catch (err) {
+      setError('Failed to expire silence')
+      console.error('Error expiring silence:', err)
+    }
+  }
+
+  const describeMatchers = (silence: Silence) => {
+    const parts: string[] = []
+    if (silence.cluster) parts.push(`cluster=${silence.cluster}`)
+    if (silence.namespace) parts.push(`namespace=${silence.namespace}`)
+    if (silence.node) parts.push(`node=${silence.node}`)
+    if (silence.rule) parts.push(`rule=${silence.rule}`)
+    Object.entries(silence.labels || {}).forEach(([key, value]) => parts.push(`${key}=${value}`))
+    return parts.join(', ')
+  }
+
+  const isActive = (silence: Silence) => {
+    const now = Date.now()
+    return new Date(silence.starts_at).getTime() <= now && new Date(silence.ends_at).getTime() > now
+  }
+
+  const updateForm = (field: keyof typeof emptyForm) => (e: React.ChangeEvent<HTMLInputElement>) =>
+    setForm({ ...form, [field]: e.target.value })
+
+  return (
+    <div>
+      <div className="flex flex-col md:flex-row md:items-center justify-between mb-6 gap-4">
+        <h1 className="text-2xl font-bold">Silences</h1>
+        <button
+          onClick={fetchSilences}
+          className="btn btn-secondary flex items-center space-x-2"
+        >
+          <RefreshCw className="h-4 w-4" />
+          <span>Refresh</span>
+        </button>
+      </div>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded mb-4">
+          {error}
+        </div>
+      )}
+
+      <div className="space-y-8">
+        <form onSubmit={handleCreate} className="card p-6">
+          <h2 className="text-lg font-semibold flex items-center space-x-2 mb-4">
+            <Plus className="h-5 w-5 text-primary-600 dark:text-primary-400" />
+            New Silence
+          </h2>
+          <div className="grid grid-cols-1 md:grid-cols-4 gap-4">
+            <input className="input" placeholder="Cluster" value={form.cluster} onChange={updateForm('cluster')} />
+            <input className="input" placeholder="Namespace" value={form.namespace} onChange={updateForm('namespace')} />
+            <input className="input" placeholder="Node" value={form.node} onChange={updateForm('node')} />
+            <input className="input" placeholder="Rule" value={form.rule} onChange={updateForm('rule')} />
+            <input className="input" placeholder="Labels (team=web,env=prod)" value={form.labels} onChange={updateForm('labels')} />
+            <input className="input" placeholder="Duration (2h)" value={form.duration} onChange={updateForm('duration')} required />
+            <input className="input" placeholder="Created by" value={form.createdBy} onChange={updateForm('createdBy')} required />
+            <input className="input" placeholder="Comment" value={form.comment} onChange={updateForm('comment')} />
+          </div>
+          <div className="mt-4 flex justify-end">
+            <button type="submit" className="btn btn-primary flex items-center space-x-2">
+              <BellOff className="h-4 w-4" />
+              <span>Create</span>
+            </button>
+          </div>
+        </form>
+
+        <div className="card p-6">
+          <h2 className="text-lg font-semibold flex items-center space-x-2 mb-4">
+            <BellOff className="h-5 w-5 text-warning-600 dark:text-warning-400" />
+            Silences
+          </h2>
+          {loading ? (
+            <div className="flex items-center justify-center py-8">
+              <Loader2 className="h-8 w-8 animate-spin text-primary-600" />
+            </div>
+          ) : silences.length > 0 ? (
+            <div className="space-y-4">
+              {silences.map((silence) => (
+                <div key={silence.id} className="bg-gray-50 dark:bg-gray-800 rounded-lg p-4 flex items-start justify-between">
+                  <div>
+                    <h3 className="font-medium">{describeMatchers(silence)}</h3>
+                    <p className="text-sm text-gray-600 dark:text-gray-400 mt-1">
+                      {formatDate(silence.starts_at)} - {formatDate(silence.ends_at)} · {silence.created_by}
+                      {silence.comment && ` · ${silence.comment}`}
+                    </p>
+                  </div>
+                  {isActive(silence) ? (
+                    <button
+                      onClick={() => handleExpire(silence.id)}
+                      className="btn btn-secondary flex items-center space-x-1"
+                    >
+                      <X className="h-4 w-4" />
+                      <span>Expire</span>
+                    </button>
+                  ) : (
+                    <span className="text-sm text-gray-500 dark:text-gray-400">Expired</span>
+                  )}
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="text-center py-8 text-gray-500 dark:text-gray-400">
+              No silences found
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default SilencesPage